@@ -0,0 +1,47 @@
+package main
+
+import (
+	"ai-novel-platform/internal/service"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// minAdminPasswordLength 管理员密码最短长度，比普通用户更严格
+const minAdminPasswordLength = 10
+
+// runCreateAdmin 处理 create-admin 子命令，创建管理员或把已有用户提升为管理员：
+//
+//	go run ./cmd create-admin -username admin -email admin@example.com
+//
+// 密码优先读取 -password，未提供时读取环境变量 ADMIN_PASSWORD，避免出现在 shell 历史中
+func runCreateAdmin(userService *service.UserService, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "管理员用户名")
+	email := fs.String("email", "", "管理员邮箱（创建新用户时必填）")
+	password := fs.String("password", "", "管理员密码，默认读取 ADMIN_PASSWORD")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *password == "" {
+		*password = os.Getenv("ADMIN_PASSWORD")
+	}
+	*username = strings.TrimSpace(*username)
+	*email = strings.TrimSpace(*email)
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+	if len(*password) < minAdminPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minAdminPasswordLength)
+	}
+
+	user, err := userService.EnsureAdmin(*username, *email, *password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Admin %q (id %d) is ready\n", user.Username, user.ID)
+	return nil
+}
@@ -1,19 +1,43 @@
 package main
 
 import (
+	"ai-novel-platform/config"
 	"ai-novel-platform/internal/api/handlers"
 	"ai-novel-platform/internal/middleware"
 	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/moderation"
+	"ai-novel-platform/internal/policy"
+	"ai-novel-platform/internal/realtime"
+	"ai-novel-platform/internal/search"
 	"ai-novel-platform/internal/service"
 	"ai-novel-platform/internal/utils"
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// shutdownTimeout 收到退出信号后等待进行中的请求和最后一次落库的时间
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	// 加载配置，校验失败（如生产环境仍使用默认密钥）时拒绝启动
+	cfg, err := config.Load(config.Path())
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	log.Printf("Starting in %s environment", cfg.Env)
+	gin.SetMode(cfg.Server.Mode)
+	middleware.ConfigureJWT(cfg.JWT.Secret, time.Duration(cfg.JWT.Expire))
+
 	// 初始化数据库连接
-	db, err := utils.InitDB("novel_user", "novel_password", "localhost", "3306", "novel_platform")
+	db, err := utils.InitDB(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -21,8 +45,13 @@ func main() {
 	// 删除现有表（仅在开发环境使用）
 	//db.Migrator().DropTable(&models.Novel{}, &models.User{}, &models.Favorite{}, &models.Chapter{})
 
+	// 唯一索引创建前清理重复数据
+	if err := service.DeduplicateReadProgress(db); err != nil {
+		log.Fatalf("Failed to deduplicate reading progress: %v", err)
+	}
+
 	// 自动迁移数据库表
-	if err := db.AutoMigrate(&models.User{}, &models.Novel{}, &models.Favorite{}, &models.Chapter{}, &models.ReadProgress{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Novel{}, &models.Favorite{}, &models.Chapter{}, &models.ReadProgress{}, &models.OutlineVersion{}, &models.Bookmark{}, &models.Annotation{}, &models.Comment{}, &models.CommentLike{}, &models.CommentReport{}, &models.Review{}, &models.ReviewVote{}, &models.Follow{}, &models.Notification{}, &models.NotificationDispatch{}, &models.NotificationOutbox{}, &models.NotificationPreference{}, &models.FeedToken{}, &models.RankingEntry{}, &models.ReadStat{}, &models.NovelNeighbor{}, &models.Tag{}, &models.TagSynonym{}, &models.NovelTag{}, &models.Category{}, &models.AuditLog{}, &models.ModerationItem{}, &models.ChapterFingerprint{}, &models.DuplicateFlag{}); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
@@ -31,43 +60,112 @@ func main() {
 		log.Printf("Warning: Failed to modify novel_outline column type: %v", err)
 	}
 
+	userService := service.NewUserService(db)
+	if err := userService.BackfillAuthorRoles(); err != nil {
+		log.Printf("Warning: Failed to backfill author roles: %v", err)
+	}
+	// 子命令：create-admin 创建管理员后退出
+	if len(os.Args) > 1 && os.Args[1] == "create-admin" {
+		if err := runCreateAdmin(userService, os.Args[2:]); err != nil {
+			log.Fatalf("Failed to create admin: %v", err)
+		}
+		return
+	}
+	middleware.SetRoleLoader(userService.GetRole)
+	middleware.SetSessionValidator(userService.ValidateSession)
+
 	// 初始化Redis连接
-	rdb, err := utils.InitRedis("localhost", "6379", "", 0)
+	rdb, err := utils.InitRedis(cfg.Redis)
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 	defer rdb.Close()
 
+	// SIGINT/SIGTERM 时先停止接收请求，再结束后台任务，保证阅读量和阅读进度最后一次落库
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// 初始化Gin
 	r := gin.Default()
+	// 客户端 IP 用于限流和审计，只信任配置中的代理转发的 X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+	r.Use(middleware.RequestID())
 
 	// 使用 CORS 中间件
-	r.Use(func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		if origin == "http://localhost:5173" {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-			c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-			c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range")
-
-			if c.Request.Method == "OPTIONS" {
-				c.AbortWithStatus(204)
-				return
-			}
-		}
-		c.Next()
-	})
+	r.Use(middleware.CORS(cfg.CORS.AllowedOrigins))
 
 	// 初始化服务和处理器
-	userService := service.NewUserService(db)
-	userHandler := handlers.NewUserHandler(userService, "your_jwt_secret")
-	novelService := service.NewNovelService(db)
-	novelHandler := handlers.NewNovelHandler(novelService)
-	chapterService := service.NewChapterService(db)
-	chapterHandler := handlers.NewChapterHandler(chapterService, novelService)
-	readProgressService := service.NewReadProgressService(db)
+	hub := realtime.NewHub(rdb)
+	hub.Start(ctx)
+	realtimeHandler := handlers.NewRealtimeHandler(hub, cfg.CORS.AllowedOrigins)
+	notificationService := service.NewNotificationService(db, hub)
+	notificationDone := notificationService.Start(ctx)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	auditService := service.NewAuditService(db)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	categoryService := service.NewCategoryService(db)
+	if err := categoryService.EnsureDefaultCategories(); err != nil {
+		log.Printf("Warning: Failed to seed default categories: %v", err)
+	}
+	// 把旧的自由文本分类映射到分类目录，需在搜索索引重建之前完成
+	if err := categoryService.MigrateLegacyCategories(context.Background()); err != nil {
+		log.Printf("Warning: Failed to migrate novel categories: %v", err)
+	}
+	categoryHandler := handlers.NewCategoryHandler(categoryService, auditService)
+	// 搜索服务先创建，Start 中的索引重建需等标签迁移完成
+	searchService := service.NewSearchService(db, search.NewMemoryIndex())
+	tagService := service.NewTagService(db, searchService)
+	if err := tagService.EnsureDefaultTags(); err != nil {
+		log.Printf("Warning: Failed to seed default tags: %v", err)
+	}
+	// 迁移旧的 JSON 标签，需在搜索索引重建之前完成
+	if err := tagService.MigrateLegacyTags(context.Background()); err != nil {
+		log.Printf("Warning: Failed to migrate novel tags: %v", err)
+	}
+	tagHandler := handlers.NewTagHandler(tagService, auditService)
+	searchService.Start(ctx)
+	searchHandler := handlers.NewSearchHandler(searchService, categoryService)
+	rankingService := service.NewRankingService(db, rdb)
+	rankingService.Start(ctx)
+	rankingHandler := handlers.NewRankingHandler(rankingService)
+	readTracker := service.NewReadTrackerService(db, rdb, rankingService)
+	readTrackerDone := readTracker.StartFlusher(ctx, time.Minute)
+	novelService := service.NewNovelService(db, searchService, rankingService, tagService, categoryService)
+	recommendationService := service.NewRecommendationService(db)
+	recommendationService.Start(ctx)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService)
+	duplicateService := service.NewDuplicateService(db)
+	duplicateService.Start(ctx)
+	duplicateHandler := handlers.NewDuplicateHandler(duplicateService, auditService)
+	readProgressService := service.NewReadProgressService(db, rdb)
+	chapterService := service.NewChapterService(db, notificationService, searchService, duplicateService, readProgressService)
+	readProgressDone := readProgressService.StartFlusher(ctx, 5*time.Second)
 	readProgressHandler := handlers.NewReadProgressHandler(readProgressService)
+	annotationService := service.NewAnnotationService(db)
+	annotationHandler := handlers.NewAnnotationHandler(annotationService)
+	commentService := service.NewCommentService(db, notificationService)
+	adminService := service.NewAdminService(db, searchService, tagService, readProgressService)
+	adminHandler := handlers.NewAdminHandler(userService, adminService, commentService, auditService)
+	// 敏感词库修改后自动重新加载
+	moderationService := service.NewModerationService(db, moderation.NewWordList(cfg.Moderation.WordList), chapterService, commentService, adminService)
+	moderationService.Start(ctx)
+	moderationHandler := handlers.NewModerationHandler(moderationService, auditService)
+	userHandler := handlers.NewUserHandler(userService, auditService, moderationService)
+	novelHandler := handlers.NewNovelHandler(novelService, recommendationService, auditService, moderationService)
+	commentHandler := handlers.NewCommentHandler(commentService, moderationService)
+	chapterHandler := handlers.NewChapterHandler(chapterService, novelService, commentService, readTracker, auditService, moderationService)
+	reviewService := service.NewReviewService(db, notificationService)
+	reviewHandler := handlers.NewReviewHandler(reviewService)
+	followService := service.NewFollowService(db, notificationService)
+	followHandler := handlers.NewFollowHandler(followService)
+	feedService := service.NewFeedService(db, cfg.Site.BaseURL)
+	feedHandler := handlers.NewFeedHandler(feedService, cfg.Site.APIBaseURL)
+	opdsService := service.NewOPDSService(db, userService, cfg.Site.BaseURL)
+	opdsHandler := handlers.NewOPDSHandler(opdsService, userService, auditService, cfg.Site.APIBaseURL)
 
 	// 用户相关路由
 	auth := r.Group("/api/v1/auth")
@@ -87,6 +185,108 @@ func main() {
 		user.PUT("/profile", userHandler.UpdateUser)
 		user.PUT("/password", userHandler.UpdatePassword)
 		user.POST("/avatar", userHandler.UploadAvatar)
+		user.GET("/security-activity", auditHandler.GetSecurityActivity)
+		user.GET("/moderation", moderationHandler.ListMyItems)
+		user.GET("/history", readProgressHandler.GetReadingHistory)
+		user.DELETE("/history/:novelId", readProgressHandler.DeleteReadingHistory)
+		user.DELETE("/history", readProgressHandler.ClearReadingHistory)
+		user.GET("/feed-token", opdsHandler.GetFeedToken)
+		user.POST("/feed-token", opdsHandler.RotateFeedToken)
+		user.DELETE("/feed-token", opdsHandler.RevokeFeedToken)
+	}
+
+	// 关注相关路由
+	users := r.Group("/api/v1/users")
+	{
+		users.GET("/:id/follow-stats", middleware.OptionalJWTAuth(), followHandler.GetFollowStats)
+		users.GET("/:id/followers", followHandler.ListFollowers)
+		users.GET("/:id/following", followHandler.ListFollowing)
+		users.POST("/:id/follow", middleware.JWTAuth(), followHandler.Follow)
+		users.DELETE("/:id/follow", middleware.JWTAuth(), followHandler.Unfollow)
+	}
+
+	// 实时推送（WebSocket）
+	r.GET("/api/v1/ws", realtimeHandler.Connect)
+	r.POST("/api/v1/ws/job-progress", middleware.JWTAuth(), realtimeHandler.ReportJobProgress)
+
+	// 全文搜索
+	r.GET("/api/v1/search", searchHandler.Search)
+	r.GET("/api/v1/search/suggest", searchHandler.Suggest)
+
+	// 排行榜
+	r.GET("/api/v1/rankings/:board", rankingHandler.GetRanking)
+
+	// 分类目录
+	r.GET("/api/v1/categories", categoryHandler.ListCategories)
+
+	// 管理后台
+	admin := r.Group("/api/v1/admin")
+	admin.Use(middleware.JWTAuth())
+	{
+		admin.GET("/stats", middleware.RequirePermission(policy.PermUserManage), adminHandler.GetStats)
+		admin.GET("/audit-logs", middleware.RequirePermission(policy.PermUserManage), auditHandler.ListAuditLogs)
+		admin.GET("/roles", middleware.RequirePermission(policy.PermRoleManage), adminHandler.ListRoles)
+		admin.GET("/users", middleware.RequirePermission(policy.PermUserManage), adminHandler.ListUsers)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(policy.PermRoleManage), adminHandler.UpdateUserRole)
+		admin.POST("/users/:id/ban", middleware.RequirePermission(policy.PermUserManage), adminHandler.BanUser)
+		admin.DELETE("/users/:id/ban", middleware.RequirePermission(policy.PermUserManage), adminHandler.UnbanUser)
+		admin.POST("/users/:id/force-password-reset", middleware.RequirePermission(policy.PermUserManage), adminHandler.ForcePasswordReset)
+		admin.GET("/novels/deleted", middleware.RequirePermission(policy.PermNovelModerate), adminHandler.ListDeletedNovels)
+		admin.POST("/novels/:id/hide", middleware.RequirePermission(policy.PermNovelModerate), adminHandler.HideNovel)
+		admin.DELETE("/novels/:id/hide", middleware.RequirePermission(policy.PermNovelModerate), adminHandler.UnhideNovel)
+		admin.POST("/novels/:id/restore", middleware.RequirePermission(policy.PermNovelModerate), adminHandler.RestoreNovel)
+		admin.GET("/duplicates", middleware.RequirePermission(policy.PermNovelModerate), duplicateHandler.ListDuplicates)
+		admin.GET("/duplicates/:id", middleware.RequirePermission(policy.PermNovelModerate), duplicateHandler.GetDuplicate)
+		admin.PUT("/duplicates/:id", middleware.RequirePermission(policy.PermNovelModerate), duplicateHandler.ReviewDuplicate)
+		admin.POST("/chapters/:id/hide", middleware.RequirePermission(policy.PermNovelModerate), adminHandler.HideChapter)
+		admin.DELETE("/chapters/:id/hide", middleware.RequirePermission(policy.PermNovelModerate), adminHandler.UnhideChapter)
+		admin.GET("/reports", middleware.RequirePermission(policy.PermReportReview), adminHandler.ListReports)
+		admin.PUT("/reports/:id", middleware.RequirePermission(policy.PermReportReview), adminHandler.ResolveReport)
+		admin.GET("/moderation", middleware.RequirePermission(policy.PermContentReview), moderationHandler.ListQueue)
+		admin.PUT("/moderation/:id", middleware.RequirePermission(policy.PermContentReview), moderationHandler.ReviewItem)
+		admin.POST("/moderation/words/reload", middleware.RequirePermission(policy.PermContentReview), moderationHandler.ReloadWords)
+		admin.POST("/categories", middleware.RequirePermission(policy.PermCategoryManage), categoryHandler.CreateCategory)
+		admin.PUT("/categories/:id", middleware.RequirePermission(policy.PermCategoryManage), categoryHandler.UpdateCategory)
+		admin.DELETE("/categories/:id", middleware.RequirePermission(policy.PermCategoryManage), categoryHandler.DeleteCategory)
+		admin.POST("/tags/:id/merge", middleware.RequirePermission(policy.PermTagManage), tagHandler.MergeTag)
+		admin.POST("/tags/:id/synonyms", middleware.RequirePermission(policy.PermTagManage), tagHandler.AddSynonym)
+		admin.DELETE("/tags/:id/synonyms/:name", middleware.RequirePermission(policy.PermTagManage), tagHandler.DeleteSynonym)
+		admin.PUT("/tags/:id/category", middleware.RequirePermission(policy.PermTagManage), tagHandler.UpdateCategory)
+	}
+
+	// 标签
+	r.GET("/api/v1/tags", tagHandler.ListTags)
+	r.GET("/api/v1/tags/novels", tagHandler.ListNovelsByTags)
+	r.GET("/api/v1/tags/:name", tagHandler.GetTag)
+
+	// 个性化推荐
+	r.GET("/api/v1/recommendations", middleware.OptionalJWTAuth(), recommendationHandler.GetRecommendations)
+
+	// 作者订阅源
+	r.GET("/api/v1/authors/:id/feed.atom", feedHandler.AuthorFeed)
+
+	// OPDS 书库，供电子阅读器使用 HTTP Basic 认证或订阅令牌访问
+	for _, opds := range []*gin.RouterGroup{r.Group("/opds"), r.Group("/opds/t/:token")} {
+		opds.Use(opdsHandler.Authenticate())
+		opds.GET("", opdsHandler.Root)
+		opds.GET("/latest", opdsHandler.Latest)
+		opds.GET("/popular", opdsHandler.Popular)
+		opds.GET("/categories", opdsHandler.Categories)
+		opds.GET("/categories/:category", opdsHandler.Category)
+		opds.GET("/favorites", opdsHandler.Favorites)
+		opds.GET("/novels/:id/book.epub", opdsHandler.DownloadBook)
+	}
+
+	// 通知相关路由
+	notifications := r.Group("/api/v1/notifications")
+	notifications.Use(middleware.JWTAuth())
+	{
+		notifications.GET("", notificationHandler.ListNotifications)
+		notifications.GET("/unread-count", notificationHandler.GetUnreadCounts)
+		notifications.PUT("/read", notificationHandler.MarkRead)
+		notifications.DELETE("/:id", notificationHandler.DeleteNotification)
+		notifications.GET("/preferences", notificationHandler.GetPreferences)
+		notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
 	}
 
 	// 小说相关路由
@@ -94,13 +294,16 @@ func main() {
 	{
 		// 公开接口
 		novels.GET("", novelHandler.ListNovels)
-		novels.GET("/:id", novelHandler.GetNovel)
+		novels.GET("/:id", middleware.OptionalJWTAuth(), novelHandler.GetNovel)
+		novels.GET("/:id/rating", reviewHandler.GetNovelRating)
+		novels.GET("/:id/feed.atom", feedHandler.NovelFeed)
+		novels.GET("/:id/reviews", middleware.OptionalJWTAuth(), reviewHandler.ListReviews)
 
 		// 需要认证的接口
 		authorized := novels.Group("")
 		authorized.Use(middleware.JWTAuth())
 		{
-			authorized.POST("", novelHandler.CreateNovel)
+			authorized.POST("", middleware.RequirePermission(policy.PermNovelCreate), novelHandler.CreateNovel)
 			authorized.PUT("/:id", novelHandler.UpdateNovel)
 			authorized.PUT("/:id/status", novelHandler.UpdateNovelStatus)
 			authorized.DELETE("/:id", novelHandler.DeleteNovel)
@@ -108,10 +311,20 @@ func main() {
 			authorized.GET("/author/stats", novelHandler.GetAuthorStats)
 			authorized.GET("/:id/outline", novelHandler.GetNovelOutline)
 			authorized.PUT("/:id/outline", novelHandler.UpdateNovelOutline)
+			authorized.GET("/:id/outline/export", novelHandler.ExportNovelOutline)
+			authorized.POST("/:id/outline/import/preview", novelHandler.PreviewOutlineImport)
+			authorized.POST("/:id/outline/import", novelHandler.ImportNovelOutline)
+			authorized.GET("/:id/outline/versions", novelHandler.ListOutlineVersions)
+			authorized.GET("/:id/outline/versions/:version", novelHandler.GetOutlineVersion)
+			authorized.POST("/:id/outline/versions/:version/restore", novelHandler.RestoreOutlineVersion)
+			authorized.GET("/:id/outline/diff", novelHandler.DiffOutlineVersions)
 			authorized.GET("/favorite/:id", novelHandler.CheckFavorite)
 			authorized.POST("/favorite/:id", novelHandler.FavoriteNovel)
 			authorized.DELETE("/favorite/:id", novelHandler.UnfavoriteNovel)
 			authorized.GET("/favorites", novelHandler.GetFavorites)
+			authorized.GET("/:id/review", reviewHandler.GetMyReview)
+			authorized.PUT("/:id/review", reviewHandler.SaveReview)
+			authorized.DELETE("/:id/review", reviewHandler.DeleteReview)
 		}
 	}
 
@@ -136,8 +349,87 @@ func main() {
 		progress.GET("/novel/:novelId", readProgressHandler.GetReadProgress)
 	}
 
+	// 书签相关路由
+	bookmarks := r.Group("/api/v1/bookmarks")
+	bookmarks.Use(middleware.JWTAuth())
+	{
+		bookmarks.POST("", annotationHandler.CreateBookmark)
+		bookmarks.GET("", annotationHandler.ListBookmarks)
+		bookmarks.PUT("/:id", annotationHandler.UpdateBookmark)
+		bookmarks.DELETE("/:id", annotationHandler.DeleteBookmark)
+	}
+
+	// 划线笔记相关路由
+	annotations := r.Group("/api/v1/annotations")
+	annotations.Use(middleware.JWTAuth())
+	{
+		annotations.POST("", annotationHandler.CreateAnnotation)
+		annotations.GET("", annotationHandler.ListUserAnnotations)
+		annotations.GET("/export", annotationHandler.ExportAnnotations)
+		annotations.GET("/chapter/:chapterId", annotationHandler.ListChapterAnnotations)
+		annotations.PUT("/:id", annotationHandler.UpdateAnnotation)
+		annotations.DELETE("/:id", annotationHandler.DeleteAnnotation)
+	}
+
+	// 评论相关路由
+	comments := r.Group("/api/v1/comments")
+	{
+		// 公开接口，登录用户可获得点赞状态
+		public := comments.Group("")
+		public.Use(middleware.OptionalJWTAuth())
+		{
+			public.GET("", commentHandler.ListComments)
+			public.GET("/paragraphs", commentHandler.GetParagraphCommentCounts)
+			public.GET("/:id/replies", commentHandler.ListReplies)
+		}
+
+		authorized := comments.Group("")
+		authorized.Use(middleware.JWTAuth())
+		{
+			authorized.POST("", commentHandler.CreateComment)
+			authorized.DELETE("/:id", commentHandler.DeleteComment)
+			authorized.POST("/:id/like", commentHandler.LikeComment)
+			authorized.DELETE("/:id/like", commentHandler.UnlikeComment)
+			authorized.POST("/:id/pin", commentHandler.PinComment)
+			authorized.DELETE("/:id/pin", commentHandler.UnpinComment)
+			authorized.POST("/:id/report", commentHandler.ReportComment)
+			authorized.GET("/reports", commentHandler.ListCommentReports)
+			authorized.PUT("/reports/:id", commentHandler.ResolveCommentReport)
+		}
+	}
+
+	// 书评相关路由
+	reviews := r.Group("/api/v1/reviews")
+	reviews.Use(middleware.JWTAuth())
+	{
+		reviews.POST("/:id/helpful", reviewHandler.VoteHelpful)
+		reviews.DELETE("/:id/helpful", reviewHandler.UnvoteHelpful)
+	}
+
 	// 启动服务器
-	if err := r.Run(":8080"); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	srv := &http.Server{Addr: cfg.Server.Addr(), Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	<-sigCtx.Done()
+	stop()
+	log.Println("Shutting down server...")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	cancel()
+	for _, done := range []<-chan struct{}{readTrackerDone, readProgressDone, notificationDone} {
+		select {
+		case <-done:
+		case <-shutdownCtx.Done():
+			log.Printf("Timed out waiting for background flush")
+			return
+		}
 	}
+	log.Println("Server stopped")
 }
@@ -1,7 +1,335 @@
+// Package config 加载服务配置：内置默认值 → 配置文件（YAML 或 TOML）→ 环境配置文件 → 环境变量，
+// 后加载的覆盖先加载的，最后统一校验
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+const (
+	// DefaultPath 未通过 NOVEL_CONFIG 指定时使用的配置文件
+	DefaultPath = "config/config.yaml"
+	// DefaultJWTSecret 开发环境的默认密钥，生产环境禁止使用
+	DefaultJWTSecret = "your_jwt_secret"
+
+	minProductionSecretLength = 32
+)
+
+// Duration 配置中的时长，写作 Go 的时长格式，如 "24h"、"30s"
+type Duration time.Duration
+
+// UnmarshalText 解析时长字符串，YAML、TOML 和环境变量共用
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 输出时长字符串
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Config 服务配置
 type Config struct {
-    JWT struct {
-        Secret string
-        Expire time.Duration
-    }
-    // ... 其他配置
-} 
\ No newline at end of file
+	Env        string           `yaml:"env" toml:"env"`
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Database   DatabaseConfig   `yaml:"database" toml:"database"`
+	Redis      RedisConfig      `yaml:"redis" toml:"redis"`
+	JWT        JWTConfig        `yaml:"jwt" toml:"jwt"`
+	CORS       CORSConfig       `yaml:"cors" toml:"cors"`
+	Site       SiteConfig       `yaml:"site" toml:"site"`
+	Moderation ModerationConfig `yaml:"moderation" toml:"moderation"`
+}
+
+type ServerConfig struct {
+	Port int    `yaml:"port" toml:"port"`
+	Mode string `yaml:"mode" toml:"mode"` // gin 运行模式：debug、release、test，为空时生产环境用 release，其余用 debug
+	// 可信反向代理的 IP 或网段，只有来自这些地址的请求才采用 X-Forwarded-For 中的客户端 IP；为空时不信任任何代理
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	Host            string   `yaml:"host" toml:"host"`
+	Port            int      `yaml:"port" toml:"port"`
+	Username        string   `yaml:"username" toml:"username"`
+	Password        string   `yaml:"password" toml:"password"`
+	DBName          string   `yaml:"dbname" toml:"dbname"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	LogLevel        string   `yaml:"log_level" toml:"log_level"` // SQL 日志级别：silent、error、warn、info
+}
+
+type RedisConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+}
+
+type JWTConfig struct {
+	Secret string   `yaml:"secret" toml:"secret"`
+	Expire Duration `yaml:"expire" toml:"expire"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"` // 允许跨域访问 API 和 WebSocket 的前端地址
+}
+
+type SiteConfig struct {
+	BaseURL    string `yaml:"base_url" toml:"base_url"`         // 前端站点地址，用于订阅源和 OPDS 中的链接
+	APIBaseURL string `yaml:"api_base_url" toml:"api_base_url"` // 后端对外地址，用于订阅源的 self 链接、书库和订阅令牌地址
+}
+
+type ModerationConfig struct {
+	WordList string `yaml:"word_list" toml:"word_list"` // 敏感词库文件
+}
+
+// Default 开发环境的默认配置，与本地 docker 环境一致
+func Default() *Config {
+	return &Config{
+		Env:    EnvDevelopment,
+		Server: ServerConfig{Port: 8080},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            3306,
+			Username:        "novel_user",
+			Password:        "novel_password",
+			DBName:          "novel_platform",
+			MaxIdleConns:    10,
+			MaxOpenConns:    100,
+			ConnMaxLifetime: Duration(time.Hour),
+			LogLevel:        "info",
+		},
+		Redis:      RedisConfig{Host: "localhost", Port: 6379},
+		JWT:        JWTConfig{Secret: DefaultJWTSecret, Expire: Duration(24 * time.Hour)},
+		CORS:       CORSConfig{AllowedOrigins: []string{"http://localhost:5173"}},
+		Site:       SiteConfig{BaseURL: "http://localhost:5173", APIBaseURL: "http://localhost:8080"},
+		Moderation: ModerationConfig{WordList: "config/sensitive_words.txt"},
+	}
+}
+
+// Path 配置文件路径，可通过环境变量 NOVEL_CONFIG 指定
+func Path() string {
+	if path := os.Getenv("NOVEL_CONFIG"); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Load 加载并校验配置。配置文件不存在时只使用默认值和环境变量；
+// 运行环境确定后，同目录下的 config.<env>.yaml（或 .toml）存在时覆盖基础配置
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if err := loadFile(path, cfg, true); err != nil {
+		return nil, err
+	}
+
+	// 运行环境可以由环境变量指定，需要先确定才能选择环境配置文件
+	if env := os.Getenv(envPrefix + "ENV"); env != "" {
+		cfg.Env = env
+	}
+	ext := filepath.Ext(path)
+	profile := strings.TrimSuffix(path, ext) + "." + cfg.Env + ext
+	if err := loadFile(profile, cfg, true); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnv(cfg, os.LookupEnv); err != nil {
+		return nil, err
+	}
+	cfg.setDerivedDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile 按扩展名解析 YAML 或 TOML，只覆盖文件中出现的字段
+func loadFile(path string, cfg *Config, optional bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil // 空文件
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	default:
+		return fmt.Errorf("unsupported config format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) setDerivedDefaults() {
+	if c.Server.Mode == "" {
+		c.Server.Mode = "debug"
+		if c.IsProduction() {
+			c.Server.Mode = "release"
+		}
+	}
+}
+
+// IsProduction 是否为生产环境
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Validate 校验配置，返回所有问题而不是只返回第一个
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Env == EnvDevelopment || c.Env == EnvTest || c.Env == EnvProduction,
+		"env must be one of development, test, production, got %q", c.Env)
+	check(validPort(c.Server.Port), "server.port must be between 1 and 65535")
+	check(c.Server.Mode == "debug" || c.Server.Mode == "release" || c.Server.Mode == "test",
+		"server.mode must be one of debug, release, test, got %q", c.Server.Mode)
+	for _, proxy := range c.Server.TrustedProxies {
+		check(validProxy(proxy), "server.trusted_proxies contains invalid IP or CIDR %q", proxy)
+	}
+
+	check(c.Database.Host != "", "database.host is required")
+	check(validPort(c.Database.Port), "database.port must be between 1 and 65535")
+	check(c.Database.Username != "", "database.username is required")
+	check(c.Database.DBName != "", "database.dbname is required")
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns must be between 0 and max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	_, ok := logLevels[c.Database.LogLevel]
+	check(ok, "database.log_level must be one of silent, error, warn, info, got %q", c.Database.LogLevel)
+
+	check(c.Redis.Host != "", "redis.host is required")
+	check(validPort(c.Redis.Port), "redis.port must be between 1 and 65535")
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+
+	check(c.JWT.Secret != "", "jwt.secret is required")
+	check(c.JWT.Expire > 0, "jwt.expire must be positive")
+	if c.IsProduction() {
+		// 默认密钥写在公开的代码里，生产环境使用等于任何人都能伪造登录凭证
+		check(c.JWT.Secret != DefaultJWTSecret, "jwt.secret must be changed from the default value in production")
+		check(len(c.JWT.Secret) >= minProductionSecretLength,
+			"jwt.secret must be at least %d characters in production", minProductionSecretLength)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(validOrigin(origin), "cors.allowed_origins contains invalid origin %q", origin)
+	}
+	check(validBaseURL(c.Site.BaseURL), "site.base_url must be an absolute http(s) URL, got %q", c.Site.BaseURL)
+	check(validBaseURL(c.Site.APIBaseURL), "site.api_base_url must be an absolute http(s) URL, got %q", c.Site.APIBaseURL)
+	if c.IsProduction() {
+		// 默认值指向本地开发环境，生产环境漏配时订阅源链接和跨域设置都会失效
+		for _, origin := range c.CORS.AllowedOrigins {
+			check(!isLocalURL(origin), "cors.allowed_origins must not contain local address %q in production", origin)
+		}
+		check(!isLocalURL(c.Site.BaseURL), "site.base_url must not be a local address in production, got %q", c.Site.BaseURL)
+		check(!isLocalURL(c.Site.APIBaseURL), "site.api_base_url must not be a local address in production, got %q", c.Site.APIBaseURL)
+	}
+	check(c.Moderation.WordList != "", "moderation.word_list is required")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// validBaseURL 站点地址须为绝对的 http(s) 地址
+func validBaseURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validProxy 可信代理须为 IP 地址或 CIDR 网段
+func validProxy(proxy string) bool {
+	if strings.Contains(proxy, "/") {
+		_, _, err := net.ParseCIDR(proxy)
+		return err == nil
+	}
+	return net.ParseIP(proxy) != nil
+}
+
+// logLevels 数据库日志级别名称
+var logLevels = map[string]bool{"silent": true, "error": true, "warn": true, "info": true}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// validOrigin 跨域来源只能是协议加主机（和端口），不能带路径
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		(u.Path == "" || u.Path == "/") && u.RawQuery == ""
+}
+
+// isLocalURL 地址的主机是否为 localhost 或回环地址
+func isLocalURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// DSN MySQL 连接串
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.Username, c.Password, c.Host, c.Port, c.DBName)
+}
+
+// Addr Redis 地址
+func (c RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// Addr HTTP 服务监听地址
+func (c ServerConfig) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
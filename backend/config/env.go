@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// envPrefix 环境变量前缀，变量名由配置路径转换而来，如 jwt.secret 对应 NOVEL_JWT_SECRET，
+// database.max_open_conns 对应 NOVEL_DATABASE_MAX_OPEN_CONNS；列表用逗号分隔，值为空的变量视为未设置
+const envPrefix = "NOVEL_"
+
+// applyEnv 用环境变量覆盖配置，lookup 通常为 os.LookupEnv
+func applyEnv(cfg *Config, lookup func(string) (string, bool)) error {
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), strings.TrimSuffix(envPrefix, "_"), lookup)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := prefix + "_" + strings.ToUpper(strings.Split(field.Tag.Get("yaml"), ",")[0])
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && !fv.Addr().Type().Implements(textUnmarshalerType) {
+			if err := applyEnvStruct(fv, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setFromEnv(fv, raw); err != nil {
+			return fmt.Errorf("environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func setFromEnv(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,480 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/policy"
+	"ai-novel-platform/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	userService    *service.UserService
+	adminService   *service.AdminService
+	commentService *service.CommentService
+	auditService   *service.AuditService
+}
+
+func NewAdminHandler(userService *service.UserService, adminService *service.AdminService, commentService *service.CommentService, auditService *service.AuditService) *AdminHandler {
+	return &AdminHandler{
+		userService:    userService,
+		adminService:   adminService,
+		commentService: commentService,
+		auditService:   auditService,
+	}
+}
+
+// reasonRequest 需要填写原因的管理操作
+type reasonRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// GetStats 系统概况
+func (h *AdminHandler) GetStats(c *gin.Context) {
+	stats, err := h.adminService.Stats()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListRoles 获取所有角色及其权限
+func (h *AdminHandler) ListRoles(c *gin.Context) {
+	roles := make([]gin.H, 0, len(policy.Roles))
+	for _, role := range policy.Roles {
+		roles = append(roles, gin.H{
+			"role":        role,
+			"permissions": policy.Permissions(role),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// ListUsers 按用户名或邮箱、角色、封禁状态搜索用户
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	filter := service.UserFilter{
+		Keyword: c.Query("keyword"),
+		Role:    c.Query("role"),
+	}
+	if banned := c.Query("banned"); banned != "" {
+		value := banned == "true"
+		filter.Banned = &value
+	}
+
+	users, total, err := h.userService.SearchUsers(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户列表失败"})
+		return
+	}
+	items := make([]gin.H, 0, len(users))
+	for i := range users {
+		items = append(items, adminUserView(&users[i]))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// UpdateUserRole 修改用户角色
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !policy.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+	if !middleware.CurrentActor(c).CanAssignRole(uint(id), req.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "不能修改自己的角色"})
+		return
+	}
+
+	oldRole, err := h.userService.GetRole(uint(id))
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	user, err := h.userService.UpdateRole(uint(id), req.Role)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditUserRoleChange, TargetType: models.AuditTargetUser, TargetID: user.ID,
+		Detail: user.Username, Before: oldRole, After: user.Role,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":          user.ID,
+		"username":    user.Username,
+		"role":        user.Role,
+		"permissions": policy.Permissions(user.Role),
+	})
+}
+
+// BanUser 封禁用户，until 为空表示永久封禁
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	var req struct {
+		Reason string     `json:"reason" binding:"required,max=255"`
+		Until  *time.Time `json:"until"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "封禁截止时间必须晚于当前时间"})
+		return
+	}
+	target, ok := h.authorizeSanction(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.BanUser(target.ID, req.Until, req.Reason)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditUserBan, TargetType: models.AuditTargetUser, TargetID: user.ID,
+		Reason: req.Reason, Detail: user.Username, Before: banSummary(target), After: banSummary(user),
+	})
+
+	c.JSON(http.StatusOK, adminUserView(user))
+}
+
+// UnbanUser 解除封禁
+func (h *AdminHandler) UnbanUser(c *gin.Context) {
+	target, ok := h.authorizeSanction(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.UnbanUser(target.ID)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditUserUnban, TargetType: models.AuditTargetUser, TargetID: user.ID,
+		Detail: user.Username, Before: banSummary(target), After: banSummary(user),
+	})
+
+	c.JSON(http.StatusOK, adminUserView(user))
+}
+
+// ForcePasswordReset 要求用户重置密码，用户需通过找回密码流程设置新密码后才能登录
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	var req reasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	target, ok := h.authorizeSanction(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.ForcePasswordReset(target.ID)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditUserForceReset, TargetType: models.AuditTargetUser, TargetID: user.ID,
+		Reason: req.Reason, Detail: user.Username,
+	})
+
+	c.JSON(http.StatusOK, adminUserView(user))
+}
+
+// authorizeSanction 读取目标用户并检查能否对其执行封禁等操作，不能时已写入错误响应
+func (h *AdminHandler) authorizeSanction(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+	user, err := h.userService.GetUserByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	if !middleware.CurrentActor(c).CanSanctionUser(user.ID, user.Role) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "不能对自己或管理员执行此操作"})
+		return nil, false
+	}
+	return user, true
+}
+
+// ListDeletedNovels 获取已删除的小说
+func (h *AdminHandler) ListDeletedNovels(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	novels, total, err := h.adminService.ListDeletedNovels(page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取已删除小说失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"novels": novels,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// HideNovel 隐藏小说
+func (h *AdminHandler) HideNovel(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid novel ID")
+	if !ok {
+		return
+	}
+	var req reasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	novel, err := h.adminService.HideNovel(id, req.Reason)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditNovelHide, TargetType: models.AuditTargetNovel, TargetID: novel.ID,
+		Reason: req.Reason, Detail: novel.Title,
+	})
+
+	c.JSON(http.StatusOK, novel)
+}
+
+// UnhideNovel 取消隐藏小说
+func (h *AdminHandler) UnhideNovel(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid novel ID")
+	if !ok {
+		return
+	}
+
+	novel, err := h.adminService.UnhideNovel(id)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditNovelUnhide, TargetType: models.AuditTargetNovel, TargetID: novel.ID, Detail: novel.Title,
+	})
+
+	c.JSON(http.StatusOK, novel)
+}
+
+// RestoreNovel 恢复已删除的小说
+func (h *AdminHandler) RestoreNovel(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid novel ID")
+	if !ok {
+		return
+	}
+
+	novel, err := h.adminService.RestoreNovel(id)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditNovelRestore, TargetType: models.AuditTargetNovel, TargetID: novel.ID, Detail: novel.Title,
+	})
+
+	c.JSON(http.StatusOK, novel)
+}
+
+// HideChapter 隐藏已发布的章节
+func (h *AdminHandler) HideChapter(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid chapter ID")
+	if !ok {
+		return
+	}
+	var req reasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	chapter, err := h.adminService.HideChapter(id, req.Reason)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditChapterHide, TargetType: models.AuditTargetChapter, TargetID: chapter.ID,
+		Reason: req.Reason, Detail: chapter.Title,
+	})
+
+	c.JSON(http.StatusOK, chapter)
+}
+
+// UnhideChapter 取消隐藏章节
+func (h *AdminHandler) UnhideChapter(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid chapter ID")
+	if !ok {
+		return
+	}
+
+	chapter, err := h.adminService.UnhideChapter(id)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditChapterUnhide, TargetType: models.AuditTargetChapter, TargetID: chapter.ID, Detail: chapter.Title,
+	})
+
+	c.JSON(http.StatusOK, chapter)
+}
+
+// ListReports 获取全站评论举报，status 默认为待处理，传 -1 获取全部
+func (h *AdminHandler) ListReports(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	status, err := strconv.Atoi(c.DefaultQuery("status", strconv.Itoa(models.CommentReportPending)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	reports, total, err := h.commentService.ListAllCommentReports(status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取举报列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ResolveReport 处理举报：remove 删除评论，reject 驳回
+func (h *AdminHandler) ResolveReport(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid report ID")
+	if !ok {
+		return
+	}
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=remove reject"`
+		Reason string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.commentService.ResolveCommentReport(middleware.CurrentActor(c), id, req.Action); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.audit(c, models.AuditLog{
+		Action: service.AuditReportResolve, TargetType: models.AuditTargetReport, TargetID: id,
+		Reason: req.Reason, After: req.Action,
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved successfully"})
+}
+
+// audit 记录管理操作
+func (h *AdminHandler) audit(c *gin.Context, entry models.AuditLog) {
+	recordAudit(c, h.auditService, entry)
+}
+
+// banSummary 封禁状态摘要，用于审计日志
+func banSummary(user *models.User) string {
+	switch {
+	case !user.IsBanned(time.Now()):
+		return "active"
+	case user.BannedUntil == nil:
+		return "banned permanently"
+	default:
+		return "banned until " + user.BannedUntil.Format(time.RFC3339)
+	}
+}
+
+// adminUserView 管理后台展示的用户信息，不包含密码等敏感字段
+func adminUserView(user *models.User) gin.H {
+	return gin.H{
+		"id":                    user.ID,
+		"username":              user.Username,
+		"email":                 user.Email,
+		"avatar":                user.Avatar,
+		"role":                  user.Role,
+		"banned":                user.IsBanned(time.Now()),
+		"bannedAt":              user.BannedAt,
+		"bannedUntil":           user.BannedUntil,
+		"banReason":             user.BanReason,
+		"passwordResetRequired": user.PasswordResetRequired,
+		"createdAt":             user.CreatedAt,
+	}
+}
+
+func parseAdminID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func parsePageQuery(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+func respondAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case errors.Is(err, service.ErrNovelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+	case errors.Is(err, service.ErrChapterNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+	case errors.Is(err, service.ErrLastAdmin):
+		c.JSON(http.StatusConflict, gin.H{"error": "至少需要保留一个管理员"})
+	case errors.Is(err, service.ErrNovelNotDeleted),
+		errors.Is(err, service.ErrChapterNotPublished),
+		errors.Is(err, service.ErrChapterNotHidden):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnnotationHandler struct {
+	annotationService *service.AnnotationService
+}
+
+func NewAnnotationHandler(annotationService *service.AnnotationService) *AnnotationHandler {
+	return &AnnotationHandler{annotationService: annotationService}
+}
+
+// CreateBookmark 添加书签
+func (h *AnnotationHandler) CreateBookmark(c *gin.Context) {
+	var req struct {
+		ChapterID uint   `json:"chapterId" binding:"required"`
+		Offset    int    `json:"offset"`
+		Color     string `json:"color"`
+		Note      string `json:"note"`
+		IsPublic  bool   `json:"isPublic"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bookmark := models.Bookmark{ChapterID: req.ChapterID, Offset: req.Offset, Color: req.Color, Note: req.Note, IsPublic: req.IsPublic}
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.annotationService.CreateBookmark(userID, &bookmark); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, bookmark)
+}
+
+// ListBookmarks 获取书签列表
+func (h *AnnotationHandler) ListBookmarks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	novelID, _ := strconv.ParseUint(c.DefaultQuery("novelId", "0"), 10, 32)
+	userID := utils.GetUserIDFromContext(c)
+
+	bookmarks, total, err := h.annotationService.ListBookmarks(userID, uint(novelID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取书签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bookmarks": bookmarks,
+		"total":     total,
+		"page":      page,
+		"limit":     limit,
+	})
+}
+
+// UpdateBookmark 修改书签颜色、备注和公开状态
+func (h *AnnotationHandler) UpdateBookmark(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	var req struct {
+		Color    string `json:"color"`
+		Note     string `json:"note"`
+		IsPublic bool   `json:"isPublic"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	bookmark, err := h.annotationService.UpdateBookmark(userID, uint(id), req.Color, req.Note, req.IsPublic)
+	if err != nil {
+		if err == service.ErrBookmarkNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bookmark)
+}
+
+// DeleteBookmark 删除书签
+func (h *AnnotationHandler) DeleteBookmark(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bookmark ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.annotationService.DeleteBookmark(userID, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark deleted successfully"})
+}
+
+// CreateAnnotation 添加划线批注
+func (h *AnnotationHandler) CreateAnnotation(c *gin.Context) {
+	var req struct {
+		ChapterID   uint   `json:"chapterId" binding:"required"`
+		StartOffset int    `json:"startOffset"`
+		EndOffset   int    `json:"endOffset" binding:"required"`
+		Color       string `json:"color"`
+		Note        string `json:"note"`
+		IsPublic    bool   `json:"isPublic"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	annotation := models.Annotation{
+		ChapterID:   req.ChapterID,
+		StartOffset: req.StartOffset,
+		EndOffset:   req.EndOffset,
+		Color:       req.Color,
+		Note:        req.Note,
+		IsPublic:    req.IsPublic,
+	}
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.annotationService.CreateAnnotation(userID, &annotation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// UpdateAnnotation 修改批注
+func (h *AnnotationHandler) UpdateAnnotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid annotation ID"})
+		return
+	}
+
+	var req struct {
+		Color    string `json:"color"`
+		Note     string `json:"note"`
+		IsPublic bool   `json:"isPublic"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	annotation, err := h.annotationService.UpdateAnnotation(userID, uint(id), req.Color, req.Note, req.IsPublic)
+	if err != nil {
+		if err == service.ErrAnnotationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, annotation)
+}
+
+// DeleteAnnotation 删除批注
+func (h *AnnotationHandler) DeleteAnnotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid annotation ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.annotationService.DeleteAnnotation(userID, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Annotation deleted successfully"})
+}
+
+// ListChapterAnnotations 获取章节内的批注和书签（自己的和他人公开的）
+func (h *AnnotationHandler) ListChapterAnnotations(c *gin.Context) {
+	chapterID, err := strconv.ParseUint(c.Param("chapterId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chapter ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	annotations, err := h.annotationService.ListChapterAnnotations(userID, uint(chapterID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	bookmarks, err := h.annotationService.ListChapterBookmarks(userID, uint(chapterID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取书签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"annotations": annotations, "bookmarks": bookmarks})
+}
+
+// ListUserAnnotations 获取我在所有小说中的笔记
+func (h *AnnotationHandler) ListUserAnnotations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	novelID, _ := strconv.ParseUint(c.DefaultQuery("novelId", "0"), 10, 32)
+	userID := utils.GetUserIDFromContext(c)
+
+	entries, total, err := h.annotationService.ListUserAnnotations(userID, uint(novelID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取笔记失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"annotations": entries,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+	})
+}
+
+// ExportAnnotations 导出笔记为 Markdown
+func (h *AnnotationHandler) ExportAnnotations(c *gin.Context) {
+	novelID, _ := strconv.ParseUint(c.DefaultQuery("novelId", "0"), 10, 32)
+	userID := utils.GetUserIDFromContext(c)
+
+	data, err := h.annotationService.ExportAnnotationsMarkdown(userID, uint(novelID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出笔记失败"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="notes.md"`)
+	c.Data(http.StatusOK, "text/markdown; charset=utf-8", data)
+}
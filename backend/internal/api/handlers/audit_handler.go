@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+}
+
+func NewAuditHandler(auditService *service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAuditLogs 管理员查询审计日志，支持按操作者、动作、对象、请求ID和时间范围（RFC3339）筛选
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	filter := service.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		RequestID:  c.Query("requestId"),
+	}
+	if actorID, err := strconv.ParseUint(c.DefaultQuery("actorId", "0"), 10, 32); err == nil {
+		filter.ActorID = uint(actorID)
+	}
+	if targetID, err := strconv.ParseUint(c.DefaultQuery("targetId", "0"), 10, 32); err == nil {
+		filter.TargetID = uint(targetID)
+	}
+	for key, dest := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(key)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key + " time"})
+			return
+		}
+		*dest = t
+	}
+
+	logs, total, err := h.auditService.List(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审计日志失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"logs":  logs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetSecurityActivity 当前用户的账号安全记录
+func (h *AuditHandler) GetSecurityActivity(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	events, total, err := h.auditService.ListSecurityActivity(middleware.GetUserID(c), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取安全记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// recordAudit 记录审计日志，补全请求来源信息；未指定操作者时使用当前登录用户。
+// 写入失败只记录日志，不影响已经完成的操作
+func recordAudit(c *gin.Context, auditService *service.AuditService, entry models.AuditLog) {
+	if entry.ActorID == 0 {
+		entry.ActorID = middleware.GetUserID(c)
+	}
+	entry.IP = c.ClientIP()
+	entry.UserAgent = c.Request.UserAgent()
+	entry.RequestID = middleware.GetRequestID(c)
+	if err := auditService.Record(&entry); err != nil {
+		log.Printf("Failed to record audit log %s on %s %d (request %s): %v", entry.Action, entry.TargetType, entry.TargetID, entry.RequestID, err)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	categoryService *service.CategoryService
+	auditService    *service.AuditService
+}
+
+func NewCategoryHandler(categoryService *service.CategoryService, auditService *service.AuditService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService, auditService: auditService}
+}
+
+// ListCategories 获取分类树及各分类下的小说数
+func (h *CategoryHandler) ListCategories(c *gin.Context) {
+	tree, err := h.categoryService.GetTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取分类失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": tree})
+}
+
+// CreateCategory 创建分类
+func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	var category models.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category.ID = 0
+
+	if err := h.categoryService.CreateCategory(&category); err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditCategoryCreate, TargetType: models.AuditTargetCategory, TargetID: category.ID,
+		After: category.Slug + " " + category.Name,
+	})
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory 更新分类，修改 slug 会同步到引用该分类的小说
+func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+	var input models.Category
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(uint(id), &input)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditCategoryUpdate, TargetType: models.AuditTargetCategory, TargetID: category.ID,
+		After: category.Slug + " " + category.Name,
+	})
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory 删除没有子分类和小说的分类
+func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(uint(id)); err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditCategoryDelete, TargetType: models.AuditTargetCategory, TargetID: uint(id),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
+
+func respondCategoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+	case errors.Is(err, service.ErrInvalidCategory):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrCategoryInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": "分类下仍有子分类或小说，无法删除"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,46 +1,93 @@
 package handlers
 
 import (
+	"ai-novel-platform/internal/middleware"
 	"ai-novel-platform/internal/models"
 	"ai-novel-platform/internal/service"
 	"ai-novel-platform/internal/utils"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
 	"strconv"
 )
 
 type ChapterHandler struct {
-	chapterService *service.ChapterService
-	novelService   *service.NovelService
+	chapterService    *service.ChapterService
+	novelService      *service.NovelService
+	commentService    *service.CommentService
+	readTracker       *service.ReadTrackerService
+	auditService      *service.AuditService
+	moderationService *service.ModerationService
 }
 
-func NewChapterHandler(chapterService *service.ChapterService, novelService *service.NovelService) *ChapterHandler {
+func NewChapterHandler(chapterService *service.ChapterService, novelService *service.NovelService, commentService *service.CommentService, readTracker *service.ReadTrackerService, auditService *service.AuditService, moderationService *service.ModerationService) *ChapterHandler {
 	return &ChapterHandler{
-		chapterService: chapterService,
-		novelService:   novelService,
+		chapterService:    chapterService,
+		novelService:      novelService,
+		commentService:    commentService,
+		readTracker:       readTracker,
+		auditService:      auditService,
+		moderationService: moderationService,
 	}
 }
 
 // CreateChapter 创建新章节
 func (h *ChapterHandler) CreateChapter(c *gin.Context) {
-	var chapter models.Chapter
-	if err := c.ShouldBindJSON(&chapter); err != nil {
+	// 只接受作者可填写的字段，阅读数、隐藏原因等由服务端维护
+	var req struct {
+		NovelID   uint   `json:"novelId" binding:"required"`
+		Title     string `json:"title" binding:"required"`
+		Content   string `json:"content"`
+		WordCount int    `json:"wordCount"`
+		Status    int    `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// 待审核和隐藏只能由审核流程和管理员设置
+	if req.Status != models.ChapterStatusDraft && req.Status != models.ChapterStatusPublished {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	chapter := models.Chapter{
+		NovelID:   req.NovelID,
+		Title:     req.Title,
+		Content:   req.Content,
+		WordCount: req.WordCount,
+		Status:    req.Status,
+	}
 
 	// 验证小说所有权
-	userID := utils.GetUserIDFromContext(c)
 	novel, err := h.novelService.GetNovel(chapter.NovelID)
-	if err != nil || novel.AuthorID != userID {
+	if err != nil || !middleware.CurrentActor(c).CanManageNovel(novel.AuthorID) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此小说"})
 		return
 	}
 
+	// 直接发布的章节先做敏感词检测，需要审核时改为待审核
+	var screen service.ScreenResult
+	if chapter.Status == models.ChapterStatusPublished {
+		var ok bool
+		if screen, ok = h.screenChapter(c, &chapter.Title, &chapter.Content); !ok {
+			return
+		}
+		if screen.NeedsReview() {
+			chapter.Status = models.ChapterStatusReviewing
+		}
+	}
+
 	if err := h.chapterService.CreateChapter(&chapter); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if screen.NeedsReview() {
+		enqueueForReview(h.moderationService, models.ModerationContentChapter, chapter.ID, novel.AuthorID, chapter.Title, screen)
+	}
+	if err := h.novelService.AddWordCount(novel.ID, chapter.WordCount); err != nil {
+		log.Printf("Failed to update word count of novel %d: %v", novel.ID, err)
+	}
 
 	c.JSON(http.StatusCreated, chapter)
 }
@@ -58,6 +105,21 @@ func (h *ChapterHandler) GetChapter(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
 		return
 	}
+	novel, err := h.novelService.GetNovel(chapter.NovelID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+		return
+	}
+	// 未发布的章节以及被隐藏小说的章节只对作者和有管理权限的用户可见
+	if chapter.Status != models.ChapterStatusPublished || novel.HiddenAt != nil {
+		if !middleware.CurrentActor(c).CanViewDraft(novel.AuthorID) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+			return
+		}
+	} else {
+		visitor := service.ReadVisitor(utils.GetUserIDFromContext(c), c.ClientIP(), c.Request.UserAgent())
+		h.readTracker.TrackRead(chapter.NovelID, chapter.ID, visitor, c.Request.UserAgent())
+	}
 
 	c.JSON(http.StatusOK, chapter)
 }
@@ -81,24 +143,43 @@ func (h *ChapterHandler) UpdateChapter(c *gin.Context) {
 		return
 	}
 
-	exist, err := h.chapterService.GetChapter(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+	exist, ok := h.authorizeChapter(c, uint(id))
+	if !ok {
 		return
 	}
+	// 已公开或审核中的章节修改后重新检测，需要审核时撤下等待审核
+	var screen service.ScreenResult
+	if exist.Status == models.ChapterStatusPublished || exist.Status == models.ChapterStatusReviewing {
+		if screen, ok = h.screenChapter(c, &updates.Title, &updates.Content); !ok {
+			return
+		}
+	}
 
 	if err := h.chapterService.UpdateChapterContent(uint(id), updates.Title, updates.Content, updates.WordCount); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// 内容变化后重新定位段评
+	if updates.Content != exist.Content {
+		if err := h.commentService.ReanchorParagraphComments(uint(id)); err != nil {
+			log.Printf("Failed to reanchor paragraph comments of chapter %d: %v", id, err)
+		}
+	}
+
 	novel, err := h.novelService.GetNovel(exist.NovelID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	novel.WordCount += updates.WordCount
-	if err := h.novelService.UpdateNovel(novel); err != nil {
+	if screen.NeedsReview() {
+		if err := h.chapterService.UpdateChapterStatus(uint(id), models.ChapterStatusReviewing); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		enqueueForReview(h.moderationService, models.ModerationContentChapter, exist.ID, novel.AuthorID, updates.Title, screen)
+	}
+	if err := h.novelService.AddWordCount(novel.ID, updates.WordCount-exist.WordCount); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -114,10 +195,23 @@ func (h *ChapterHandler) DeleteChapter(c *gin.Context) {
 		return
 	}
 
+	chapter, ok := h.authorizeChapter(c, uint(id))
+	if !ok {
+		return
+	}
+
 	if err := h.chapterService.DeleteChapter(uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.novelService.AddWordCount(chapter.NovelID, -chapter.WordCount); err != nil {
+		log.Printf("Failed to update word count of novel %d: %v", chapter.NovelID, err)
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditChapterDelete, TargetType: models.AuditTargetChapter, TargetID: chapter.ID,
+		Detail: chapter.Title,
+		Before: fmt.Sprintf("novel %d, chapter %d, %d words, status %d", chapter.NovelID, chapter.Order, chapter.WordCount, chapter.Status),
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Chapter deleted successfully"})
 }
@@ -130,7 +224,19 @@ func (h *ChapterHandler) ListNovelChapters(c *gin.Context) {
 		return
 	}
 
-	chapters, err := h.chapterService.ListNovelChapters(uint(novelID))
+	novel, err := h.novelService.GetNovel(uint(novelID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+		return
+	}
+	// 与 GetChapter 一致：草稿、审核中、被隐藏的章节以及被隐藏小说的章节只对作者和有管理权限的用户可见
+	canViewDraft := middleware.CurrentActor(c).CanViewDraft(novel.AuthorID)
+	if novel.HiddenAt != nil && !canViewDraft {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+		return
+	}
+
+	chapters, err := h.chapterService.ListNovelChapters(uint(novelID), canViewDraft)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -153,6 +259,10 @@ func (h *ChapterHandler) MoveChapter(c *gin.Context) {
 		return
 	}
 
+	if _, ok := h.authorizeChapter(c, uint(id)); !ok {
+		return
+	}
+
 	if err := h.chapterService.MoveChapter(uint(id), direction); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -179,23 +289,67 @@ func (h *ChapterHandler) UpdateChapterStatus(c *gin.Context) {
 	}
 
 	// 验证小说所有权
-	chapter, err := h.chapterService.GetChapter(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+	chapter, ok := h.authorizeChapter(c, uint(id))
+	if !ok {
 		return
 	}
-
-	userID := utils.GetUserIDFromContext(c)
-	novel, err := h.novelService.GetNovel(chapter.NovelID)
-	if err != nil || novel.AuthorID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此章节"})
+	if chapter.Status == models.ChapterStatusHidden || req.Status == models.ChapterStatusHidden {
+		c.JSON(http.StatusForbidden, gin.H{"error": "章节已被管理员隐藏，不能修改状态"})
 		return
 	}
 
+	// 发布前做敏感词检测：轻微的词打码后保存，需要审核时改为待审核
+	var screen service.ScreenResult
+	if req.Status == models.ChapterStatusPublished && chapter.Status != models.ChapterStatusPublished {
+		title, content := chapter.Title, chapter.Content
+		if screen, ok = h.screenChapter(c, &title, &content); !ok {
+			return
+		}
+		if title != chapter.Title || content != chapter.Content {
+			if err := h.chapterService.UpdateChapterContent(chapter.ID, title, content, chapter.WordCount); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if screen.NeedsReview() {
+			req.Status = models.ChapterStatusReviewing
+		}
+	}
+
 	if err := h.chapterService.UpdateChapterStatus(uint(id), req.Status); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if screen.NeedsReview() {
+		if novel, err := h.novelService.GetNovel(chapter.NovelID); err == nil {
+			enqueueForReview(h.moderationService, models.ModerationContentChapter, chapter.ID, novel.AuthorID, chapter.Title, screen)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Chapter status updated successfully", "status": req.Status})
+}
+
+// screenChapter 检测将要公开的章节标题和正文，轻微的词直接在原文上打码；内容被拒绝时已写入错误响应
+func (h *ChapterHandler) screenChapter(c *gin.Context, title, content *string) (service.ScreenResult, bool) {
+	screen := h.moderationService.Screen(models.ModerationContentChapter, title, content)
+	if err := screen.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return screen, false
+	}
+	return screen, true
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Chapter status updated successfully"})
+// authorizeChapter 读取章节并检查当前用户能否管理其所属小说，不能时已写入错误响应
+func (h *ChapterHandler) authorizeChapter(c *gin.Context, id uint) (*models.Chapter, bool) {
+	chapter, err := h.chapterService.GetChapter(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Chapter not found"})
+		return nil, false
+	}
+	novel, err := h.novelService.GetNovel(chapter.NovelID)
+	if err != nil || !middleware.CurrentActor(c).CanManageNovel(novel.AuthorID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此章节"})
+		return nil, false
+	}
+	return chapter, true
 }
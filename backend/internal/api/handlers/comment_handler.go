@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	commentService    *service.CommentService
+	moderationService *service.ModerationService
+}
+
+func NewCommentHandler(commentService *service.CommentService, moderationService *service.ModerationService) *CommentHandler {
+	return &CommentHandler{commentService: commentService, moderationService: moderationService}
+}
+
+// CreateComment 发表评论或回复
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	var req struct {
+		NovelID   uint   `json:"novelId"`
+		ChapterID uint   `json:"chapterId"`
+		ParentID  uint   `json:"parentId"`
+		Paragraph *int   `json:"paragraphIndex"` // 段评所在段落序号
+		Content   string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.NovelID == 0 && req.ParentID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "novelId or parentId is required"})
+		return
+	}
+
+	comment := models.Comment{
+		NovelID:        req.NovelID,
+		ChapterID:      req.ChapterID,
+		ParentID:       req.ParentID,
+		ParagraphIndex: req.Paragraph,
+		Content:        req.Content,
+	}
+	// 敏感词检测：轻微的词打码，需要审核的评论先保存但不公开
+	screen := h.moderationService.Screen(models.ModerationContentComment, &comment.Content)
+	if err := screen.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if screen.NeedsReview() {
+		comment.Status = models.CommentStatusPending
+	}
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.commentService.CreateComment(userID, &comment); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if screen.NeedsReview() {
+		enqueueForReview(h.moderationService, models.ModerationContentComment, comment.ID, userID, "", screen)
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListComments 获取小说或章节的一级评论
+func (h *CommentHandler) ListComments(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Query("novelId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+	chapterID, _ := strconv.ParseUint(c.DefaultQuery("chapterId", "0"), 10, 32)
+	sort := c.DefaultQuery("sort", service.CommentSortLatest)
+	limit := parseCommentLimit(c)
+
+	target := service.CommentTarget{NovelID: uint(novelID), ChapterID: uint(chapterID)}
+	// paragraph 为段落序号时查询该段的段评，为 orphaned 时查询无法重新定位的段评
+	if paragraph := c.Query("paragraph"); paragraph == "orphaned" {
+		target.Orphaned = true
+	} else if paragraph != "" {
+		index, err := strconv.Atoi(paragraph)
+		if err != nil || index < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid paragraph index"})
+			return
+		}
+		target.Paragraph = &index
+	}
+
+	viewerID := utils.GetUserIDFromContext(c)
+	page, err := h.commentService.ListComments(viewerID, target, sort, c.Query("cursor"), limit)
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ListReplies 获取一级评论下的回复
+func (h *CommentHandler) ListReplies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	viewerID := utils.GetUserIDFromContext(c)
+	page, err := h.commentService.ListReplies(viewerID, uint(id), c.Query("cursor"), parseCommentLimit(c))
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// GetParagraphCommentCounts 获取章节各段落的段评数量
+func (h *CommentHandler) GetParagraphCommentCounts(c *gin.Context) {
+	chapterID, err := strconv.ParseUint(c.Query("chapterId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid chapter ID"})
+		return
+	}
+
+	summary, err := h.commentService.GetParagraphCommentCounts(uint(chapterID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取段评数量失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// DeleteComment 删除评论
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	actor := middleware.CurrentActor(c)
+	if err := h.commentService.DeleteComment(actor, uint(id)); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// LikeComment 点赞评论
+func (h *CommentHandler) LikeComment(c *gin.Context) {
+	h.toggleLike(c, true)
+}
+
+// UnlikeComment 取消点赞
+func (h *CommentHandler) UnlikeComment(c *gin.Context) {
+	h.toggleLike(c, false)
+}
+
+func (h *CommentHandler) toggleLike(c *gin.Context, like bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	var count int
+	if like {
+		count, err = h.commentService.LikeComment(userID, uint(id))
+	} else {
+		count, err = h.commentService.UnlikeComment(userID, uint(id))
+	}
+	if err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"liked": like, "likeCount": count})
+}
+
+// PinComment 作者置顶评论
+func (h *CommentHandler) PinComment(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+// UnpinComment 作者取消置顶
+func (h *CommentHandler) UnpinComment(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+func (h *CommentHandler) setPinned(c *gin.Context, pinned bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	actor := middleware.CurrentActor(c)
+	if err := h.commentService.PinComment(actor, uint(id), pinned); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pinned": pinned})
+}
+
+// ReportComment 举报评论
+func (h *CommentHandler) ReportComment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.commentService.ReportComment(userID, uint(id), req.Reason); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment reported successfully"})
+}
+
+// ListCommentReports 获取作者小说下待处理的举报
+func (h *CommentHandler) ListCommentReports(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	userID := utils.GetUserIDFromContext(c)
+
+	reports, total, err := h.commentService.ListCommentReports(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取举报列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// ResolveCommentReport 处理举报
+func (h *CommentHandler) ResolveCommentReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=remove reject"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor := middleware.CurrentActor(c)
+	if err := h.commentService.ResolveCommentReport(actor, uint(id), req.Action); err != nil {
+		c.JSON(commentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report resolved successfully"})
+}
+
+func parseCommentLimit(c *gin.Context) int {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return limit
+}
+
+func commentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrCommentForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrCommentReportExist):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DuplicateHandler struct {
+	duplicateService *service.DuplicateService
+	auditService     *service.AuditService
+}
+
+func NewDuplicateHandler(duplicateService *service.DuplicateService, auditService *service.AuditService) *DuplicateHandler {
+	return &DuplicateHandler{duplicateService: duplicateService, auditService: auditService}
+}
+
+// ListDuplicates 疑似抄袭或重复上传的章节，默认只返回待处理的记录，status=-1 返回全部；
+// 传入 novelId 时只返回涉及该小说的记录，便于判断整本书是否为搬运
+func (h *DuplicateHandler) ListDuplicates(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	status, err := strconv.Atoi(c.DefaultQuery("status", strconv.Itoa(models.DuplicatePending)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	novelID, err := strconv.ParseUint(c.DefaultQuery("novelId", "0"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	entries, total, err := h.duplicateService.List(status, uint(novelID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取疑似重复列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"duplicates": entries,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// GetDuplicate 双方章节的逐段对照证据
+func (h *DuplicateHandler) GetDuplicate(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid duplicate ID")
+	if !ok {
+		return
+	}
+
+	evidence, err := h.duplicateService.GetEvidence(id)
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, evidence)
+}
+
+// ReviewDuplicate 确认抄袭或标记为误报。确认后如需下架，由审核人员通过隐藏章节或小说处理
+func (h *DuplicateHandler) ReviewDuplicate(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid duplicate ID")
+	if !ok {
+		return
+	}
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=confirm dismiss"`
+		Note   string `json:"note" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, action := models.DuplicateConfirmed, service.AuditDuplicateConfirm
+	if req.Action == "dismiss" {
+		status, action = models.DuplicateDismissed, service.AuditDuplicateDismiss
+	}
+	flag, err := h.duplicateService.Review(id, middleware.GetUserID(c), status, req.Note)
+	if err != nil {
+		respondDuplicateError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: action, TargetType: models.AuditTargetDuplicate, TargetID: flag.ID, Reason: req.Note,
+		Detail: fmt.Sprintf("chapter %d (author %d) vs chapter %d (author %d), similarity %.2f",
+			flag.ChapterID, flag.AuthorID, flag.MatchChapterID, flag.MatchAuthorID, flag.Similarity),
+	})
+
+	c.JSON(http.StatusOK, flag)
+}
+
+func respondDuplicateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrDuplicateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Duplicate not found"})
+	case errors.Is(err, service.ErrDuplicateReviewed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/feed"
+	"ai-novel-platform/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FeedHandler struct {
+	feedService *service.FeedService
+	apiBaseURL  string // 后端对外地址，用于 feed 的 self 链接，不使用请求中的 Host
+}
+
+func NewFeedHandler(feedService *service.FeedService, apiBaseURL string) *FeedHandler {
+	return &FeedHandler{feedService: feedService, apiBaseURL: strings.TrimRight(apiBaseURL, "/")}
+}
+
+// NovelFeed 小说更新的 Atom 订阅源
+func (h *FeedHandler) NovelFeed(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	version, err := h.feedService.NovelFeedVersion(uint(novelID))
+	if err != nil {
+		writeFeedError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	if notModified(c, version.ETag, version.Updated) {
+		return
+	}
+	f, err := h.feedService.NovelFeed(uint(novelID), h.apiBaseURL+c.Request.URL.Path)
+	writeFeed(c, f, err)
+}
+
+// AuthorFeed 作者作品更新的 Atom 订阅源
+func (h *FeedHandler) AuthorFeed(c *gin.Context) {
+	authorID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+
+	version, err := h.feedService.AuthorFeedVersion(uint(authorID))
+	if err != nil {
+		writeFeedError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	if notModified(c, version.ETag, version.Updated) {
+		return
+	}
+	f, err := h.feedService.AuthorFeed(uint(authorID), h.apiBaseURL+c.Request.URL.Path)
+	writeFeed(c, f, err)
+}
+
+func writeFeedError(c *gin.Context, err error) {
+	if err == service.ErrFeedNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Feed not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "生成订阅源失败"})
+}
+
+// writeFeed 输出 feed，ETag 和 Last-Modified 已由 notModified 根据版本设置
+func writeFeed(c *gin.Context, f *feed.Feed, err error) {
+	if err != nil {
+		writeFeedError(c, err)
+		return
+	}
+
+	data, err := f.Encode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成订阅源失败"})
+		return
+	}
+	c.Data(http.StatusOK, feed.AtomMIME, data)
+}
+
+// writeConditional 根据内容计算 ETag 并设置 Last-Modified，内容未变化时返回 304
+func writeConditional(c *gin.Context, data []byte, contentType string, updated time.Time) {
+	if notModified(c, feed.ETag(data), updated) {
+		return
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// notModified 设置 ETag 和 Last-Modified；请求的条件匹配时返回 304 并返回 true
+func notModified(c *gin.Context, etag string, updated time.Time) bool {
+	lastModified := updated.UTC().Truncate(time.Second)
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if match == etag || match == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil && !lastModified.After(since) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
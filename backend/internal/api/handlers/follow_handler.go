@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FollowHandler struct {
+	followService *service.FollowService
+}
+
+func NewFollowHandler(followService *service.FollowService) *FollowHandler {
+	return &FollowHandler{followService: followService}
+}
+
+// Follow 关注作者
+func (h *FollowHandler) Follow(c *gin.Context) {
+	followeeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.followService.Follow(userID, uint(followeeID)); err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, service.ErrFollowSelf) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "关注成功"})
+}
+
+// Unfollow 取消关注
+func (h *FollowHandler) Unfollow(c *gin.Context) {
+	followeeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.followService.Unfollow(userID, uint(followeeID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "取消关注失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "取消关注成功"})
+}
+
+// GetFollowStats 获取用户的关注数和粉丝数
+func (h *FollowHandler) GetFollowStats(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	viewerID := utils.GetUserIDFromContext(c)
+	stats, err := h.followService.GetFollowStats(viewerID, uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取关注数据失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListFollowers 获取粉丝列表
+func (h *FollowHandler) ListFollowers(c *gin.Context) {
+	h.listFollows(c, h.followService.ListFollowers)
+}
+
+// ListFollowing 获取关注列表
+func (h *FollowHandler) ListFollowing(c *gin.Context) {
+	h.listFollows(c, h.followService.ListFollowing)
+}
+
+func (h *FollowHandler) listFollows(c *gin.Context, list func(userID uint, page, limit int) ([]service.FollowUser, int64, error)) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	users, total, err := list(uint(userID), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取关注列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+	moderationService *service.ModerationService
+	auditService      *service.AuditService
+}
+
+func NewModerationHandler(moderationService *service.ModerationService, auditService *service.AuditService) *ModerationHandler {
+	return &ModerationHandler{moderationService: moderationService, auditService: auditService}
+}
+
+// ListQueue 审核队列，默认只返回待审核的内容，status=-1 返回全部
+func (h *ModerationHandler) ListQueue(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	status, err := strconv.Atoi(c.DefaultQuery("status", strconv.Itoa(models.ModerationPending)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	items, total, err := h.moderationService.ListQueue(status, c.Query("contentType"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审核队列失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// ReviewItem 审核通过或驳回，驳回时必须填写原因，原因会展示给作者
+func (h *ModerationHandler) ReviewItem(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid moderation item ID")
+	if !ok {
+		return
+	}
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=approve reject"`
+		Reason string `json:"reason" binding:"max=255"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Action == "reject" && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "驳回时需要填写原因"})
+		return
+	}
+
+	reviewerID := middleware.GetUserID(c)
+	var item *models.ModerationItem
+	var err error
+	action := service.AuditModerationApprove
+	if req.Action == "approve" {
+		item, err = h.moderationService.Approve(id, reviewerID, req.Reason)
+	} else {
+		item, err = h.moderationService.Reject(id, reviewerID, req.Reason)
+		action = service.AuditModerationReject
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrModerationItemNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Moderation item not found"})
+		case errors.Is(err, service.ErrModerationItemReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: action, TargetType: models.AuditTargetModeration, TargetID: item.ID,
+		Reason: req.Reason, Detail: fmt.Sprintf("%s %d by user %d: %s", item.ContentType, item.ContentID, item.AuthorID, item.Words),
+	})
+
+	c.JSON(http.StatusOK, item)
+}
+
+// ReloadWords 立即重新加载敏感词库，无需等待定时检查
+func (h *ModerationHandler) ReloadWords(c *gin.Context) {
+	count, err := h.moderationService.ReloadWords()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditModerationReload, TargetType: models.AuditTargetModeration,
+		After: fmt.Sprintf("%d words", count),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// ListMyItems 当前用户被送审的内容及审核结果
+func (h *ModerationHandler) ListMyItems(c *gin.Context) {
+	page, limit := parsePageQuery(c)
+	items, total, err := h.moderationService.ListAuthorItems(middleware.GetUserID(c), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取审核记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// enqueueForReview 把需要审核的内容加入审核队列。内容已按待审核状态保存，不会被公开，
+// 因此入队失败只记录日志
+func enqueueForReview(moderationService *service.ModerationService, contentType string, contentID, authorID uint, title string, result service.ScreenResult) {
+	if err := moderationService.Enqueue(contentType, contentID, authorID, title, result); err != nil {
+		log.Printf("Failed to enqueue %s %d for moderation: %v", contentType, contentID, err)
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// ListNotifications 获取通知列表
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	unreadOnly := c.Query("unread") == "true"
+	userID := utils.GetUserIDFromContext(c)
+
+	notifications, total, err := h.notificationService.ListNotifications(userID, c.Query("type"), unreadOnly, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notifications,
+		"total":         total,
+		"page":          page,
+		"limit":         limit,
+	})
+}
+
+// GetUnreadCounts 获取各类型未读通知数量
+func (h *NotificationHandler) GetUnreadCounts(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	counts, err := h.notificationService.GetUnreadCounts(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取未读数量失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// MarkRead 批量标记已读，ids 为空时按 type 标记（type 也为空时标记全部）
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	var req struct {
+		IDs  []uint `json:"ids"`
+		Type string `json:"type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	updated, err := h.notificationService.MarkRead(userID, req.IDs, req.Type)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "标记已读失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// DeleteNotification 删除通知
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.notificationService.DeleteNotification(userID, uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification deleted successfully"})
+}
+
+// GetPreferences 获取通知偏好
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	prefs, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知设置失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences 更新通知偏好，请求体为 {类型: 是否开启}
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	prefs, err := h.notificationService.UpdatePreferences(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新通知设置失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
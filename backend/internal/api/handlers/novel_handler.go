@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"ai-novel-platform/internal/middleware"
 	"ai-novel-platform/internal/models"
 	"ai-novel-platform/internal/service"
 	"ai-novel-platform/internal/utils"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,29 +15,77 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// alsoLikedLimit 小说详情中“读过这本书的人还喜欢”的数量
+const alsoLikedLimit = 6
+
 type NovelHandler struct {
-	novelService *service.NovelService
+	novelService          *service.NovelService
+	recommendationService *service.RecommendationService
+	auditService          *service.AuditService
+	moderationService     *service.ModerationService
+}
+
+func NewNovelHandler(novelService *service.NovelService, recommendationService *service.RecommendationService, auditService *service.AuditService, moderationService *service.ModerationService) *NovelHandler {
+	return &NovelHandler{novelService: novelService, recommendationService: recommendationService, auditService: auditService, moderationService: moderationService}
+}
+
+// novelRequest 作者可编辑的小说字段。阅读数、收藏数、评分、完结时间等由服务端维护，不从请求中读取
+type novelRequest struct {
+	Title       string             `json:"title" binding:"required"`
+	Description string             `json:"description"`
+	CoverURL    string             `json:"coverUrl"`
+	Category    string             `json:"category"`
+	Status      int                `json:"status"`
+	Tags        models.StringArray `json:"tags"`
 }
 
-func NewNovelHandler(novelService *service.NovelService) *NovelHandler {
-	return &NovelHandler{novelService: novelService}
+func (r *novelRequest) apply(novel *models.Novel) {
+	novel.Title = r.Title
+	novel.Description = r.Description
+	novel.CoverURL = r.CoverURL
+	novel.Category = r.Category
+	novel.Status = r.Status
+	novel.Tags = r.Tags
 }
 
 func (h *NovelHandler) CreateNovel(c *gin.Context) {
-	var novel models.Novel
-	if err := c.ShouldBindJSON(&novel); err != nil {
+	var req novelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Status < 0 || req.Status > 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
 
 	// 获取当前用户ID
 	userID := utils.GetUserIDFromContext(c)
-	novel.AuthorID = userID
+	novel := models.Novel{AuthorID: userID}
+	req.apply(&novel)
+
+	// 标题和简介需要审核时，作品先隐藏，审核通过后公开
+	screen, ok := h.screenNovel(c, &novel)
+	if !ok {
+		return
+	}
+	if screen.NeedsReview() {
+		now := time.Now()
+		novel.HiddenAt = &now
+		novel.HiddenReason = service.ModerationHiddenReason
+	}
 
 	if err := h.novelService.CreateNovel(&novel); err != nil {
+		if errors.Is(err, service.ErrInvalidTag) || errors.Is(err, service.ErrTooManyTags) || errors.Is(err, service.ErrInvalidCategory) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if screen.NeedsReview() {
+		enqueueForReview(h.moderationService, models.ModerationContentNovel, novel.ID, userID, novel.Title, screen)
+	}
 
 	c.JSON(http.StatusCreated, novel)
 }
@@ -50,8 +102,22 @@ func (h *NovelHandler) GetNovel(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
 		return
 	}
+	// 被隐藏的小说只对作者和有管理权限的用户可见
+	if novel.HiddenAt != nil && !middleware.CurrentActor(c).CanManageNovel(novel.AuthorID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+		return
+	}
+
+	alsoLiked, err := h.recommendationService.AlsoLiked(novel.ID, alsoLikedLimit)
+	if err != nil {
+		log.Printf("Failed to load similar novels of %d: %v", novel.ID, err)
+		alsoLiked = []models.Novel{}
+	}
 
-	c.JSON(http.StatusOK, novel)
+	c.JSON(http.StatusOK, struct {
+		*models.Novel
+		AlsoLiked []models.Novel `json:"alsoLiked"`
+	}{novel, alsoLiked})
 }
 
 func (h *NovelHandler) UpdateNovel(c *gin.Context) {
@@ -61,31 +127,49 @@ func (h *NovelHandler) UpdateNovel(c *gin.Context) {
 		return
 	}
 
-	var novel models.Novel
-	if err := c.ShouldBindJSON(&novel); err != nil {
+	var req novelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Status < 0 || req.Status > 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	var novel models.Novel
+	req.apply(&novel)
 
-	exist, err := h.novelService.GetNovel(uint(id))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+	exist, ok := h.authorizeNovel(c, uint(id))
+	if !ok {
 		return
 	}
+	// 标题或简介有修改时重新检测。需要审核，或之前因审核被隐藏的作品修改后，重新隐藏等待审核；
+	// 被管理员手动隐藏的作品保持原状，只加入审核队列
+	var screen service.ScreenResult
+	review := false
+	if novel.Title != exist.Title || novel.Description != exist.Description {
+		if screen, ok = h.screenNovel(c, &novel); !ok {
+			return
+		}
+		review = screen.NeedsReview() || service.IsAwaitingModeration(exist)
+	}
 	exist.UpdatedAt = time.Now()
-	exist.Title = novel.Title
-	exist.CoverURL = novel.CoverURL
-	exist.Description = novel.Description
-	exist.Category = novel.Category
-	exist.Status = novel.Status
-	exist.Tags = novel.Tags
-
-	if err := h.novelService.UpdateNovel(exist); err != nil {
+	req.Title, req.Description = novel.Title, novel.Description // 检测时可能已打码
+	req.apply(exist)
+
+	if err := h.novelService.UpdateNovel(exist, review); err != nil {
+		if errors.Is(err, service.ErrInvalidTag) || errors.Is(err, service.ErrTooManyTags) || errors.Is(err, service.ErrInvalidCategory) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if review {
+		enqueueForReview(h.moderationService, models.ModerationContentNovel, exist.ID, exist.AuthorID, exist.Title, screen)
+	}
 
-	c.JSON(http.StatusOK, novel)
+	c.JSON(http.StatusOK, exist)
 }
 func (h *NovelHandler) UpdateNovelStatus(c *gin.Context) {
 	novelId := c.Param("id")
@@ -103,14 +187,17 @@ func (h *NovelHandler) UpdateNovelStatus(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "Invalid status"})
 		return
 	}
-	userID := utils.GetUserIDFromContext(c)
 	// 更新数据库中的小说状态
 	uintNovelId, err := strconv.Atoi(novelId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
 		return
 	}
-	if err := h.novelService.UpdateNovelStatus(uint(uintNovelId), req.Status, userID); err != nil {
+	novel, ok := h.authorizeNovel(c, uint(uintNovelId))
+	if !ok {
+		return
+	}
+	if err := h.novelService.UpdateNovelStatus(novel.ID, req.Status, novel.AuthorID); err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update status"})
 		return
 	}
@@ -124,15 +211,46 @@ func (h *NovelHandler) DeleteNovel(c *gin.Context) {
 		return
 	}
 
-	userID := utils.GetUserIDFromContext(c)
-	if err := h.novelService.DeleteNovel(uint(id), userID); err != nil {
+	novel, ok := h.authorizeNovel(c, uint(id))
+	if !ok {
+		return
+	}
+	if err := h.novelService.DeleteNovel(novel.ID, novel.AuthorID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditNovelDelete, TargetType: models.AuditTargetNovel, TargetID: novel.ID,
+		Detail: novel.Title, Before: fmt.Sprintf("%d words, author %d", novel.WordCount, novel.AuthorID),
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Novel deleted successfully"})
 }
 
+// screenNovel 检测小说标题和简介，轻微的词直接打码；内容被拒绝时已写入错误响应
+func (h *NovelHandler) screenNovel(c *gin.Context, novel *models.Novel) (service.ScreenResult, bool) {
+	screen := h.moderationService.Screen(models.ModerationContentNovel, &novel.Title, &novel.Description)
+	if err := screen.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return screen, false
+	}
+	return screen, true
+}
+
+// authorizeNovel 读取小说并检查当前用户能否管理，不能时已写入错误响应
+func (h *NovelHandler) authorizeNovel(c *gin.Context, id uint) (*models.Novel, bool) {
+	novel, err := h.novelService.GetNovel(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+		return nil, false
+	}
+	if !middleware.CurrentActor(c).CanManageNovel(novel.AuthorID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此小说"})
+		return nil, false
+	}
+	return novel, true
+}
+
 func (h *NovelHandler) ListNovels(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -223,11 +341,13 @@ func (h *NovelHandler) GetNovelOutline(c *gin.Context) {
 		})
 		return
 	}
+	version, _ := h.novelService.GetOutlineHeadVersion(uint(novelID))
 
 	c.JSON(http.StatusOK, gin.H{
-		"code": 0,
-		"msg":  "success",
-		"data": outline,
+		"code":    0,
+		"msg":     "success",
+		"data":    outline,
+		"version": version,
 	})
 }
 
@@ -242,8 +362,12 @@ func (h *NovelHandler) UpdateNovelOutline(c *gin.Context) {
 		return
 	}
 
-	var outline models.NovelOutline
-	if err := c.ShouldBindJSON(&outline); err != nil {
+	var req struct {
+		models.NovelOutline
+		BaseVersion int    `json:"baseVersion"` // 编辑时基于的版本，落后于最新版本时自动三方合并
+		Source      string `json:"source"`      // manual 或 ai
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code":  400,
 			"msg":   "Invalid outline data",
@@ -251,9 +375,32 @@ func (h *NovelHandler) UpdateNovelOutline(c *gin.Context) {
 		})
 		return
 	}
+	if req.Source != models.OutlineSourceAI {
+		req.Source = models.OutlineSourceManual
+	}
 
 	userID := utils.GetUserIDFromContext(c)
-	if err := h.novelService.UpdateNovelOutline(uint(novelID), userID, &outline); err != nil {
+	result, err := h.novelService.CommitNovelOutline(uint(novelID), userID, &req.NovelOutline, service.OutlineCommitOptions{
+		BaseVersion: req.BaseVersion,
+		Source:      req.Source,
+	})
+	if errors.Is(err, service.ErrOutlineConflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"code": 409,
+			"msg":  "Outline has conflicting changes",
+			"data": result,
+		})
+		return
+	}
+	if errors.Is(err, service.ErrOutlineDuplicateID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":  400,
+			"msg":   "Invalid outline data",
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code":  500,
 			"msg":   "Failed to update outline",
@@ -265,5 +412,6 @@ func (h *NovelHandler) UpdateNovelOutline(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"msg":  "success",
+		"data": result,
 	})
 }
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/epub"
+	"ai-novel-platform/internal/feed"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// opdsBaseKey 上下文中保存的书库根地址，令牌访问时包含令牌路径，保证 feed 内链接可直接访问
+const opdsBaseKey = "opdsBase"
+
+type OPDSHandler struct {
+	opdsService  *service.OPDSService
+	userService  *service.UserService
+	auditService *service.AuditService
+	apiBaseURL   string // 后端对外地址，用于书库和订阅令牌地址，不使用请求中的 Host
+}
+
+func NewOPDSHandler(opdsService *service.OPDSService, userService *service.UserService, auditService *service.AuditService, apiBaseURL string) *OPDSHandler {
+	return &OPDSHandler{
+		opdsService:  opdsService,
+		userService:  userService,
+		auditService: auditService,
+		apiBaseURL:   strings.TrimRight(apiBaseURL, "/"),
+	}
+}
+
+// Authenticate 书库认证：路径中带订阅令牌（/opds/t/:token）或使用 HTTP Basic 认证
+func (h *OPDSHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		base := h.apiBaseURL + "/opds"
+		userID, err := uint(0), service.ErrOPDSUnauthorized
+		if token := c.Param("token"); token != "" {
+			base += "/t/" + url.PathEscape(token)
+			userID, err = h.opdsService.AuthenticateToken(token)
+		} else if username, password, ok := c.Request.BasicAuth(); ok {
+			userID, err = h.opdsService.AuthenticateBasic(username, password, c.ClientIP())
+			if errors.Is(err, service.ErrOPDSRateLimited) {
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+				return
+			}
+			if err != nil {
+				recordAudit(c, h.auditService, models.AuditLog{
+					Action: service.AuditLoginFailed, TargetType: models.AuditTargetUser, TargetID: h.userService.LookupUserID(username),
+					Reason: err.Error(), Detail: username,
+				})
+			}
+		}
+
+		if err != nil {
+			c.Header("WWW-Authenticate", `Basic realm="AI Novel OPDS", charset="UTF-8"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		c.Set(utils.ContextUserKey, userID)
+		c.Set(opdsBaseKey, base)
+		c.Next()
+	}
+}
+
+// Root 书库首页
+func (h *OPDSHandler) Root(c *gin.Context) {
+	f := h.opdsService.Root(c.GetString(opdsBaseKey))
+	writeOPDS(c, f, feed.OPDSNavigationMIME, time.Now(), nil)
+}
+
+// Latest 最近更新
+func (h *OPDSHandler) Latest(c *gin.Context) {
+	f, updated, err := h.opdsService.Latest(c.GetString(opdsBaseKey), opdsPage(c))
+	writeOPDS(c, f, feed.OPDSAcquisitionMIME, updated, err)
+}
+
+// Popular 热门作品
+func (h *OPDSHandler) Popular(c *gin.Context) {
+	f, updated, err := h.opdsService.Popular(c.GetString(opdsBaseKey), opdsPage(c))
+	writeOPDS(c, f, feed.OPDSAcquisitionMIME, updated, err)
+}
+
+// Categories 分类导航
+func (h *OPDSHandler) Categories(c *gin.Context) {
+	f, updated, err := h.opdsService.Categories(c.GetString(opdsBaseKey))
+	writeOPDS(c, f, feed.OPDSNavigationMIME, updated, err)
+}
+
+// Category 分类下的作品
+func (h *OPDSHandler) Category(c *gin.Context) {
+	f, updated, err := h.opdsService.Category(c.GetString(opdsBaseKey), c.Param("category"), opdsPage(c))
+	writeOPDS(c, f, feed.OPDSAcquisitionMIME, updated, err)
+}
+
+// Favorites 我的收藏
+func (h *OPDSHandler) Favorites(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+	f, updated, err := h.opdsService.Favorites(c.GetString(opdsBaseKey), userID, opdsPage(c))
+	writeOPDS(c, f, feed.OPDSAcquisitionMIME, updated, err)
+}
+
+// DownloadBook 下载小说 EPUB
+func (h *OPDSHandler) DownloadBook(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	book, updated, err := h.opdsService.Book(uint(novelID))
+	if err != nil {
+		if err == service.ErrBookNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成电子书失败"})
+		return
+	}
+
+	data, err := book.Bytes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成电子书失败"})
+		return
+	}
+	filename := fmt.Sprintf("novel-%d.epub", novelID)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, filename, url.PathEscape(book.Title+".epub")))
+	c.Header("Cache-Control", "private, max-age=300")
+	writeConditional(c, data, epub.MIME, updated)
+}
+
+// GetFeedToken 获取当前用户的订阅令牌及书库地址
+func (h *OPDSHandler) GetFeedToken(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	feedToken, err := h.opdsService.GetFeedToken(userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusOK, gin.H{"token": nil})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取订阅令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      feedToken.Token,
+		"url":        h.apiBaseURL + "/opds/t/" + feedToken.Token,
+		"createdAt":  feedToken.CreatedAt,
+		"lastUsedAt": feedToken.LastUsedAt,
+	})
+}
+
+// RotateFeedToken 生成新的订阅令牌，旧地址随即失效
+func (h *OPDSHandler) RotateFeedToken(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	feedToken, err := h.opdsService.RotateFeedToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成订阅令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      feedToken.Token,
+		"url":        h.apiBaseURL + "/opds/t/" + feedToken.Token,
+		"createdAt":  feedToken.CreatedAt,
+		"lastUsedAt": feedToken.LastUsedAt,
+	})
+}
+
+// RevokeFeedToken 撤销订阅令牌
+func (h *OPDSHandler) RevokeFeedToken(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	if err := h.opdsService.RevokeFeedToken(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "撤销订阅令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "订阅令牌已撤销"})
+}
+
+// writeOPDS 输出 OPDS feed；书库内容因用户而异，只允许私有缓存
+func writeOPDS(c *gin.Context, f *feed.Feed, contentType string, updated time.Time, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成书库目录失败"})
+		return
+	}
+
+	data, err := f.Encode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成书库目录失败"})
+		return
+	}
+	c.Header("Cache-Control", "private, max-age=60")
+	writeConditional(c, data, contentType, updated)
+}
+
+func opdsPage(c *gin.Context) int {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	return page
+}
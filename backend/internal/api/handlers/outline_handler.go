@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExportNovelOutline 导出小说大纲（OPML 或 Markdown）
+func (h *NovelHandler) ExportNovelOutline(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid novel ID"})
+		return
+	}
+
+	format := c.DefaultQuery("format", service.OutlineFormatMarkdown)
+	style := c.Query("style")
+	userID := utils.GetUserIDFromContext(c)
+
+	data, err := h.novelService.ExportNovelOutline(uint(novelID), userID, format, style)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedOutlineFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": err.Error()})
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "Novel not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "Failed to export outline", "error": err.Error()})
+		return
+	}
+
+	contentType, ext := "text/markdown; charset=utf-8", "md"
+	if format == service.OutlineFormatOPML {
+		contentType, ext = "text/x-opml; charset=utf-8", "opml"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="outline-%d.%s"`, novelID, ext))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// PreviewOutlineImport 预览大纲导入结果
+func (h *NovelHandler) PreviewOutlineImport(c *gin.Context) {
+	h.importOutline(c, true)
+}
+
+// ImportNovelOutline 导入大纲并替换现有大纲
+func (h *NovelHandler) ImportNovelOutline(c *gin.Context) {
+	h.importOutline(c, false)
+}
+
+func (h *NovelHandler) importOutline(c *gin.Context, preview bool) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid novel ID"})
+		return
+	}
+
+	data, filename, err := readOutlineUpload(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid outline file", "error": err.Error()})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = service.DetectOutlineFormat(filename, data)
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	var result *service.OutlineImportPreview
+	if preview {
+		result, err = h.novelService.PreviewOutlineImport(uint(novelID), userID, format, data)
+	} else {
+		result, err = h.novelService.ImportNovelOutline(uint(novelID), userID, format, data)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "Novel not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Failed to import outline", "error": err.Error()})
+		return
+	}
+	// 导入会整体替换现有大纲
+	if !preview {
+		recordAudit(c, h.auditService, models.AuditLog{
+			Action: service.AuditOutlineImport, TargetType: models.AuditTargetNovel, TargetID: uint(novelID),
+			Detail: format,
+			Before: fmt.Sprintf("%d nodes", result.CurrentNodeCount),
+			After:  fmt.Sprintf("%d nodes, %d characters, %d locations", result.NodeCount, result.CharacterCount, result.LocationCount),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": result,
+	})
+}
+
+// readOutlineUpload 读取 multipart 文件字段 file，或直接读取请求体
+func readOutlineUpload(c *gin.Context) ([]byte, string, error) {
+	var reader io.Reader
+	filename := ""
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > service.MaxOutlineImportSize {
+			return nil, "", service.ErrOutlineImportTooLarge
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer f.Close()
+		reader, filename = f, file.Filename
+	} else {
+		reader = c.Request.Body
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, service.MaxOutlineImportSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > service.MaxOutlineImportSize {
+		return nil, "", service.ErrOutlineImportTooLarge
+	}
+	if len(data) == 0 {
+		return nil, "", errors.New("empty outline file")
+	}
+	return data, filename, nil
+}
+
+// ListOutlineVersions 获取大纲历史版本列表
+func (h *NovelHandler) ListOutlineVersions(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid novel ID"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	userID := utils.GetUserIDFromContext(c)
+	versions, total, err := h.novelService.ListOutlineVersions(uint(novelID), userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":  0,
+		"msg":   "success",
+		"data":  versions,
+		"total": total,
+	})
+}
+
+// GetOutlineVersion 获取指定版本的大纲
+func (h *NovelHandler) GetOutlineVersion(c *gin.Context) {
+	novelID, version, ok := parseOutlineVersionParams(c)
+	if !ok {
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	v, err := h.novelService.GetOutlineVersion(novelID, userID, version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": "Outline version not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": v})
+}
+
+// DiffOutlineVersions 比较两个大纲版本的结构差异，未指定 to 时与当前大纲比较
+func (h *NovelHandler) DiffOutlineVersions(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid novel ID"})
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid from version"})
+		return
+	}
+	to, _ := strconv.Atoi(c.DefaultQuery("to", "0"))
+
+	userID := utils.GetUserIDFromContext(c)
+	changes, err := h.novelService.DiffOutlineVersions(uint(novelID), userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "msg": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": changes})
+}
+
+// RestoreOutlineVersion 恢复到指定版本
+func (h *NovelHandler) RestoreOutlineVersion(c *gin.Context) {
+	novelID, version, ok := parseOutlineVersionParams(c)
+	if !ok {
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	headVersion, _ := h.novelService.GetOutlineHeadVersion(novelID)
+	result, err := h.novelService.RestoreOutlineVersion(novelID, userID, version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "msg": "Failed to restore outline", "error": err.Error()})
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditOutlineRestore, TargetType: models.AuditTargetNovel, TargetID: novelID,
+		Detail: fmt.Sprintf("restored from version %d", version),
+		Before: fmt.Sprintf("version %d", headVersion),
+		After:  fmt.Sprintf("version %d", result.Version),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": result})
+}
+
+func parseOutlineVersionParams(c *gin.Context) (uint, int, bool) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid novel ID"})
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "msg": "Invalid version"})
+		return 0, 0, false
+	}
+	return uint(novelID), version, true
+}
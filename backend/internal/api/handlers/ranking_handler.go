@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRankingLimit = 100
+
+type RankingHandler struct {
+	rankingService *service.RankingService
+}
+
+func NewRankingHandler(rankingService *service.RankingService) *RankingHandler {
+	return &RankingHandler{rankingService: rankingService}
+}
+
+// GetRanking 获取榜单：board 为 read/favorite/rising/completed/rated，
+// period 为 day/week/month/all（默认 week），category 为空时返回全站榜
+func (h *RankingHandler) GetRanking(c *gin.Context) {
+	board := c.Param("board")
+	period := c.DefaultQuery("period", models.RankingPeriodWeek)
+	category := c.Query("category")
+	if category == "all" {
+		category = ""
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > maxRankingLimit {
+		limit = 20
+	}
+
+	items, computedAt, err := h.rankingService.GetRanking(board, period, category, limit)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidRankingBoard:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ranking board not found"})
+		case service.ErrInvalidRankingPeriod:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取榜单失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"board":      board,
+		"period":     period,
+		"category":   category,
+		"items":      items,
+		"computedAt": computedAt,
+	})
+}
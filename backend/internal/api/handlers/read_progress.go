@@ -3,9 +3,11 @@ package handlers
 import (
 	"ai-novel-platform/internal/service"
 	"ai-novel-platform/internal/utils"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type ReadProgressHandler struct {
@@ -19,8 +21,14 @@ func NewReadProgressHandler(readProgressService *service.ReadProgressService) *R
 // UpdateReadProgress 更新阅读进度
 func (h *ReadProgressHandler) UpdateReadProgress(c *gin.Context) {
 	var req struct {
-		NovelID   uint `json:"novelId" binding:"required"`
-		ChapterID uint `json:"chapterId" binding:"required"`
+		NovelID        uint    `json:"novelId" binding:"required"`
+		ChapterID      uint    `json:"chapterId" binding:"required"`
+		ParagraphIndex int     `json:"paragraphIndex" binding:"min=0"`
+		CharOffset     int     `json:"charOffset" binding:"min=0"`
+		Percentage     float64 `json:"percentage"`
+		DeviceID       string  `json:"deviceId" binding:"max=64"`
+		ClientTime     int64   `json:"clientTime"` // 客户端记录进度的毫秒时间戳
+		Strategy       string  `json:"strategy"`   // latest（默认）或 max
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,13 +39,37 @@ func (h *ReadProgressHandler) UpdateReadProgress(c *gin.Context) {
 	// 从上下文获取用户ID
 	userID := utils.GetUserIDFromContext(c)
 
-	err := h.readProgressService.UpdateReadProgress(userID, req.NovelID, req.ChapterID)
+	update := service.ProgressUpdate{
+		ChapterID:      req.ChapterID,
+		ParagraphIndex: req.ParagraphIndex,
+		CharOffset:     req.CharOffset,
+		Percentage:     req.Percentage,
+		DeviceID:       req.DeviceID,
+		Strategy:       req.Strategy,
+	}
+	if req.ClientTime > 0 {
+		update.ClientTime = time.UnixMilli(req.ClientTime)
+	}
+
+	progress, accepted, err := h.readProgressService.UpdateReadProgress(userID, req.NovelID, update)
+	if errors.Is(err, service.ErrChapterNotInNovel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrReadProgressContended) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update reading progress"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Reading progress updated successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Reading progress updated successfully",
+		"accepted": accepted,
+		"data":     progress,
+	})
 }
 
 // GetReadProgress 获取阅读进度
@@ -57,3 +89,58 @@ func (h *ReadProgressHandler) GetReadProgress(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": progress})
 }
+
+// GetReadingHistory 获取阅读历史（按最近阅读时间排序）
+func (h *ReadProgressHandler) GetReadingHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	userID := utils.GetUserIDFromContext(c)
+
+	entries, total, err := h.readProgressService.ListReadingHistory(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get reading history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// DeleteReadingHistory 删除某部小说的阅读记录
+func (h *ReadProgressHandler) DeleteReadingHistory(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("novelId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+	userID := utils.GetUserIDFromContext(c)
+
+	if err := h.readProgressService.DeleteReadProgress(userID, uint(novelID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete reading history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading history deleted successfully"})
+}
+
+// ClearReadingHistory 清空阅读历史
+func (h *ReadProgressHandler) ClearReadingHistory(c *gin.Context) {
+	userID := utils.GetUserIDFromContext(c)
+
+	if err := h.readProgressService.DeleteReadProgress(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear reading history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading history cleared successfully"})
+}
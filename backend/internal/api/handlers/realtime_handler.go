@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/realtime"
+	"ai-novel-platform/internal/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type RealtimeHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewRealtimeHandler allowedOrigins 为允许建立 WebSocket 连接的前端地址，与 CORS 配置一致
+func NewRealtimeHandler(hub *realtime.Hub, allowedOrigins []string) *RealtimeHandler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		allowed[origin] = true
+	}
+	return &RealtimeHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			Subprotocols:    []string{bearerSubprotocol},
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || allowed[origin]
+			},
+		},
+	}
+}
+
+// bearerSubprotocol 浏览器无法为 WebSocket 设置 Authorization 请求头，token 放在
+// Sec-WebSocket-Protocol 中，形如 ["bearer", "<token>"]；不使用查询参数，避免 token 写入访问日志
+const bearerSubprotocol = "bearer"
+
+// Connect 建立 WebSocket 连接，token 通过 Sec-WebSocket-Protocol 或 Authorization 请求头传入；
+// lastEventId 用于断线重连后补发事件
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if protocols := websocket.Subprotocols(c.Request); len(protocols) == 2 && protocols[0] == bearerSubprotocol {
+		token = protocols[1]
+	}
+	claims, err := middleware.ParseToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+
+	lastEventID := c.Query("lastEventId")
+	if lastEventID != "" && !realtime.ValidEventID(lastEventID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid lastEventId"})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade 失败时已向客户端写入错误响应
+		return
+	}
+	h.hub.Serve(conn, claims.UserID, lastEventID)
+}
+
+// jobProgressStatuses 允许上报的任务状态
+var jobProgressStatuses = map[string]bool{"pending": true, "running": true, "succeeded": true, "failed": true}
+
+// ReportJobProgress 浏览器中运行的 AI 写作任务上报进度，转发给当前用户的所有在线会话
+func (h *RealtimeHandler) ReportJobProgress(c *gin.Context) {
+	var req struct {
+		JobID    string `json:"jobId" binding:"required,max=64"`
+		Status   string `json:"status" binding:"required"`
+		Progress int    `json:"progress" binding:"min=0,max=100"`
+		Message  string `json:"message" binding:"max=200"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !jobProgressStatuses[req.Status] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	progress := realtime.JobProgress{JobID: req.JobID, Status: req.Status, Progress: req.Progress, Message: req.Message}
+	if err := h.hub.PublishJobProgress(utils.GetUserIDFromContext(c), progress); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "推送任务进度失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已推送"})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRecommendationLimit = 50
+
+type RecommendationHandler struct {
+	recommendationService *service.RecommendationService
+}
+
+func NewRecommendationHandler(recommendationService *service.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService: recommendationService}
+}
+
+// GetRecommendations 获取个性化推荐，游客返回热门小说
+func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > maxRecommendationLimit {
+		limit = 20
+	}
+
+	items, err := h.recommendationService.Recommend(utils.GetUserIDFromContext(c), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取推荐失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
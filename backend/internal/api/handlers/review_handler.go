@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/service"
+	"ai-novel-platform/internal/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+}
+
+func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// SaveReview 发表或修改书评
+func (h *ReviewHandler) SaveReview(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	var req struct {
+		Score     int    `json:"score" binding:"required,min=1,max=5"`
+		Content   string `json:"content"`
+		IsSpoiler bool   `json:"isSpoiler"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	review, err := h.reviewService.SaveReview(userID, uint(novelID), req.Score, req.Content, req.IsSpoiler)
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// GetMyReview 获取我对小说的书评
+func (h *ReviewHandler) GetMyReview(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	review, err := h.reviewService.GetReview(userID, uint(novelID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// DeleteReview 删除我的书评
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	if err := h.reviewService.DeleteReview(userID, uint(novelID)); err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Review deleted successfully"})
+}
+
+// ListReviews 获取小说的书评列表
+func (h *ReviewHandler) ListReviews(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	sort := c.DefaultQuery("sort", service.ReviewSortHelpful)
+
+	viewerID := utils.GetUserIDFromContext(c)
+	reviews, total, err := h.reviewService.ListReviews(viewerID, uint(novelID), sort, page, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrReviewNovelNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取书评失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// GetNovelRating 获取小说评分汇总
+func (h *ReviewHandler) GetNovelRating(c *gin.Context) {
+	novelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid novel ID"})
+		return
+	}
+
+	rating, err := h.reviewService.GetNovelRating(uint(novelID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Novel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rating)
+}
+
+// VoteHelpful 书评投“有用”
+func (h *ReviewHandler) VoteHelpful(c *gin.Context) {
+	h.setHelpful(c, true)
+}
+
+// UnvoteHelpful 取消“有用”投票
+func (h *ReviewHandler) UnvoteHelpful(c *gin.Context) {
+	h.setHelpful(c, false)
+}
+
+func (h *ReviewHandler) setHelpful(c *gin.Context, voted bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		return
+	}
+
+	userID := utils.GetUserIDFromContext(c)
+	var count int
+	if voted {
+		count, err = h.reviewService.VoteHelpful(userID, uint(id))
+	} else {
+		count, err = h.reviewService.UnvoteHelpful(userID, uint(id))
+	}
+	if err != nil {
+		c.JSON(reviewErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"voted": voted, "helpfulCount": count})
+}
+
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrReviewNotFound), errors.Is(err, service.ErrReviewNovelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrReviewOwnNovel), errors.Is(err, service.ErrReviewOwnReview):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
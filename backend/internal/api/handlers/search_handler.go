@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/search"
+	"ai-novel-platform/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxSearchLimit  = 50
+	maxSuggestLimit = 10
+)
+
+type SearchHandler struct {
+	searchService   *service.SearchService
+	categoryService *service.CategoryService
+}
+
+func NewSearchHandler(searchService *service.SearchService, categoryService *service.CategoryService) *SearchHandler {
+	return &SearchHandler{searchService: searchService, categoryService: categoryService}
+}
+
+// Search 全文搜索小说，检索标题、简介、作者、标签和章节正文；
+// 支持 category、status、tags（逗号分隔）、minWords、maxWords 筛选
+func (h *SearchHandler) Search(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		limit = 20
+	}
+
+	q := search.Query{
+		Text:   strings.TrimSpace(c.Query("q")),
+		Offset: (page - 1) * limit,
+		Limit:  limit,
+	}
+	// 按父分类筛选时包含所有子分类
+	if category := c.Query("category"); category != "" {
+		categories, err := h.categoryService.Expand(category)
+		if err != nil {
+			if errors.Is(err, service.ErrCategoryNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
+			return
+		}
+		q.Categories = categories
+	}
+	if status, err := strconv.Atoi(c.DefaultQuery("status", "-1")); err == nil && status != -1 {
+		q.Status = &status
+	}
+	for _, tag := range strings.Split(c.Query("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			q.Tags = append(q.Tags, tag)
+		}
+	}
+	q.MinWords, _ = strconv.Atoi(c.Query("minWords"))
+	q.MaxWords, _ = strconv.Atoi(c.Query("maxWords"))
+
+	items, total, err := h.searchService.Search(q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "搜索失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": items,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// Suggest 搜索框输入建议，q 可以是标题、作者、标签、角色名的前缀或拼音首字母；type 可按类型过滤
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if limit < 1 || limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	suggestions := h.searchService.Suggest(strings.TrimSpace(c.Query("q")), limit, c.Query("type"))
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxTagPageSize = 100
+
+type TagHandler struct {
+	tagService   *service.TagService
+	auditService *service.AuditService
+}
+
+func NewTagHandler(tagService *service.TagService, auditService *service.AuditService) *TagHandler {
+	return &TagHandler{tagService: tagService, auditService: auditService}
+}
+
+// ListTags 按使用次数列出标签，可按分类过滤或按前缀搜索（同义写法也能匹配）
+func (h *TagHandler) ListTags(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxTagPageSize {
+		pageSize = 50
+	}
+
+	tags, total, err := h.tagService.ListTags(c.Query("category"), c.Query("keyword"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取标签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags":  tags,
+		"total": total,
+	})
+}
+
+// GetTag 获取标签详情，传入同义写法时返回对应的规范标签
+func (h *TagHandler) GetTag(c *gin.Context) {
+	tag, err := h.tagService.GetTag(c.Param("name"))
+	if err != nil {
+		if errors.Is(err, service.ErrTagNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取标签失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// ListNovelsByTags 按标签浏览小说，tags 以逗号分隔，返回同时带有所有标签的小说
+func (h *TagHandler) ListNovelsByTags(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxTagPageSize {
+		pageSize = 10
+	}
+	tags := strings.Split(c.Query("tags"), ",")
+
+	novels, total, err := h.tagService.ListNovelsByTags(tags, c.Query("sortBy"), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取小说列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"novels": novels,
+		"total":  total,
+	})
+}
+
+// MergeTag 把标签合并到 targetId 指定的标签，原标签名和同义写法归入目标标签
+func (h *TagHandler) MergeTag(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid tag ID")
+	if !ok {
+		return
+	}
+	var input struct {
+		TargetID uint `json:"targetId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.tagService.MergeTags(id, input.TargetID)
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditTagMerge, TargetType: models.AuditTargetTag, TargetID: id,
+		After: strconv.FormatUint(uint64(tag.ID), 10) + " " + tag.Name,
+	})
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// AddSynonym 为标签添加同义写法
+func (h *TagHandler) AddSynonym(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid tag ID")
+	if !ok {
+		return
+	}
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.tagService.AddSynonym(id, input.Name)
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditTagSynonymAdd, TargetType: models.AuditTargetTag, TargetID: tag.ID,
+		Detail: tag.Name, After: service.NormalizeTagName(input.Name),
+	})
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteSynonym 删除标签的同义写法
+func (h *TagHandler) DeleteSynonym(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid tag ID")
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagService.DeleteSynonym(id, c.Param("name"))
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditTagSynonymDelete, TargetType: models.AuditTargetTag, TargetID: tag.ID,
+		Detail: tag.Name, Before: service.NormalizeTagName(c.Param("name")),
+	})
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// UpdateCategory 修改标签分类
+func (h *TagHandler) UpdateCategory(c *gin.Context) {
+	id, ok := parseAdminID(c, "Invalid tag ID")
+	if !ok {
+		return
+	}
+	var input struct {
+		Category string `json:"category" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tag, err := h.tagService.SetTagCategory(id, input.Category)
+	if err != nil {
+		respondTagError(c, err)
+		return
+	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditTagCategory, TargetType: models.AuditTargetTag, TargetID: tag.ID,
+		Detail: tag.Name, After: tag.Category,
+	})
+
+	c.JSON(http.StatusOK, tag)
+}
+
+func respondTagError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+	case errors.Is(err, service.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTagConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -3,8 +3,11 @@ package handlers
 import (
 	"ai-novel-platform/internal/api/dto"
 	"ai-novel-platform/internal/middleware"
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/policy"
 	"ai-novel-platform/internal/service"
 	"ai-novel-platform/internal/utils"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -12,14 +15,16 @@ import (
 )
 
 type UserHandler struct {
-	userService *service.UserService
-	jwtSecret   string
+	userService       *service.UserService
+	auditService      *service.AuditService
+	moderationService *service.ModerationService
 }
 
-func NewUserHandler(userService *service.UserService, jwtSecret string) *UserHandler {
+func NewUserHandler(userService *service.UserService, auditService *service.AuditService, moderationService *service.ModerationService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		jwtSecret:   jwtSecret,
+		userService:       userService,
+		auditService:      auditService,
+		moderationService: moderationService,
 	}
 }
 
@@ -29,6 +34,10 @@ func (h *UserHandler) Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.moderationService.Screen(models.ModerationContentUsername, &req.Username).Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := h.userService.Register(req.Username, req.Password, req.Email); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -47,6 +56,14 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	user, err := h.userService.Login(req.Username, req.Password)
 	if err != nil {
+		recordAudit(c, h.auditService, models.AuditLog{
+			Action: service.AuditLoginFailed, TargetType: models.AuditTargetUser, TargetID: h.userService.LookupUserID(req.Username),
+			Reason: err.Error(), Detail: req.Username,
+		})
+		if errors.Is(err, service.ErrUserBanned) || errors.Is(err, service.ErrPasswordResetRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
@@ -59,13 +76,18 @@ func (h *UserHandler) Login(c *gin.Context) {
 		})
 		return
 	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		ActorID: user.ID, Action: service.AuditLogin, TargetType: models.AuditTargetUser, TargetID: user.ID,
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"token": token,
 		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
+			"id":          user.ID,
+			"username":    user.Username,
+			"email":       user.Email,
+			"role":        user.Role,
+			"permissions": policy.Permissions(user.Role),
 		},
 	})
 }
@@ -96,10 +118,14 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.userService.ResetPassword(req.Token, req.Password); err != nil {
+	userID, err := h.userService.ResetPassword(req.Token, req.Password)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		ActorID: userID, Action: service.AuditPasswordReset, TargetType: models.AuditTargetUser, TargetID: userID,
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
 }
@@ -123,16 +149,31 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	updates := make(map[string]interface{})
 
 	if req.Username != "" {
+		if err := h.moderationService.Screen(models.ModerationContentUsername, &req.Username).Err(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		updates["username"] = req.Username
 	}
 	if req.Email != "" {
 		updates["email"] = req.Email
 	}
 
+	before, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
 	if err := h.userService.UpdateUser(userID, updates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Email != "" && req.Email != before.Email {
+		recordAudit(c, h.auditService, models.AuditLog{
+			Action: service.AuditEmailChange, TargetType: models.AuditTargetUser, TargetID: userID,
+			Before: before.Email, After: req.Email,
+		})
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
 }
@@ -149,6 +190,9 @@ func (h *UserHandler) UpdatePassword(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, h.auditService, models.AuditLog{
+		Action: service.AuditPasswordChange, TargetType: models.AuditTargetUser, TargetID: userID,
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Password updated successfully"})
 }
@@ -232,6 +276,8 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		"email":         user.Email,
 		"avatar":        user.Avatar,
 		"bio":           user.Bio,
+		"role":          user.Role,
+		"permissions":   policy.Permissions(user.Role),
 		"novelCount":    stats["novelCount"],
 		"wordCount":     stats["wordCount"],
 		"favoriteCount": stats["favoriteCount"],
@@ -0,0 +1,189 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MIME EPUB 文件类型
+const MIME = "application/epub+zip"
+
+// Book 待生成的电子书
+type Book struct {
+	Identifier  string // 唯一标识，例如 urn:uuid 或站点地址
+	Title       string
+	Author      string
+	Description string
+	Language    string
+	Modified    time.Time
+	Chapters    []Chapter
+}
+
+// Chapter 章节，Paragraphs 为纯文本段落
+type Chapter struct {
+	Title      string
+	Paragraphs []string
+}
+
+// Write 生成 EPUB 3 文件，同时包含 NCX 目录以兼容只支持 EPUB 2 的阅读器
+func (b *Book) Write(w io.Writer) error {
+	if b.Language == "" {
+		b.Language = "zh-CN"
+	}
+	zw := zip.NewWriter(w)
+
+	// mimetype 必须是第一个文件且不压缩
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, MIME); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		tmpl *template.Template
+		data interface{}
+	}{
+		{"META-INF/container.xml", containerTemplate, nil},
+		{"OEBPS/content.opf", opfTemplate, b},
+		{"OEBPS/nav.xhtml", navTemplate, b},
+		{"OEBPS/toc.ncx", ncxTemplate, b},
+	}
+	for _, f := range files {
+		if err := writeTemplate(zw, f.name, f.tmpl, f.data); err != nil {
+			return err
+		}
+	}
+
+	for i, c := range b.Chapters {
+		if err := writeTemplate(zw, chapterFile(i), chapterTemplate, struct {
+			Book    *Book
+			Chapter Chapter
+		}{b, c}); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Bytes 生成 EPUB 并返回文件内容
+func (b *Book) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeTemplate(zw *zip.Writer, name string, tmpl *template.Template, data interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+func chapterFile(i int) string {
+	return fmt.Sprintf("OEBPS/chapter-%04d.xhtml", i+1)
+}
+
+var funcs = template.FuncMap{
+	"esc": html.EscapeString,
+	"file": func(i int) string {
+		return strings.TrimPrefix(chapterFile(i), "OEBPS/")
+	},
+	"inc": func(i int) int { return i + 1 },
+	"date": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02T15:04:05Z")
+	},
+}
+
+var containerTemplate = template.Must(template.New("container").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`))
+
+var opfTemplate = template.Must(template.New("opf").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{esc .Language}}">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{esc .Identifier}}</dc:identifier>
+    <dc:title>{{esc .Title}}</dc:title>
+    <dc:creator>{{esc .Author}}</dc:creator>
+    <dc:language>{{esc .Language}}</dc:language>
+    {{- if .Description}}
+    <dc:description>{{esc .Description}}</dc:description>
+    {{- end}}
+    <meta property="dcterms:modified">{{date .Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    {{- range $i, $c := .Chapters}}
+    <item id="chapter-{{inc $i}}" href="{{file $i}}" media-type="application/xhtml+xml"/>
+    {{- end}}
+  </manifest>
+  <spine toc="ncx">
+    {{- range $i, $c := .Chapters}}
+    <itemref idref="chapter-{{inc $i}}"/>
+    {{- end}}
+  </spine>
+</package>
+`))
+
+var navTemplate = template.Must(template.New("nav").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{esc .Language}}">
+<head><title>{{esc .Title}}</title></head>
+<body>
+  <nav epub:type="toc" id="toc">
+    <h1>目录</h1>
+    <ol>
+      {{- range $i, $c := .Chapters}}
+      <li><a href="{{file $i}}">{{esc $c.Title}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+`))
+
+var ncxTemplate = template.Must(template.New("ncx").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="{{esc .Identifier}}"/>
+  </head>
+  <docTitle><text>{{esc .Title}}</text></docTitle>
+  <navMap>
+    {{- range $i, $c := .Chapters}}
+    <navPoint id="nav-{{inc $i}}" playOrder="{{inc $i}}">
+      <navLabel><text>{{esc $c.Title}}</text></navLabel>
+      <content src="{{file $i}}"/>
+    </navPoint>
+    {{- end}}
+  </navMap>
+</ncx>
+`))
+
+var chapterTemplate = template.Must(template.New("chapter").Funcs(funcs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{esc .Book.Language}}">
+<head><title>{{esc .Chapter.Title}}</title></head>
+<body>
+  <h2>{{esc .Chapter.Title}}</h2>
+  {{- range .Chapter.Paragraphs}}
+  <p>{{esc .}}</p>
+  {{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+const (
+	AtomNamespace = "http://www.w3.org/2005/Atom"
+	AtomMIME      = "application/atom+xml;charset=utf-8"
+)
+
+// Feed Atom feed（RFC 4287）
+type Feed struct {
+	XMLName  xml.Name `xml:"feed"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	ID       string   `xml:"id"`
+	Title    string   `xml:"title"`
+	Subtitle string   `xml:"subtitle,omitempty"`
+	Updated  string   `xml:"updated"`
+	Author   *Person  `xml:"author,omitempty"`
+	Icon     string   `xml:"icon,omitempty"`
+	Links    []Link   `xml:"link"`
+	Entries  []Entry  `xml:"entry"`
+}
+
+// Entry Atom 条目
+type Entry struct {
+	ID         string     `xml:"id"`
+	Title      string     `xml:"title"`
+	Updated    string     `xml:"updated"`
+	Published  string     `xml:"published,omitempty"`
+	Authors    []Person   `xml:"author,omitempty"`
+	Categories []Category `xml:"category,omitempty"`
+	Summary    *Text      `xml:"summary,omitempty"`
+	Content    *Text      `xml:"content,omitempty"`
+	Links      []Link     `xml:"link"`
+}
+
+// Person 作者
+type Person struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+// Link 链接
+type Link struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+// Category 分类
+type Category struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+// Text 文本内容，Type 为 text、html 或 xhtml
+type Text struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+// FormatTime 按 RFC 3339 格式化时间
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Encode 序列化为带 XML 声明的文档
+func (f *Feed) Encode() ([]byte, error) {
+	if f.Xmlns == "" {
+		f.Xmlns = AtomNamespace
+	}
+	data, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// ETag 根据内容计算弱校验值
+func ETag(data []byte) string {
+	sum := sha1.Sum(data)
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString(sum[:8]))
+}
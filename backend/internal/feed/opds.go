@@ -0,0 +1,14 @@
+package feed
+
+// OPDS 1.2 目录类型与链接关系
+const (
+	OPDSNavigationMIME  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	OPDSAcquisitionMIME = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+
+	RelAcquisition = "http://opds-spec.org/acquisition"
+	RelImage       = "http://opds-spec.org/image"
+	RelThumbnail   = "http://opds-spec.org/image/thumbnail"
+	RelPopular     = "http://opds-spec.org/sort/popular"
+	RelNew         = "http://opds-spec.org/sort/new"
+	RelSubsection  = "subsection"
+)
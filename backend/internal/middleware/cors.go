@@ -4,18 +4,29 @@ import (
     "github.com/gin-gonic/gin"
 )
 
-func CORS() gin.HandlerFunc {
+// CORS 只对配置中允许的前端地址返回跨域响应头
+func CORS(allowedOrigins []string) gin.HandlerFunc {
+    allowed := make(map[string]bool, len(allowedOrigins))
+    for _, origin := range allowedOrigins {
+        allowed[origin] = true
+    }
+
     return func(c *gin.Context) {
-        c.Writer.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-        c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-        c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-        c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+        origin := c.Request.Header.Get("Origin")
+        if allowed[origin] {
+            c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+            c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+            c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+            c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+            c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, Content-Range, X-Request-ID")
+            c.Writer.Header().Add("Vary", "Origin")
 
-        if c.Request.Method == "OPTIONS" {
-            c.AbortWithStatus(204)
-            return
+            if c.Request.Method == "OPTIONS" {
+                c.AbortWithStatus(204)
+                return
+            }
         }
 
         c.Next()
     }
-} 
\ No newline at end of file
+} 
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+    "errors"
     "github.com/gin-gonic/gin"
     "github.com/golang-jwt/jwt/v4"
     "net/http"
@@ -13,10 +14,25 @@ type Claims struct {
     jwt.RegisteredClaims
 }
 
-const (
-    JWTSecret = "your_jwt_secret" // 在实际应用中应该从配置文件读取
+// 签名密钥和有效期，启动时由 ConfigureJWT 从配置设置
+var (
+    jwtSecret []byte
+    jwtExpire = 24 * time.Hour
 )
 
+// ConfigureJWT 设置 token 的签名密钥和有效期，需在处理请求前调用
+func ConfigureJWT(secret string, expire time.Duration) {
+    jwtSecret = []byte(secret)
+    jwtExpire = expire
+}
+
+func keyFunc(token *jwt.Token) (interface{}, error) {
+    if len(jwtSecret) == 0 {
+        return nil, errors.New("jwt secret is not configured")
+    }
+    return jwtSecret, nil
+}
+
 func JWTAuth() gin.HandlerFunc {
     return func(c *gin.Context) {
         // 从 Header 中获取 token
@@ -40,9 +56,7 @@ func JWTAuth() gin.HandlerFunc {
         }
 
         // 解析 token
-        token, err := jwt.ParseWithClaims(parts[1], &Claims{}, func(token *jwt.Token) (interface{}, error) {
-            return []byte(JWTSecret), nil
-        })
+        token, err := jwt.ParseWithClaims(parts[1], &Claims{}, keyFunc)
 
         if err != nil {
             c.JSON(http.StatusUnauthorized, gin.H{
@@ -71,25 +85,66 @@ func JWTAuth() gin.HandlerFunc {
             return
         }
 
+        // 检查账号是否被封禁、会话是否已被吊销
+        if err := validateSession(claims); err != nil {
+            c.JSON(http.StatusUnauthorized, gin.H{
+                "error": err.Error(),
+            })
+            c.Abort()
+            return
+        }
+
         // 将用户ID存储在上下文中
         c.Set("userID", claims.UserID)
         c.Next()
     }
 }
 
+// OptionalJWTAuth 携带有效 token 时设置用户ID，否则以游客身份继续
+func OptionalJWTAuth() gin.HandlerFunc {
+    return func(c *gin.Context) {
+        parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+        if len(parts) == 2 && parts[0] == "Bearer" {
+            if claims, err := ParseToken(parts[1]); err == nil {
+                c.Set("userID", claims.UserID)
+            }
+        }
+        c.Next()
+    }
+}
+
+// ParseToken 校验 token 并返回其中的声明，供无法使用请求头的场景（如 WebSocket）使用
+func ParseToken(tokenString string) (*Claims, error) {
+    token, err := jwt.ParseWithClaims(tokenString, &Claims{}, keyFunc)
+    if err != nil {
+        return nil, err
+    }
+    claims, ok := token.Claims.(*Claims)
+    if !ok || !token.Valid {
+        return nil, errors.New("invalid token claims")
+    }
+    if err := validateSession(claims); err != nil {
+        return nil, err
+    }
+    return claims, nil
+}
+
 // GenerateToken 生成JWT token
 func GenerateToken(userID uint) (string, error) {
+    if len(jwtSecret) == 0 {
+        return "", errors.New("jwt secret is not configured")
+    }
     claims := Claims{
         UserID: userID,
         RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // token有效期24小时
+            ExpiresAt: jwt.NewNumericDate(time.Now().Add(jwtExpire)),
             IssuedAt:  jwt.NewNumericDate(time.Now()),
             NotBefore: jwt.NewNumericDate(time.Now()),
         },
     }
 
     token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(JWTSecret))
+    return token.SignedString(jwtSecret)
 }
 
 // GetUserID 从上下文中获取用户ID的辅助函数
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"ai-novel-platform/internal/policy"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const contextRoleKey = "userRole"
+
+// RoleLoader 根据用户ID读取当前角色
+type RoleLoader func(userID uint) (string, error)
+
+var (
+	roleLoaderMu sync.RWMutex
+	roleLoader   RoleLoader
+)
+
+// SetRoleLoader 设置读取用户角色的方法，启动时由 main 注入。
+// 角色每次请求从数据库读取，调整角色后立即生效，不必等 token 过期
+func SetRoleLoader(loader RoleLoader) {
+	roleLoaderMu.Lock()
+	defer roleLoaderMu.Unlock()
+	roleLoader = loader
+}
+
+// CurrentActor 获取当前请求的用户及其角色，游客的 UserID 为 0。角色在同一请求内只读取一次
+func CurrentActor(c *gin.Context) policy.Actor {
+	userID := GetUserID(c)
+	if userID == 0 {
+		return policy.Actor{}
+	}
+	if role, ok := c.Get(contextRoleKey); ok {
+		return policy.Actor{UserID: userID, Role: role.(string)}
+	}
+
+	roleLoaderMu.RLock()
+	loader := roleLoader
+	roleLoaderMu.RUnlock()
+
+	role := policy.RoleReader
+	if loader != nil {
+		loaded, err := loader(userID)
+		if err != nil {
+			log.Printf("Failed to load role of user %d: %v", userID, err)
+			loaded = ""
+		}
+		role = loaded
+	}
+	c.Set(contextRoleKey, role)
+	return policy.Actor{UserID: userID, Role: role}
+}
+
+// RequirePermission 要求当前用户拥有指定权限，需放在 JWTAuth 之后
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := CurrentActor(c)
+		if actor.UserID == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+			c.Abort()
+			return
+		}
+		if !actor.Can(permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	contextRequestIDKey = "requestID"
+)
+
+// 只接受由字母、数字、下划线和连字符组成的请求ID，避免把任意内容写入日志
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// RequestID 为每个请求分配请求ID：沿用上游代理传入的合法 X-Request-ID，否则生成新的，并在响应头中返回
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(contextRequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID 获取当前请求的请求ID，未经过 RequestID 中间件时返回空字符串
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(contextRequestIDKey)
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
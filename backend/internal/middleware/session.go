@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionValidator 检查用户的 token 是否仍然有效，账号被封禁或会话被吊销时返回错误
+type SessionValidator func(userID uint, issuedAt time.Time) error
+
+var (
+	sessionValidatorMu sync.RWMutex
+	sessionValidator   SessionValidator
+)
+
+// SetSessionValidator 设置会话校验方法，启动时由 main 注入。
+// 每次请求都会校验，封禁和强制重置密码无需等 token 过期即可生效
+func SetSessionValidator(validator SessionValidator) {
+	sessionValidatorMu.Lock()
+	defer sessionValidatorMu.Unlock()
+	sessionValidator = validator
+}
+
+func validateSession(claims *Claims) error {
+	sessionValidatorMu.RLock()
+	validator := sessionValidator
+	sessionValidatorMu.RUnlock()
+	if validator == nil {
+		return nil
+	}
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	return validator(claims.UserID, issuedAt)
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// 书签和批注的颜色
+var AnnotationColors = []string{"yellow", "green", "blue", "pink", "purple"}
+
+// Bookmark 书签，锚定到章节内的字符位置
+type Bookmark struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;index"`
+	NovelID   uint      `json:"novelId" gorm:"not null;index"`
+	ChapterID uint      `json:"chapterId" gorm:"not null;index"`
+	Offset    int       `json:"offset"`                // 章节内的字符偏移
+	Quote     string    `json:"quote" gorm:"size:255"` // 书签位置的文本片段，章节修改后用于重新定位
+	Color     string    `json:"color" gorm:"size:20;default:yellow"`
+	Note      string    `json:"note" gorm:"type:text"`
+	IsPublic  bool      `json:"isPublic" gorm:"default:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Annotation 划线批注，锚定到章节内的字符区间，并保存引用文本用于章节修改后的重新定位
+type Annotation struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null;index"`
+	NovelID     uint      `json:"novelId" gorm:"not null;index"`
+	ChapterID   uint      `json:"chapterId" gorm:"not null;index"`
+	StartOffset int       `json:"startOffset"` // 起始字符偏移（含）
+	EndOffset   int       `json:"endOffset"`   // 结束字符偏移（不含）
+	Quote       string    `json:"quote" gorm:"type:text"`
+	Prefix      string    `json:"-" gorm:"size:255"` // 引用文本之前的上下文
+	Suffix      string    `json:"-" gorm:"size:255"` // 引用文本之后的上下文
+	Color       string    `json:"color" gorm:"size:20;default:yellow"`
+	Note        string    `json:"note" gorm:"type:text"`
+	IsPublic    bool      `json:"isPublic" gorm:"default:false"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	Orphaned bool `json:"orphaned" gorm:"-"` // 章节修改后无法重新定位
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计对象类型
+const (
+	AuditTargetUser       = "user"
+	AuditTargetNovel      = "novel"
+	AuditTargetChapter    = "chapter"
+	AuditTargetReport     = "report"
+	AuditTargetCategory   = "category"
+	AuditTargetTag        = "tag"
+	AuditTargetModeration = "moderation"
+	AuditTargetDuplicate  = "duplicate"
+)
+
+// ErrAuditLogImmutable 审计日志只能追加，不能修改或删除
+var ErrAuditLogImmutable = errors.New("audit logs are append-only")
+
+// AuditLog 安全和内容相关操作的审计日志，只追加不修改
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ActorID    uint      `json:"actorId" gorm:"not null;index"`        // 0 表示未登录，如登录失败
+	Action     string    `json:"action" gorm:"size:50;not null;index"` // 如 auth.login、user.ban、novel.hide
+	TargetType string    `json:"targetType" gorm:"size:20;not null;index:idx_audit_logs_target,priority:1"`
+	TargetID   uint      `json:"targetId" gorm:"not null;index:idx_audit_logs_target,priority:2"`
+	Reason     string    `json:"reason" gorm:"size:255"`
+	Detail     string    `json:"detail" gorm:"type:text"` // 操作对象的说明，如小说标题
+	Before     string    `json:"before" gorm:"type:text"` // 变更前的摘要
+	After      string    `json:"after" gorm:"type:text"`  // 变更后的摘要
+	IP         string    `json:"ip" gorm:"size:45"`
+	UserAgent  string    `json:"userAgent" gorm:"size:255"`
+	RequestID  string    `json:"requestId" gorm:"size:64;index"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index"`
+}
+
+// BeforeUpdate 禁止修改审计日志
+func (AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete 禁止删除审计日志
+func (AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
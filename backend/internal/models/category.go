@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// Category 分类目录，支持多级；小说通过 Slug 引用分类
+type Category struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	ParentID  *uint       `json:"parentId" gorm:"index"`
+	Slug      string      `json:"slug" gorm:"size:50;not null;uniqueIndex"`
+	Name      string      `json:"name" gorm:"size:50;not null"`
+	Icon      string      `json:"icon" gorm:"size:255"`
+	SortOrder int         `json:"sortOrder" gorm:"default:0"`
+	Aliases   StringArray `json:"aliases" gorm:"type:json"` // 其他常见写法，用于把旧的自由文本分类映射过来
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+
+	NovelCount int64      `json:"novelCount" gorm:"-"` // 本分类及所有子分类下的小说数
+	Children   []Category `json:"children,omitempty" gorm:"-"`
+}
@@ -4,15 +4,25 @@ import (
 	"time"
 )
 
+// 章节状态
+const (
+	ChapterStatusDraft     = 0 // 草稿
+	ChapterStatusPublished = 1 // 已发布
+	ChapterStatusReviewing = 2 // 待审核
+	ChapterStatusHidden    = 3 // 被管理员隐藏，作者不能自行恢复
+)
+
 type Chapter struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	NovelID   uint      `json:"novelId" gorm:"not null"`
-	Title     string    `json:"title" gorm:"size:100;not null"`
-	Content   string    `json:"content" gorm:"type:text"`
-	WordCount int       `json:"wordCount"`
-	Order     int       `json:"order" gorm:"not null"` // 章节顺序
-	Status    int       `json:"status" gorm:"default:0"`
-	Novel     Novel     `json:"-" gorm:"foreignKey:NovelID"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updateTime"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	NovelID      uint      `json:"novelId" gorm:"not null"`
+	Title        string    `json:"title" gorm:"size:100;not null"`
+	Content      string    `json:"content" gorm:"type:text"`
+	WordCount    int       `json:"wordCount"`
+	Order        int       `json:"order" gorm:"not null"` // 章节顺序
+	Status       int       `json:"status" gorm:"default:0"`
+	ReadCount    int       `json:"readCount" gorm:"default:0"`             // 按天去重后的累计阅读人次
+	HiddenReason string    `json:"hiddenReason,omitempty" gorm:"size:255"` // 被管理员隐藏的原因
+	Novel        Novel     `json:"-" gorm:"foreignKey:NovelID"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updateTime"`
 }
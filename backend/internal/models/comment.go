@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 评论状态
+const (
+	CommentStatusNormal  = 0 // 正常
+	CommentStatusHidden  = 1 // 因举报过多被隐藏，等待作者或管理员处理
+	CommentStatusPending = 2 // 命中敏感词，等待人工审核后公开
+)
+
+// 举报处理状态
+const (
+	CommentReportPending  = 0 // 待处理
+	CommentReportResolved = 1 // 已处理（评论被删除）
+	CommentReportRejected = 2 // 已驳回
+)
+
+// Comment 小说或章节评论；ParentID 为 0 时为一级评论，回复统一挂在 RootID 所指的一级评论下
+type Comment struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	NovelID        uint           `json:"novelId" gorm:"not null;index:idx_comments_target,priority:1"`
+	ChapterID      uint           `json:"chapterId" gorm:"not null;default:0;index:idx_comments_target,priority:2"` // 0 表示针对整部小说的评论
+	ParagraphIndex *int           `json:"paragraphIndex" gorm:"index:idx_comments_target,priority:3"`               // 段评所在段落序号，为空表示不是段评
+	ParagraphHash  string         `json:"-" gorm:"size:16"`                                                         // 段落指纹，章节修改后用于重新定位
+	ParagraphText  string         `json:"-" gorm:"size:255"`                                                        // 段落开头片段，指纹不匹配时用于模糊匹配
+	AnchorOrphaned bool           `json:"anchorOrphaned" gorm:"default:false"`                                      // 章节修改后无法重新定位到段落
+	UserID         uint           `json:"userId" gorm:"not null;index"`
+	ParentID       uint           `json:"parentId" gorm:"not null;default:0"`      // 直接回复的评论
+	RootID         uint           `json:"rootId" gorm:"not null;default:0;index"`  // 所属一级评论，一级评论为 0
+	ReplyToUserID  uint           `json:"replyToUserId" gorm:"not null;default:0"` // 被回复的用户
+	Content        string         `json:"content" gorm:"type:text;not null"`
+	LikeCount      int            `json:"likeCount" gorm:"default:0"`
+	ReplyCount     int            `json:"replyCount" gorm:"default:0"`
+	ReportCount    int            `json:"-" gorm:"default:0"`
+	IsPinned       bool           `json:"isPinned" gorm:"default:false"`
+	Status         int            `json:"status" gorm:"default:0"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+
+	Author      *CommentUser `json:"author,omitempty" gorm:"-"`
+	ReplyToUser *CommentUser `json:"replyToUser,omitempty" gorm:"-"`
+	Liked       bool         `json:"liked" gorm:"-"`   // 当前用户是否已点赞
+	Deleted     bool         `json:"deleted" gorm:"-"` // 已删除但仍有回复的一级评论，内容不再展示
+}
+
+// CommentUser 评论中展示的用户信息
+type CommentUser struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+}
+
+// CommentLike 评论点赞
+type CommentLike struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"commentId" gorm:"not null;uniqueIndex:idx_comment_likes_comment_user"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_comment_likes_comment_user"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CommentReport 评论举报
+type CommentReport struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"commentId" gorm:"not null;uniqueIndex:idx_comment_reports_comment_user"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_comment_reports_comment_user"`
+	Reason    string    `json:"reason" gorm:"size:255"`
+	Status    int       `json:"status" gorm:"default:0"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// 疑似重复内容的处理状态
+const (
+	DuplicatePending   = 0 // 待处理
+	DuplicateConfirmed = 1 // 确认抄袭或重复上传
+	DuplicateDismissed = 2 // 误报，如作者本人在其他平台的授权转载
+)
+
+// ChapterFingerprint 章节正文的 SimHash 指纹，按段建索引用于查找近似重复的章节
+type ChapterFingerprint struct {
+	ChapterID uint      `json:"chapterId" gorm:"primaryKey;autoIncrement:false"`
+	NovelID   uint      `json:"novelId" gorm:"not null;index"`
+	AuthorID  uint      `json:"authorId" gorm:"not null;index"`
+	SimHash   uint64    `json:"-" gorm:"not null"`
+	Band0     uint16    `json:"-" gorm:"not null;index"`
+	Band1     uint16    `json:"-" gorm:"not null;index"`
+	Band2     uint16    `json:"-" gorm:"not null;index"`
+	Band3     uint16    `json:"-" gorm:"not null;index"`
+	Shingles  int       `json:"shingles"` // 参与计算的特征数
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// DuplicateFlag 不同作者之间近似重复的一对章节。ChapterID 为后创建的章节，即疑似抄袭的一方
+type DuplicateFlag struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	ChapterID      uint       `json:"chapterId" gorm:"not null;uniqueIndex:idx_duplicate_flags_pair,priority:1"`
+	MatchChapterID uint       `json:"matchChapterId" gorm:"not null;uniqueIndex:idx_duplicate_flags_pair,priority:2;index"`
+	NovelID        uint       `json:"novelId" gorm:"not null;index"`
+	MatchNovelID   uint       `json:"matchNovelId" gorm:"not null"`
+	AuthorID       uint       `json:"authorId" gorm:"not null;index"`
+	MatchAuthorID  uint       `json:"matchAuthorId" gorm:"not null"`
+	Distance       int        `json:"distance"`   // 指纹的海明距离
+	Similarity     float64    `json:"similarity"` // 按海明距离换算的相似度
+	Status         int        `json:"status" gorm:"default:0;index"`
+	Note           string     `json:"note" gorm:"size:255"` // 处理说明
+	ReviewerID     uint       `json:"reviewerId" gorm:"not null;default:0"`
+	ReviewedAt     *time.Time `json:"reviewedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
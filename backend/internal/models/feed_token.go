@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// FeedToken 用户的订阅令牌，供无法使用 JWT 的阅读器（如 KOReader）访问 OPDS 书库
+type FeedToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"userId" gorm:"not null;uniqueIndex"`
+	Token      string     `json:"token" gorm:"size:64;not null;uniqueIndex"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// Follow 用户关注作者
+type Follow struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	FollowerID uint      `json:"followerId" gorm:"not null;uniqueIndex:idx_follows_follower_followee"`
+	FolloweeID uint      `json:"followeeId" gorm:"not null;uniqueIndex:idx_follows_follower_followee;index"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
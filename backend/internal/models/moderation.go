@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// 需要审核的内容类型
+const (
+	ModerationContentNovel    = "novel"
+	ModerationContentChapter  = "chapter"
+	ModerationContentComment  = "comment"
+	ModerationContentUsername = "username"
+)
+
+// 审核状态
+const (
+	ModerationPending  = 0 // 待审核
+	ModerationApproved = 1 // 审核通过，内容已公开
+	ModerationRejected = 2 // 审核未通过
+)
+
+// ModerationItem 命中敏感词、等待人工审核的内容；同一内容同时只有一条待审核记录
+type ModerationItem struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ContentType string     `json:"contentType" gorm:"size:20;not null;index:idx_moderation_items_content,priority:1"`
+	ContentID   uint       `json:"contentId" gorm:"not null;index:idx_moderation_items_content,priority:2"`
+	AuthorID    uint       `json:"authorId" gorm:"not null;index"`
+	Status      int        `json:"status" gorm:"default:0;index"`
+	Title       string     `json:"title" gorm:"size:255"`      // 内容的标题，如章节名，便于审核人员识别
+	Words       string     `json:"words" gorm:"size:255"`      // 命中的敏感词，以逗号分隔
+	Categories  string     `json:"categories" gorm:"size:255"` // 命中的敏感词分类，以逗号分隔
+	Excerpt     string     `json:"excerpt" gorm:"type:text"`   // 命中位置附近的原文
+	Reason      string     `json:"reason" gorm:"size:255"`     // 审核意见，驳回时展示给作者
+	ReviewerID  uint       `json:"reviewerId" gorm:"not null;default:0"`
+	ReviewedAt  *time.Time `json:"reviewedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
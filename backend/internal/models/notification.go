@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// 通知类型
+const (
+	NotificationNewChapter   = "new_chapter"   // 收藏的小说发布新章节
+	NotificationCommentReply = "comment_reply" // 评论被回复
+	NotificationNewFollower  = "new_follower"  // 新增关注者
+	NotificationNovelReview  = "novel_review"  // 作品收到书评
+)
+
+// NotificationTypes 所有通知类型
+var NotificationTypes = []string{
+	NotificationNewChapter,
+	NotificationCommentReply,
+	NotificationNewFollower,
+	NotificationNovelReview,
+}
+
+// Notification 用户收件箱中的通知
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"userId" gorm:"not null;index:idx_notifications_user_read,priority:1"`
+	IsRead    bool       `json:"isRead" gorm:"default:false;index:idx_notifications_user_read,priority:2"`
+	Type      string     `json:"type" gorm:"size:32;not null"`
+	ActorID   uint       `json:"actorId"` // 触发通知的用户，系统通知为 0
+	NovelID   uint       `json:"novelId"`
+	ChapterID uint       `json:"chapterId"`
+	CommentID uint       `json:"commentId"`
+	ReviewID  uint       `json:"reviewId"`
+	Title     string     `json:"title" gorm:"size:255"`
+	Content   string     `json:"content" gorm:"type:text"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// NotificationDispatch 章节更新通知的分发进度，同一章节只分发一次，章节撤回后重新发布时不再重复通知收藏用户。
+// 分发按收藏记录分批进行，每批通知与 LastFavoriteID 在同一事务中写入，中断后从断点继续
+type NotificationDispatch struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ChapterID      uint      `json:"chapterId" gorm:"not null;uniqueIndex:idx_notification_dispatches_chapter_type"`
+	Type           string    `json:"type" gorm:"size:32;not null;uniqueIndex:idx_notification_dispatches_chapter_type"`
+	NovelID        uint      `json:"novelId"`
+	LastFavoriteID uint      `json:"lastFavoriteId"`
+	InProgress     bool      `json:"inProgress" gorm:"not null;default:false;index"` // 尚未分发完成
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// NotificationOutbox 队列已满或服务停止时暂存的通知事件，由后台任务稍后分发
+type NotificationOutbox struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Payload   string    `json:"payload" gorm:"type:text;not null"` // 事件的 JSON
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NotificationPreference 用户的通知偏好，未设置的类型默认开启
+type NotificationPreference struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	UserID  uint   `json:"userId" gorm:"not null;uniqueIndex:idx_notification_prefs_user_type"`
+	Type    string `json:"type" gorm:"size:32;not null;uniqueIndex:idx_notification_prefs_user_type"`
+	Enabled bool   `json:"enabled"`
+}
@@ -20,12 +20,14 @@ type OutlineItem struct {
 
 // Character 角色结构
 type Character struct {
+	ID          uint   `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
 
 // Location 地点结构
 type Location struct {
+	ID          uint   `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
@@ -107,25 +109,55 @@ func (sa *StringArray) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, sa)
 }
 
+// RatingStats 评分汇总，随书评增删改增量维护
+type RatingStats struct {
+	Count   int     `gorm:"default:0" json:"count"`
+	Sum     int     `gorm:"default:0" json:"-"`
+	Average float64 `gorm:"default:0" json:"average"`
+	Score   float64 `gorm:"default:0" json:"score"` // 贝叶斯平均分，用于排序，避免少量评分造成的偏差
+	Star1   int     `gorm:"default:0" json:"star1"`
+	Star2   int     `gorm:"default:0" json:"star2"`
+	Star3   int     `gorm:"default:0" json:"star3"`
+	Star4   int     `gorm:"default:0" json:"star4"`
+	Star5   int     `gorm:"default:0" json:"star5"`
+}
+
+// 小说状态
+const (
+	NovelStatusOngoing   = 0 // 连载中
+	NovelStatusCompleted = 1 // 已完结
+)
+
 type Novel struct {
-	ID            uint           `gorm:"primaryKey" json:"id"`
-	Title         string         `gorm:"size:255;not null" json:"title"`
-	Description   string         `gorm:"type:text" json:"description"`
-	CoverURL      string         `gorm:"size:255" json:"coverUrl"`
-	Category      string         `gorm:"size:50" json:"category"`
-	Status        int            `gorm:"default:0" json:"status"` // 0: 连载中, 1: 已完结
-	WordCount     int            `gorm:"default:0" json:"wordCount"`
-	ReadCount     int            `gorm:"default:0" json:"readCount"`
-	FavoriteCount int            `gorm:"default:0" json:"favoriteCount"`
-	AuthorID      uint           `gorm:"not null" json:"authorId"`
-	Author        User           `gorm:"foreignKey:AuthorID" json:"author"`
-	CreatedAt     time.Time      `json:"createdAt"`
-	UpdatedAt     time.Time      `json:"updatedAt"`
-	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
-	Tags          StringArray    `json:"tags" gorm:"type:json"`
-	NovelOutline  *NovelOutline  `json:"novelOutline" gorm:"type:json"` // 小说大纲，包含世界观设定
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Title          string         `gorm:"size:255;not null" json:"title"`
+	Description    string         `gorm:"type:text" json:"description"`
+	CoverURL       string         `gorm:"size:255" json:"coverUrl"`
+	Category       string         `gorm:"size:50" json:"category"`
+	Status         int            `gorm:"default:0" json:"status"`  // 0: 连载中, 1: 已完结
+	CompletedAt    *time.Time     `gorm:"index" json:"completedAt"` // 完结时间，用于新完结榜
+	WordCount      int            `gorm:"default:0" json:"wordCount"`
+	ReadCount      int            `gorm:"default:0" json:"readCount"` // 按天去重后的累计阅读人次
+	FavoriteCount  int            `gorm:"default:0" json:"favoriteCount"`
+	CommentCount   int            `gorm:"default:0" json:"commentCount"`
+	Rating         RatingStats    `gorm:"embedded;embeddedPrefix:rating_" json:"rating"`
+	AuthorID       uint           `gorm:"not null" json:"authorId"`
+	Author         User           `gorm:"foreignKey:AuthorID" json:"author"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	Tags           StringArray    `json:"tags" gorm:"type:json"`
+	NovelOutline   *NovelOutline  `json:"novelOutline" gorm:"type:json"`   // 小说大纲，包含世界观设定
+	OutlineVersion int            `gorm:"default:0" json:"outlineVersion"` // 当前大纲版本号
+	HiddenAt       *time.Time     `gorm:"index" json:"hiddenAt,omitempty"` // 被管理员隐藏的时间，隐藏后不出现在任何公开列表中
+	HiddenReason   string         `gorm:"size:255" json:"hiddenReason,omitempty"`
 }
 
 func (Novel) TableName() string {
 	return "novels"
 }
+
+// VisibleNovels 公开场景使用的查询范围，排除被管理员隐藏的小说
+func VisibleNovels(db *gorm.DB) *gorm.DB {
+	return db.Where("novels.hidden_at IS NULL")
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// NovelNeighbor 定期预计算的相似小说列表，按 Rank 升序即相似度从高到低
+type NovelNeighbor struct {
+	ID         uint      `json:"-" gorm:"primaryKey"`
+	NovelID    uint      `json:"novelId" gorm:"not null;index:idx_novel_neighbor,priority:1"`
+	Rank       int       `json:"rank" gorm:"not null;index:idx_novel_neighbor,priority:2"`
+	NeighborID uint      `json:"neighborId" gorm:"not null"`
+	Score      float64   `json:"score"`
+	ComputedAt time.Time `json:"computedAt"`
+}
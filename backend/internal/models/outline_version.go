@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// 大纲版本来源
+const (
+	OutlineSourceInitial = "initial" // 启用历史前已存在的大纲
+	OutlineSourceManual  = "manual"
+	OutlineSourceImport  = "import"
+	OutlineSourceAI      = "ai"
+	OutlineSourceMerge   = "merge"
+	OutlineSourceRestore = "restore"
+)
+
+// OutlineVersion 大纲历史版本快照
+type OutlineVersion struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	NovelID       uint          `json:"novelId" gorm:"not null;uniqueIndex:idx_outline_versions_novel_version"`
+	Version       int           `json:"version" gorm:"not null;uniqueIndex:idx_outline_versions_novel_version"`
+	ParentVersion int           `json:"parentVersion"` // 提交时的最新版本
+	BaseVersion   int           `json:"baseVersion"`   // 三方合并时客户端基于的版本
+	AuthorID      uint          `json:"authorId" gorm:"not null"`
+	Source        string        `json:"source" gorm:"size:20;not null"`
+	NodeCount     int           `json:"nodeCount"`
+	Outline       *NovelOutline `json:"outline,omitempty" gorm:"type:json"` // 版本列表不加载大纲内容，为 nil
+	CreatedAt     time.Time     `json:"createdAt"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// 榜单类型
+const (
+	RankingBoardRead      = "read"      // 阅读榜
+	RankingBoardFavorite  = "favorite"  // 收藏榜
+	RankingBoardRising    = "rising"    // 飙升榜：阅读量相对上一周期的增长
+	RankingBoardCompleted = "completed" // 新完结榜
+	RankingBoardRated     = "rated"     // 好评榜
+)
+
+// 统计周期
+const (
+	RankingPeriodDay   = "day"
+	RankingPeriodWeek  = "week"
+	RankingPeriodMonth = "month"
+	RankingPeriodAll   = "all"
+)
+
+// RankingEntry 定期物化的榜单快照，Category 为空表示全站榜
+type RankingEntry struct {
+	ID         uint      `json:"-" gorm:"primaryKey"`
+	Board      string    `json:"board" gorm:"size:20;not null;index:idx_ranking_lookup,priority:1"`
+	Period     string    `json:"period" gorm:"size:10;not null;index:idx_ranking_lookup,priority:2"`
+	Category   string    `json:"category" gorm:"size:50;not null;index:idx_ranking_lookup,priority:3"`
+	Rank       int       `json:"rank" gorm:"not null;index:idx_ranking_lookup,priority:4"`
+	NovelID    uint      `json:"novelId" gorm:"not null"`
+	Score      float64   `json:"score"`
+	ComputedAt time.Time `json:"computedAt"`
+}
@@ -6,12 +6,17 @@ import (
 
 // ReadProgress 用户阅读进度记录
 type ReadProgress struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    uint      `json:"userId" gorm:"not null"`
-	NovelID   uint      `json:"novelId" gorm:"not null"`
-	ChapterID uint      `json:"chapterId" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	UserID          uint      `json:"userId" gorm:"not null;uniqueIndex:idx_read_progress_user_novel"`
+	NovelID         uint      `json:"novelId" gorm:"not null;uniqueIndex:idx_read_progress_user_novel"`
+	ChapterID       uint      `json:"chapterId" gorm:"not null"`
+	ParagraphIndex  int       `json:"paragraphIndex" gorm:"default:0"` // 章节内段落序号
+	CharOffset      int       `json:"charOffset" gorm:"default:0"`     // 章节内字符偏移
+	Percentage      float64   `json:"percentage" gorm:"default:0"`     // 章节内阅读百分比 0-100
+	DeviceID        string    `json:"deviceId" gorm:"size:64"`         // 最后一次上报进度的设备
+	ClientUpdatedAt time.Time `json:"clientUpdatedAt"`                 // 客户端记录进度的时间，多端冲突时按此判断
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
 
 	// 外键关联
 	User    User    `json:"-" gorm:"foreignKey:UserID"`
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+// ReadStat 每日独立读者数；ChapterID 为 0 时为整本小说的统计
+type ReadStat struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Date      time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_read_stats_date_target,priority:1"`
+	NovelID   uint      `json:"novelId" gorm:"not null;uniqueIndex:idx_read_stats_date_target,priority:2;index"`
+	ChapterID uint      `json:"chapterId" gorm:"not null;uniqueIndex:idx_read_stats_date_target,priority:3"`
+	Readers   int       `json:"readers" gorm:"not null"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// Review 书评，每位用户对每部小说最多一篇
+type Review struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	NovelID      uint      `json:"novelId" gorm:"not null;uniqueIndex:idx_reviews_novel_user"`
+	UserID       uint      `json:"userId" gorm:"not null;uniqueIndex:idx_reviews_novel_user;index"`
+	Score        int       `json:"score" gorm:"not null"` // 1-5 分
+	Content      string    `json:"content" gorm:"type:text"`
+	IsSpoiler    bool      `json:"isSpoiler" gorm:"default:false"`
+	HelpfulCount int       `json:"helpfulCount" gorm:"default:0"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"index"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ReviewVote 书评的“有用”投票
+type ReviewVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"reviewId" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	CreatedAt time.Time `json:"createdAt"`
+}
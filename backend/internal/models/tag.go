@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// 标签分类
+const (
+	TagCategoryGenre   = "genre"   // 题材
+	TagCategoryElement = "element" // 元素
+	TagCategoryStyle   = "style"   // 风格
+	TagCategoryOther   = "other"   // 作者自定义，未归类
+)
+
+// Tag 规范化后的标签，小说上的同义写法都归并到同一个标签
+type Tag struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	Name       string       `json:"name" gorm:"size:50;not null;uniqueIndex"`
+	Category   string       `json:"category" gorm:"size:20;not null;index"`
+	UsageCount int          `json:"usageCount" gorm:"default:0;index"` // 使用该标签的小说数
+	Synonyms   []TagSynonym `json:"synonyms,omitempty" gorm:"foreignKey:TagID"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	UpdatedAt  time.Time    `json:"updatedAt"`
+}
+
+// TagSynonym 标签的同义写法，例如“仙侠”“修真”对应“修仙”
+type TagSynonym struct {
+	ID    uint   `json:"-" gorm:"primaryKey"`
+	TagID uint   `json:"-" gorm:"not null;index"`
+	Name  string `json:"name" gorm:"size:50;not null;uniqueIndex"`
+}
+
+// NovelTag 小说与标签的关联
+type NovelTag struct {
+	NovelID   uint      `json:"novelId" gorm:"primaryKey"`
+	TagID     uint      `json:"tagId" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
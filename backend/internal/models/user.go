@@ -13,10 +13,23 @@ type User struct {
 	Email        string `gorm:"unique;not null"`
 	Avatar       string `gorm:"default:''"`
 	Bio          string `gorm:"type:text"`
+	Role         string `gorm:"size:20;not null;default:reader;index"` // 角色，见 policy 包
 	ResetToken   string `gorm:"default:''"`
 	ResetExpires time.Time
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	BannedAt     *time.Time // 封禁时间，为空表示未封禁
+	BannedUntil  *time.Time // 封禁截止时间，为空表示永久封禁
+	BanReason    string     `gorm:"size:255;default:''"`
+	// PasswordResetRequired 管理员要求重置密码，重置前不能登录
+	PasswordResetRequired bool `gorm:"default:false"`
+	// SessionsRevokedAt 在此之前签发的 token 全部失效
+	SessionsRevokedAt *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// IsBanned 判断用户在给定时间是否处于封禁中
+func (u *User) IsBanned(now time.Time) bool {
+	return u.BannedAt != nil && (u.BannedUntil == nil || u.BannedUntil.After(now))
 }
 
 // BeforeCreate 在创建记录前设置默认值
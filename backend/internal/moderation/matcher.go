@@ -0,0 +1,214 @@
+// Package moderation 敏感词检测：基于 Aho-Corasick 自动机一次扫描匹配全部敏感词，
+// 匹配前统一全半角和大小写并忽略空格、标点等分隔符，避免用“敏 感 词”之类的写法绕过
+package moderation
+
+import (
+	"sort"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// Severity 敏感词级别
+type Severity int
+
+const (
+	SeverityLow    Severity = 1 // 轻微，如粗口
+	SeverityMedium Severity = 2 // 一般，需要人工判断上下文
+	SeverityHigh   Severity = 3 // 严重，不允许出现
+)
+
+// Word 词库中的一个敏感词
+type Word struct {
+	Text     string   `json:"text"`
+	Category string   `json:"category"`
+	Severity Severity `json:"severity"`
+}
+
+// Hit 一次命中，Start、End 为原文中的字符（rune）下标，左闭右开
+type Hit struct {
+	Word     string   `json:"word"`
+	Category string   `json:"category"`
+	Severity Severity `json:"severity"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+}
+
+// Matcher Aho-Corasick 自动机，构建后只读，可并发使用
+type Matcher struct {
+	nodes   []acNode
+	words   []Word
+	lengths []int // 归一化后词的长度
+}
+
+type acNode struct {
+	next    map[rune]int
+	fail    int
+	outputs []int // 在该节点结束的词（words 下标），包含沿失败链可达的词
+}
+
+// NewMatcher 用词库构建自动机；归一化后为空的词会被忽略，重复的词保留级别最高的一条
+func NewMatcher(words []Word) *Matcher {
+	m := &Matcher{nodes: []acNode{{}}}
+	seen := make(map[string]int)
+	for _, w := range words {
+		key := normalizeWord(w.Text)
+		if key == "" {
+			continue
+		}
+		if i, ok := seen[key]; ok {
+			if w.Severity > m.words[i].Severity {
+				m.words[i] = w
+			}
+			continue
+		}
+		seen[key] = len(m.words)
+		m.words = append(m.words, w)
+		m.lengths = append(m.lengths, len([]rune(key)))
+		m.insert([]rune(key), len(m.words)-1)
+	}
+	m.build()
+	return m
+}
+
+// Len 词库中的词数
+func (m *Matcher) Len() int {
+	return len(m.words)
+}
+
+func (m *Matcher) insert(key []rune, word int) {
+	cur := 0
+	for _, r := range key {
+		next, ok := m.nodes[cur].next[r]
+		if !ok {
+			if m.nodes[cur].next == nil {
+				m.nodes[cur].next = make(map[rune]int)
+			}
+			next = len(m.nodes)
+			m.nodes = append(m.nodes, acNode{})
+			m.nodes[cur].next[r] = next
+		}
+		cur = next
+	}
+	m.nodes[cur].outputs = append(m.nodes[cur].outputs, word)
+}
+
+// build 按层序计算失败指针，并把失败链上的输出合并到当前节点
+func (m *Matcher) build() {
+	queue := make([]int, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for fail != 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if next, ok := m.nodes[fail].next[r]; ok && next != child {
+				m.nodes[child].fail = next
+			}
+			m.nodes[child].outputs = append(m.nodes[child].outputs, m.nodes[m.nodes[child].fail].outputs...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Find 找出文本中的全部命中，按出现位置排序；重叠的命中都会返回。
+// 以字母数字开头或结尾的英文词需要在单词边界上，避免误伤包含它的长单词
+func (m *Matcher) Find(text string) []Hit {
+	if len(m.words) == 0 {
+		return nil
+	}
+	// keys 为参与匹配的字符，positions 为它们在原文中的下标，分隔符不参与匹配
+	var keys []rune
+	var positions []int
+	var matches [][2]int // 词下标和结束位置（keys 下标，不含）
+	cur := 0
+	for i, r := range []rune(text) {
+		r, ok := normalizeRune(r)
+		if !ok {
+			continue
+		}
+		keys = append(keys, r)
+		positions = append(positions, i)
+		for cur != 0 {
+			if _, ok := m.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		cur = m.nodes[cur].next[r]
+		for _, w := range m.nodes[cur].outputs {
+			matches = append(matches, [2]int{w, len(keys)})
+		}
+	}
+
+	var hits []Hit
+	for _, match := range matches {
+		w, end := match[0], match[1]
+		start := end - m.lengths[w]
+		if isLatin(keys[start]) && start > 0 && isLatin(keys[start-1]) && positions[start-1] == positions[start]-1 {
+			continue
+		}
+		if isLatin(keys[end-1]) && end < len(keys) && isLatin(keys[end]) && positions[end] == positions[end-1]+1 {
+			continue
+		}
+		hits = append(hits, Hit{
+			Word:     m.words[w].Text,
+			Category: m.words[w].Category,
+			Severity: m.words[w].Severity,
+			Start:    positions[start],
+			End:      positions[end-1] + 1,
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Start < hits[j].Start })
+	return hits
+}
+
+// Mask 把命中范围内的文字替换为星号，范围内的分隔符保持原样
+func Mask(text string, hits []Hit) string {
+	if len(hits) == 0 {
+		return text
+	}
+	runes := []rune(text)
+	for _, h := range hits {
+		for i := h.Start; i < h.End && i < len(runes); i++ {
+			if _, ok := normalizeRune(runes[i]); ok {
+				runes[i] = '*'
+			}
+		}
+	}
+	return string(runes)
+}
+
+// normalizeRune 全角转半角、统一小写；分隔符返回 false，不参与匹配
+func normalizeRune(r rune) (rune, bool) {
+	if folded := width.LookupRune(r).Folded(); folded != 0 {
+		r = folded
+	}
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return 0, false
+	}
+	return unicode.ToLower(r), true
+}
+
+// isLatin 归一化后的半角字母或数字
+func isLatin(r rune) bool {
+	return r < unicode.MaxASCII
+}
+
+func normalizeWord(text string) string {
+	key := make([]rune, 0, len(text))
+	for _, r := range text {
+		if r, ok := normalizeRune(r); ok {
+			key = append(key, r)
+		}
+	}
+	return string(key)
+}
@@ -0,0 +1,190 @@
+package moderation
+
+import (
+	"sort"
+	"testing"
+)
+
+type span struct {
+	word       string
+	start, end int
+}
+
+func spans(hits []Hit) []span {
+	result := make([]span, 0, len(hits))
+	for _, h := range hits {
+		result = append(result, span{h.Word, h.Start, h.End})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].start != result[j].start {
+			return result[i].start < result[j].start
+		}
+		return result[i].end < result[j].end
+	})
+	return result
+}
+
+func equalSpans(a, b []span) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMatcherFind(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		text  string
+		want  []span
+	}{
+		{
+			name:  "overlapping words",
+			words: []string{"敏感", "感词"},
+			text:  "这是敏感词",
+			want:  []span{{"敏感", 2, 4}, {"感词", 3, 5}},
+		},
+		{
+			name:  "nested words",
+			words: []string{"敏感词", "感"},
+			text:  "敏感词",
+			want:  []span{{"敏感词", 0, 3}, {"感", 1, 2}},
+		},
+		{
+			name:  "word ends where another begins",
+			words: []string{"甲乙", "乙丙", "甲乙丙"},
+			text:  "甲乙丙",
+			want:  []span{{"甲乙", 0, 2}, {"甲乙丙", 0, 3}, {"乙丙", 1, 3}},
+		},
+		{
+			name:  "latin prefix of a latin word",
+			words: []string{"ab", "abc"},
+			text:  "abc",
+			want:  []span{{"abc", 0, 3}},
+		},
+		{
+			name:  "repeated occurrences",
+			words: []string{"坏"},
+			text:  "坏人做坏事",
+			want:  []span{{"坏", 0, 1}, {"坏", 3, 4}},
+		},
+		{
+			name:  "separators between characters",
+			words: []string{"敏感词"},
+			text:  "敏 感，词",
+			want:  []span{{"敏感词", 0, 5}},
+		},
+		{
+			name:  "full-width latin",
+			words: []string{"bad"},
+			text:  "ＢＡＤ",
+			want:  []span{{"bad", 0, 3}},
+		},
+		{
+			name:  "full-width digits",
+			words: []string{"110"},
+			text:  "打１１０",
+			want:  []span{{"110", 1, 4}},
+		},
+		{
+			name:  "upper case",
+			words: []string{"spam"},
+			text:  "no SPAM here",
+			want:  []span{{"spam", 3, 7}},
+		},
+		{
+			name:  "latin word inside a longer word",
+			words: []string{"ass"},
+			text:  "first class passage",
+			want:  []span{},
+		},
+		{
+			name:  "latin word at word boundaries",
+			words: []string{"ass"},
+			text:  "an ass, ass!",
+			want:  []span{{"ass", 3, 6}, {"ass", 8, 11}},
+		},
+		{
+			name:  "latin word spelled with separators",
+			words: []string{"ass"},
+			text:  "a.s.s",
+			want:  []span{{"ass", 0, 5}},
+		},
+		{
+			name:  "latin word next to CJK",
+			words: []string{"ass"},
+			text:  "你是ass吗",
+			want:  []span{{"ass", 2, 5}},
+		},
+		{
+			name:  "mixed CJK and latin word",
+			words: []string{"傻b"},
+			text:  "你傻B吧",
+			want:  []span{{"傻b", 1, 3}},
+		},
+		{
+			name:  "no match",
+			words: []string{"敏感词"},
+			text:  "这是一段正常的文字",
+			want:  []span{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := make([]Word, 0, len(tt.words))
+			for _, w := range tt.words {
+				words = append(words, Word{Text: w, Severity: SeverityMedium})
+			}
+			got := spans(NewMatcher(words).Find(tt.text))
+			if !equalSpans(got, tt.want) {
+				t.Fatalf("Find(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMatcherDeduplicates(t *testing.T) {
+	m := NewMatcher([]Word{
+		{Text: "敏感", Severity: SeverityLow},
+		{Text: "敏 感", Severity: SeverityHigh},
+		{Text: "  ", Severity: SeverityHigh},
+	})
+	if m.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", m.Len())
+	}
+	hits := m.Find("敏感")
+	if len(hits) != 1 || hits[0].Severity != SeverityHigh {
+		t.Fatalf("Find() = %+v, want one hit with the highest severity", hits)
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		name  string
+		words []string
+		text  string
+		want  string
+	}{
+		{"keeps separators", []string{"敏感词"}, "这是敏 感 词。", "这是* * *。"},
+		{"overlapping hits", []string{"敏感", "感词"}, "敏感词", "***"},
+		{"full-width", []string{"bad"}, "ＢＡＤ boy", "*** boy"},
+		{"no hits", []string{"敏感词"}, "正常", "正常"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := make([]Word, 0, len(tt.words))
+			for _, w := range tt.words {
+				words = append(words, Word{Text: w, Severity: SeverityMedium})
+			}
+			m := NewMatcher(words)
+			if got := Mask(tt.text, m.Find(tt.text)); got != tt.want {
+				t.Fatalf("Mask(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package moderation
+
+// Action 命中敏感词后的处理方式，数值越大越严格
+type Action int
+
+const (
+	ActionPass   Action = iota // 放行
+	ActionMask                 // 把敏感词替换为星号后放行
+	ActionReview               // 保存但不公开，进入人工审核队列
+	ActionBlock                // 直接拒绝
+)
+
+var actionNames = map[Action]string{
+	ActionPass:   "pass",
+	ActionMask:   "mask",
+	ActionReview: "review",
+	ActionBlock:  "block",
+}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// Policy 各级别敏感词对应的处理方式，未配置的级别放行
+type Policy map[Severity]Action
+
+// Verdict 一段文本的检测结果
+type Verdict struct {
+	Action Action
+	Hits   []Hit
+	Text   string // 处理后的文本，需要打码的词已替换为星号
+}
+
+// Check 检测文本并按策略给出结果：处理方式取所有命中中最严格的一种，
+// 策略为打码的词无论最终结果如何都会打码
+func Check(list *WordList, policy Policy, text string) Verdict {
+	verdict := Verdict{Action: ActionPass, Text: text}
+	verdict.Hits = list.Find(text)
+	var masked []Hit
+	for _, h := range verdict.Hits {
+		action := policy[h.Severity]
+		if action == ActionMask {
+			masked = append(masked, h)
+		}
+		if action > verdict.Action {
+			verdict.Action = action
+		}
+	}
+	verdict.Text = Mask(text, masked)
+	return verdict
+}
@@ -0,0 +1,130 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultCategory 词库中未写分类的词归入此类
+const DefaultCategory = "other"
+
+// severityNames 词库文件中级别可以写数字或名称
+var severityNames = map[string]Severity{
+	"low":    SeverityLow,
+	"medium": SeverityMedium,
+	"high":   SeverityHigh,
+}
+
+// ParseWords 解析词库：每行一个词，格式为“词<Tab>分类<Tab>级别”，分类和级别可省略（默认 other、medium）；
+// 空行和 # 开头的行会被忽略
+func ParseWords(content string) ([]Word, error) {
+	var words []Word
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		word := Word{Text: strings.TrimSpace(fields[0]), Category: DefaultCategory, Severity: SeverityMedium}
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			word.Category = strings.TrimSpace(fields[1])
+		}
+		if len(fields) > 2 {
+			severity, err := parseSeverity(strings.TrimSpace(fields[2]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			word.Severity = severity
+		}
+		words = append(words, word)
+	}
+	return words, scanner.Err()
+}
+
+func parseSeverity(value string) (Severity, error) {
+	if severity, ok := severityNames[strings.ToLower(value)]; ok {
+		return severity, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < int(SeverityLow) || n > int(SeverityHigh) {
+		return 0, fmt.Errorf("invalid severity %q", value)
+	}
+	return Severity(n), nil
+}
+
+// WordList 从文件加载的词库。重新加载时整体替换自动机，检测过程无需加锁；
+// 加载失败时继续使用上一版词库
+type WordList struct {
+	path    string
+	matcher atomic.Pointer[Matcher]
+
+	mu      sync.Mutex // 串行化重新加载
+	modTime time.Time
+}
+
+// NewWordList 创建词库，需调用 Reload 加载文件，加载前不会命中任何词
+func NewWordList(path string) *WordList {
+	l := &WordList{path: path}
+	l.matcher.Store(NewMatcher(nil))
+	return l
+}
+
+// Matcher 当前生效的自动机
+func (l *WordList) Matcher() *Matcher {
+	return l.matcher.Load()
+}
+
+// Find 在当前词库中查找命中
+func (l *WordList) Find(text string) []Hit {
+	return l.Matcher().Find(text)
+}
+
+// Reload 重新读取词库文件，返回加载的词数
+func (l *WordList) Reload() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return 0, err
+	}
+	return l.load(info.ModTime())
+}
+
+// ReloadIfChanged 文件修改时间变化时重新加载，返回是否重新加载
+func (l *WordList) ReloadIfChanged() (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(l.modTime) {
+		return false, nil
+	}
+	if _, err := l.load(info.ModTime()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (l *WordList) load(modTime time.Time) (int, error) {
+	content, err := os.ReadFile(l.path)
+	if err != nil {
+		return 0, err
+	}
+	words, err := ParseWords(string(content))
+	if err != nil {
+		return 0, fmt.Errorf("parse %s: %w", l.path, err)
+	}
+	matcher := NewMatcher(words)
+	l.matcher.Store(matcher)
+	l.modTime = modTime
+	return matcher.Len(), nil
+}
@@ -0,0 +1,90 @@
+// Package policy 集中定义角色、权限以及资源访问规则，handler 和中间件都通过这里判断是否有权操作
+package policy
+
+// 角色
+const (
+	RoleReader    = "reader"    // 读者，注册后的默认角色
+	RoleAuthor    = "author"    // 作者，创建第一部作品后自动成为作者
+	RoleModerator = "moderator" // 版主，可以处理他人的内容和举报
+	RoleAdmin     = "admin"     // 管理员，拥有全部权限
+)
+
+// 权限
+const (
+	PermNovelCreate     = "novel.create"     // 创建作品
+	PermNovelModerate   = "novel.moderate"   // 管理他人的作品和章节
+	PermCommentModerate = "comment.moderate" // 管理他人的评论和书评
+	PermReportReview    = "report.review"    // 处理举报
+	PermContentReview   = "content.review"   // 审核命中敏感词的内容
+	PermTagManage       = "tag.manage"       // 维护标签和同义词
+	PermCategoryManage  = "category.manage"  // 维护分类目录
+	PermUserManage      = "user.manage"      // 管理用户账号
+	PermRoleManage      = "role.manage"      // 分配角色
+)
+
+// Roles 所有角色，按权限从低到高排列
+var Roles = []string{RoleReader, RoleAuthor, RoleModerator, RoleAdmin}
+
+var rolePermissions = map[string][]string{
+	RoleReader: {PermNovelCreate},
+	RoleAuthor: {PermNovelCreate},
+	RoleModerator: {
+		PermNovelCreate, PermNovelModerate, PermCommentModerate, PermReportReview, PermContentReview, PermTagManage,
+	},
+	RoleAdmin: {
+		PermNovelCreate, PermNovelModerate, PermCommentModerate, PermReportReview, PermContentReview, PermTagManage,
+		PermCategoryManage, PermUserManage, PermRoleManage,
+	},
+}
+
+// ValidRole 判断角色是否存在
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions 返回角色拥有的权限，未知角色没有任何权限
+func Permissions(role string) []string {
+	return append([]string(nil), rolePermissions[role]...)
+}
+
+// Can 判断角色是否拥有某个权限
+func Can(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor 发起操作的用户，UserID 为 0 表示游客
+type Actor struct {
+	UserID uint
+	Role   string
+}
+
+// Can 判断用户是否拥有某个权限
+func (a Actor) Can(permission string) bool {
+	return a.UserID != 0 && Can(a.Role, permission)
+}
+
+// CanManageNovel 作者本人或有作品管理权限的用户可以修改、删除作品及其章节
+func (a Actor) CanManageNovel(authorID uint) bool {
+	return a.UserID != 0 && (a.UserID == authorID || a.Can(PermNovelModerate))
+}
+
+// CanViewDraft 未发布的章节只有作者本人和有作品管理权限的用户可以查看
+func (a Actor) CanViewDraft(authorID uint) bool {
+	return a.CanManageNovel(authorID)
+}
+
+// CanAssignRole 只有可以分配角色的用户才能修改他人角色，不能修改自己的角色以免误操作失去权限
+func (a Actor) CanAssignRole(targetUserID uint, role string) bool {
+	return a.Can(PermRoleManage) && a.UserID != targetUserID && ValidRole(role)
+}
+
+// CanSanctionUser 有用户管理权限的用户可以封禁他人或要求他人重置密码，不能处理自己和管理员
+func (a Actor) CanSanctionUser(targetUserID uint, targetRole string) bool {
+	return a.Can(PermUserManage) && a.UserID != targetUserID && targetRole != RoleAdmin
+}
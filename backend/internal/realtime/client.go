@@ -0,0 +1,179 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	heartbeatEvery = 25 * time.Second // 需小于 pongWait
+	sendBufferSize = 64
+)
+
+// Client 一个 WebSocket 连接
+type Client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	userID uint
+	send   chan []byte
+
+	mu        sync.Mutex
+	lastID    string  // 已推送的最后一个事件ID，用于去重
+	replaying bool    // 补发历史事件期间，新事件先暂存
+	pending   []Event // 补发期间收到的新事件
+	closed    bool
+}
+
+// Serve 接管已升级的连接：注册到 Hub，补发 lastEventID 之后的事件，然后持续推送，直到连接断开
+func (h *Hub) Serve(conn *websocket.Conn, userID uint, lastEventID string) {
+	c := &Client{
+		hub:       h,
+		conn:      conn,
+		userID:    userID,
+		send:      make(chan []byte, sendBufferSize),
+		lastID:    lastEventID,
+		replaying: lastEventID != "",
+	}
+	// 先注册再补发，保证补发期间产生的事件不会丢失
+	h.register(c)
+	go c.writePump()
+
+	if lastEventID != "" {
+		c.resume(lastEventID)
+	}
+	c.readPump()
+}
+
+func (c *Client) resume(lastEventID string) {
+	events, gap, err := c.hub.replay(context.Background(), c.userID, lastEventID)
+	if err != nil {
+		log.Printf("Failed to replay realtime events for user %d: %v", c.userID, err)
+		gap = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gap {
+		c.writeLocked(Event{Type: EventResync, Time: time.Now().UnixMilli()})
+	}
+	for _, e := range events {
+		c.enqueueLocked(e)
+	}
+	for _, e := range c.pending {
+		c.enqueueLocked(e)
+	}
+	c.pending = nil
+	c.replaying = false
+}
+
+func (c *Client) deliver(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.replaying {
+		c.pending = append(c.pending, e)
+		return
+	}
+	c.enqueueLocked(e)
+}
+
+// enqueueLocked 按事件ID去重后放入发送队列
+func (c *Client) enqueueLocked(e Event) {
+	if e.ID != "" && c.lastID != "" && compareEventID(e.ID, c.lastID) <= 0 {
+		return
+	}
+	if e.ID != "" {
+		c.lastID = e.ID
+	}
+	c.writeLocked(e)
+}
+
+// writeLocked 放入发送队列；客户端消费过慢时断开连接，由客户端重连续传
+func (c *Client) writeLocked(e Event) {
+	if c.closed {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+		c.closeLocked()
+	}
+}
+
+func (c *Client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *Client) closeLocked() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.send)
+}
+
+// readPump 读取客户端消息（仅用于处理 pong 和检测断开）
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.unregister(c)
+		c.close()
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+		// 客户端发送的任何消息都视为存活
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	}
+}
+
+// writePump 发送事件，并定期发送 ping 和心跳事件
+func (c *Client) writePump() {
+	ticker := time.NewTicker(heartbeatEvery)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+			heartbeat, _ := json.Marshal(Event{Type: EventHeartbeat, Time: time.Now().UnixMilli()})
+			if err := c.conn.WriteMessage(websocket.TextMessage, heartbeat); err != nil {
+				return
+			}
+		}
+	}
+}
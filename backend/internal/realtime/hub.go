@@ -0,0 +1,259 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	eventChannel    = "realtime:events" // 各实例之间广播事件的 Redis 频道
+	eventStreamSize = 200               // 每个用户保留的最近事件数，用于断线重连后补发
+	eventStreamTTL  = 24 * time.Hour
+)
+
+// 事件类型
+const (
+	EventNotification = "notification" // 新通知
+	EventJobProgress  = "job_progress" // AI 任务进度
+	EventHeartbeat    = "heartbeat"    // 心跳
+	EventResync       = "resync"       // 断线时间过长，部分事件已过期，客户端需重新拉取数据
+)
+
+// Event 推送给客户端的事件；ID 为 Redis Stream ID，客户端重连时通过 lastEventId 续传
+type Event struct {
+	ID     string          `json:"id,omitempty"`
+	Type   string          `json:"type"`
+	UserID uint            `json:"-"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Time   int64           `json:"time"`
+}
+
+// JobProgress AI 任务进度。AI 写作由浏览器直接调用模型接口，进度由发起任务的页面上报，
+// 转发给同一用户的其他会话
+type JobProgress struct {
+	JobID    string `json:"jobId"`
+	Status   string `json:"status"`   // pending, running, succeeded, failed
+	Progress int    `json:"progress"` // 0-100
+	Message  string `json:"message,omitempty"`
+}
+
+// wireEvent 在 Redis 频道中传递的事件，需要带上接收用户
+type wireEvent struct {
+	Event
+	UserID uint `json:"userId"`
+}
+
+// Hub 管理本实例上的 WebSocket 连接，并通过 Redis pub/sub 接收所有实例发布的事件
+type Hub struct {
+	rdb     *redis.Client
+	mu      sync.RWMutex
+	clients map[uint]map[*Client]struct{}
+}
+
+func NewHub(rdb *redis.Client) *Hub {
+	return &Hub{
+		rdb:     rdb,
+		clients: make(map[uint]map[*Client]struct{}),
+	}
+}
+
+func streamKey(userID uint) string {
+	return fmt.Sprintf("realtime:stream:%d", userID)
+}
+
+// Start 订阅 Redis 频道，将事件分发给本实例上对应用户的连接
+func (h *Hub) Start(ctx context.Context) {
+	go func() {
+		for {
+			if err := h.subscribe(ctx); err != nil {
+				log.Printf("Realtime subscription interrupted: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+}
+
+func (h *Hub) subscribe(ctx context.Context) error {
+	pubsub := h.rdb.Subscribe(ctx, eventChannel)
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return fmt.Errorf("channel closed")
+			}
+			var e wireEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+				continue
+			}
+			e.Event.UserID = e.UserID
+			h.deliver(e.Event)
+		}
+	}
+}
+
+// Publish 向用户推送事件：写入用户的事件流以便续传，再广播给所有实例
+func (h *Hub) Publish(userID uint, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	id, err := h.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(userID),
+		MaxLen: eventStreamSize,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type": eventType,
+			"data": string(payload),
+			"time": now.UnixMilli(),
+		},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	h.rdb.Expire(ctx, streamKey(userID), eventStreamTTL)
+	return h.broadcast(ctx, userID, Event{ID: id, Type: eventType, Data: payload, Time: now.UnixMilli()})
+}
+
+// PublishJobProgress 推送 AI 任务进度。进度只对在线的会话有意义，不写入事件流，
+// 避免频繁的进度事件挤掉用于续传的通知
+func (h *Hub) PublishJobProgress(userID uint, progress JobProgress) error {
+	payload, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	return h.broadcast(context.Background(), userID, Event{Type: EventJobProgress, Data: payload, Time: time.Now().UnixMilli()})
+}
+
+// broadcast 通过 Redis 频道把事件发给所有实例
+func (h *Hub) broadcast(ctx context.Context, userID uint, e Event) error {
+	msg, err := json.Marshal(wireEvent{Event: e, UserID: userID})
+	if err != nil {
+		return err
+	}
+	return h.rdb.Publish(ctx, eventChannel, msg).Err()
+}
+
+// replay 返回 lastID 之后的事件；lastID 早于保留的最早事件时 gap 为 true
+func (h *Hub) replay(ctx context.Context, userID uint, lastID string) ([]Event, bool, error) {
+	messages, err := h.rdb.XRange(ctx, streamKey(userID), lastID, "+").Result()
+	if err != nil {
+		return nil, false, err
+	}
+
+	gap := false
+	if len(messages) == 0 || messages[0].ID != lastID {
+		// lastID 不在流中：若流中最早的事件比它新，说明中间的事件已被裁剪
+		first, err := h.rdb.XRangeN(ctx, streamKey(userID), "-", "+", 1).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		gap = len(first) > 0 && compareEventID(first[0].ID, lastID) > 0
+	}
+
+	events := make([]Event, 0, len(messages))
+	for _, m := range messages {
+		if m.ID == lastID {
+			continue
+		}
+		e := Event{ID: m.ID, UserID: userID}
+		e.Type, _ = m.Values["type"].(string)
+		if data, ok := m.Values["data"].(string); ok {
+			e.Data = json.RawMessage(data)
+		}
+		if t, ok := m.Values["time"].(string); ok {
+			e.Time, _ = strconv.ParseInt(t, 10, 64)
+		}
+		events = append(events, e)
+	}
+	return events, gap, nil
+}
+
+func (h *Hub) register(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[c.userID] == nil {
+		h.clients[c.userID] = make(map[*Client]struct{})
+	}
+	h.clients[c.userID][c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if set, ok := h.clients[c.userID]; ok {
+		delete(set, c)
+		if len(set) == 0 {
+			delete(h.clients, c.userID)
+		}
+	}
+}
+
+func (h *Hub) deliver(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients[e.UserID] {
+		c.deliver(e)
+	}
+}
+
+// compareEventID 比较两个 Redis Stream ID（毫秒时间戳-序号）
+func compareEventID(a, b string) int {
+	am, as := parseEventID(a)
+	bm, bs := parseEventID(b)
+	switch {
+	case am != bm:
+		if am < bm {
+			return -1
+		}
+		return 1
+	case as != bs:
+		if as < bs {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func parseEventID(id string) (uint64, uint64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	m, _ := strconv.ParseUint(ms, 10, 64)
+	s, _ := strconv.ParseUint(seq, 10, 64)
+	return m, s
+}
+
+// ValidEventID 检查客户端传入的 lastEventId 格式
+func ValidEventID(id string) bool {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	if _, err := strconv.ParseUint(ms, 10, 64); err != nil {
+		return false
+	}
+	_, err := strconv.ParseUint(seq, 10, 64)
+	return err == nil
+}
@@ -0,0 +1,126 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+	snippetBefore  = 30 // 摘要中命中位置之前保留的字数，不超过摘要长度的一半
+)
+
+// highlighter 根据查询词在原文中标记命中位置，比较时忽略大小写
+type highlighter struct {
+	terms [][]rune
+}
+
+func newHighlighter(terms []string) highlighter {
+	h := highlighter{terms: make([][]rune, 0, len(terms))}
+	for _, t := range terms {
+		h.terms = append(h.terms, []rune(t))
+	}
+	return h
+}
+
+// mask 标记 text 中被查询词覆盖的字符
+func (h highlighter) mask(text []rune) []bool {
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(text))
+	for _, term := range h.terms {
+		if len(term) == 0 {
+			continue
+		}
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if runesEqual(lower[i:i+len(term)], term) {
+				for k := i; k < i+len(term); k++ {
+					marked[k] = true
+				}
+			}
+		}
+	}
+	return marked
+}
+
+// Highlight 转义全文并标记命中词
+func (h highlighter) Highlight(text string) string {
+	runes := []rune(text)
+	return render(runes, h.mask(runes))
+}
+
+// Snippet 截取第一个命中位置附近 width 个字符作为摘要；没有命中时返回开头部分，ok 为 false
+func (h highlighter) Snippet(text string, width int) (snippet string, ok bool) {
+	runes := []rune(text)
+	marked := h.mask(runes)
+
+	first := -1
+	for i, m := range marked {
+		if m {
+			first = i
+			break
+		}
+	}
+
+	start := 0
+	if first >= 0 {
+		start = max(first-min(snippetBefore, width/2), 0)
+	}
+	end := min(start+width, len(runes))
+	if end-start < width {
+		start = max(end-width, 0)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(render(runes[start:end], marked[start:end]))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), first >= 0
+}
+
+// render 输出转义后的 HTML，连续命中的字符合并为一个 <em>
+func render(runes []rune, marked []bool) string {
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(strings.Map(flattenSpace, string(runes[i:j])))
+		if marked[i] {
+			b.WriteString(highlightOpen)
+			b.WriteString(segment)
+			b.WriteString(highlightClose)
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// flattenSpace 摘要中的换行等空白统一为空格
+func flattenSpace(r rune) rune {
+	if unicode.IsSpace(r) {
+		return ' '
+	}
+	return r
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		terms []string
+		text  string
+		want  string
+	}{
+		{"no terms", nil, "斗破苍穹", "斗破苍穹"},
+		{"single term", []string{"苍穹"}, "斗破苍穹", "斗破<em>苍穹</em>"},
+		{"adjacent terms merge", []string{"斗破", "破苍", "苍穹"}, "斗破苍穹", "<em>斗破苍穹</em>"},
+		{"case insensitive", []string{"go"}, "Learn GO", "Learn <em>GO</em>"},
+		{"escapes HTML", []string{"b"}, "<b>&", "&lt;<em>b</em>&gt;&amp;"},
+		{"newlines flattened", []string{"龙"}, "神\n龙", "神 <em>龙</em>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newHighlighter(tt.terms).Highlight(tt.text); got != tt.want {
+				t.Fatalf("Highlight(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("一", 100)
+	tests := []struct {
+		name   string
+		terms  []string
+		text   string
+		width  int
+		want   string
+		wantOK bool
+	}{
+		{"short text", []string{"龙"}, "神龙", 10, "神<em>龙</em>", true},
+		{"no hit returns the beginning", []string{"龙"}, "一二三四五", 3, "一二三…", false},
+		{"hit in the middle", []string{"龙"}, long + "龙" + long, 5, "…一一<em>龙</em>一一…", true},
+		{"keeps context before the hit", []string{"龙"}, long + "龙" + long, 80,
+			"…" + strings.Repeat("一", snippetBefore) + "<em>龙</em>" + strings.Repeat("一", 80-snippetBefore-1) + "…", true},
+		{"hit near the end", []string{"龙"}, "一二三四五龙", 3, "…四五<em>龙</em>", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := newHighlighter(tt.terms).Snippet(tt.text, tt.width)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("Snippet(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 字段权重：标题、作者、标签命中比简介和正文更重要
+const (
+	weightTitle        = 4.0
+	weightAuthor       = 3.0
+	weightTags         = 3.0
+	weightCategory     = 1.0
+	weightDescription  = 1.5
+	weightChapterTitle = 2
+
+	chapterScoreFactor = 0.6 // 正文得分占比
+	phraseBoost        = 1.5 // 标题包含完整查询词时的加成
+	minShouldMatch     = 0.7 // 至少命中的查询词比例
+	snippetWidth       = 120
+
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// MemoryIndex 内存倒排索引，按小说元数据和章节正文分别维护，随增删改增量更新
+type MemoryIndex struct {
+	mu sync.RWMutex
+
+	novels          map[uint]*novelEntry
+	novelPostings   map[string]map[uint]float64 // 词 -> 小说ID -> 加权词频
+	novelLenSum     float64
+	chapters        map[uint]*chapterEntry
+	chapterPostings map[string]map[uint]int // 词 -> 章节ID -> 词频
+	chapterLenSum   int
+	novelChapters   map[uint]map[uint]struct{} // 小说ID -> 章节ID，章节可能先于小说建立索引
+}
+
+type novelEntry struct {
+	doc    NovelDocument
+	tags   map[string]bool
+	terms  map[string]float64
+	length float64
+}
+
+type chapterEntry struct {
+	doc    ChapterDocument
+	terms  map[string]int
+	length int
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		novels:          make(map[uint]*novelEntry),
+		novelPostings:   make(map[string]map[uint]float64),
+		chapters:        make(map[uint]*chapterEntry),
+		chapterPostings: make(map[string]map[uint]int),
+		novelChapters:   make(map[uint]map[uint]struct{}),
+	}
+}
+
+// IndexNovel 新增或更新小说元数据
+func (m *MemoryIndex) IndexNovel(doc NovelDocument) error {
+	terms := make(map[string]float64)
+	addField := func(text string, weight float64) {
+		for t, n := range termFrequencies(IndexTokens(text)) {
+			terms[t] += float64(n) * weight
+		}
+	}
+	addField(doc.Title, weightTitle)
+	addField(doc.Author, weightAuthor)
+	addField(strings.Join(doc.Tags, " "), weightTags)
+	addField(doc.Category, weightCategory)
+	addField(doc.Description, weightDescription)
+
+	entry := &novelEntry{doc: doc, tags: make(map[string]bool, len(doc.Tags)), terms: terms}
+	for _, tag := range doc.Tags {
+		entry.tags[strings.ToLower(tag)] = true
+	}
+	for _, w := range terms {
+		entry.length += w
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeNovelLocked(doc.ID)
+	m.novels[doc.ID] = entry
+	m.novelLenSum += entry.length
+	for t, w := range terms {
+		postings := m.novelPostings[t]
+		if postings == nil {
+			postings = make(map[uint]float64)
+			m.novelPostings[t] = postings
+		}
+		postings[doc.ID] = w
+	}
+	return nil
+}
+
+// RemoveNovel 删除小说及其全部章节
+func (m *MemoryIndex) RemoveNovel(novelID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeNovelLocked(novelID)
+	for chapterID := range m.novelChapters[novelID] {
+		m.removeChapterLocked(chapterID)
+	}
+	return nil
+}
+
+func (m *MemoryIndex) removeNovelLocked(novelID uint) {
+	entry, ok := m.novels[novelID]
+	if !ok {
+		return
+	}
+	for t := range entry.terms {
+		delete(m.novelPostings[t], novelID)
+		if len(m.novelPostings[t]) == 0 {
+			delete(m.novelPostings, t)
+		}
+	}
+	m.novelLenSum -= entry.length
+	delete(m.novels, novelID)
+}
+
+// IndexChapter 新增或更新章节
+func (m *MemoryIndex) IndexChapter(doc ChapterDocument) error {
+	terms := termFrequencies(IndexTokens(doc.Content))
+	for t, n := range termFrequencies(IndexTokens(doc.Title)) {
+		terms[t] += n * weightChapterTitle
+	}
+	entry := &chapterEntry{doc: doc, terms: terms}
+	for _, n := range terms {
+		entry.length += n
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeChapterLocked(doc.ID)
+	m.chapters[doc.ID] = entry
+	m.chapterLenSum += entry.length
+	for t, n := range terms {
+		postings := m.chapterPostings[t]
+		if postings == nil {
+			postings = make(map[uint]int)
+			m.chapterPostings[t] = postings
+		}
+		postings[doc.ID] = n
+	}
+	if m.novelChapters[doc.NovelID] == nil {
+		m.novelChapters[doc.NovelID] = make(map[uint]struct{})
+	}
+	m.novelChapters[doc.NovelID][doc.ID] = struct{}{}
+	return nil
+}
+
+// RemoveChapter 删除章节
+func (m *MemoryIndex) RemoveChapter(chapterID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeChapterLocked(chapterID)
+	return nil
+}
+
+func (m *MemoryIndex) removeChapterLocked(chapterID uint) {
+	entry, ok := m.chapters[chapterID]
+	if !ok {
+		return
+	}
+	for t := range entry.terms {
+		delete(m.chapterPostings[t], chapterID)
+		if len(m.chapterPostings[t]) == 0 {
+			delete(m.chapterPostings, t)
+		}
+	}
+	m.chapterLenSum -= entry.length
+	delete(m.chapters, chapterID)
+	if chapters := m.novelChapters[entry.doc.NovelID]; chapters != nil {
+		delete(chapters, chapterID)
+		if len(chapters) == 0 {
+			delete(m.novelChapters, entry.doc.NovelID)
+		}
+	}
+}
+
+// IndexedIDs 返回已建立索引的小说和章节 ID
+func (m *MemoryIndex) IndexedIDs() (novelIDs, chapterIDs []uint) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	novelIDs = make([]uint, 0, len(m.novels))
+	for id := range m.novels {
+		novelIDs = append(novelIDs, id)
+	}
+	chapterIDs = make([]uint, 0, len(m.chapters))
+	for id := range m.chapters {
+		chapterIDs = append(chapterIDs, id)
+	}
+	return novelIDs, chapterIDs
+}
+
+// novelMatch 单本小说的打分过程
+type novelMatch struct {
+	score        float64
+	matched      map[string]bool
+	chapterID    uint
+	chapterScore float64
+}
+
+// Search 按 BM25 对小说元数据和章节正文分别打分；正文取得分最高的章节，
+// 按比例计入小说得分，并用该章节生成摘要
+func (m *MemoryIndex) Search(q Query) (*Result, error) {
+	terms := uniqueTerms(QueryTokens(q.Text))
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matches map[uint]*novelMatch
+	if len(terms) == 0 {
+		matches = make(map[uint]*novelMatch, len(m.novels))
+		for id := range m.novels {
+			matches[id] = &novelMatch{}
+		}
+	} else {
+		matches = m.score(terms)
+	}
+
+	phrase := strings.ToLower(strings.TrimSpace(q.Text))
+	type scored struct {
+		id    uint
+		match *novelMatch
+	}
+	results := make([]scored, 0, len(matches))
+	for id, match := range matches {
+		entry, ok := m.novels[id]
+		if !ok || !entry.accept(q) {
+			continue
+		}
+		if len(terms) > 0 {
+			coverage := float64(len(match.matched)) / float64(len(terms))
+			if coverage < minShouldMatch {
+				continue
+			}
+			match.score *= coverage * coverage
+			if phrase != "" && strings.Contains(strings.ToLower(entry.doc.Title), phrase) {
+				match.score *= phraseBoost
+			}
+		}
+		results = append(results, scored{id, match})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].match.score != results[j].match.score {
+			return results[i].match.score > results[j].match.score
+		}
+		return results[i].id > results[j].id
+	})
+
+	result := &Result{Total: len(results), Hits: []Hit{}}
+	if q.Offset >= len(results) {
+		return result, nil
+	}
+	end := len(results)
+	if q.Limit > 0 {
+		end = min(q.Offset+q.Limit, len(results))
+	}
+
+	h := newHighlighter(terms)
+	for _, r := range results[q.Offset:end] {
+		entry := m.novels[r.id]
+		hit := Hit{NovelID: r.id, Score: r.match.score, Title: h.Highlight(entry.doc.Title)}
+
+		snippet, ok := "", false
+		if chapter, exists := m.chapters[r.match.chapterID]; exists {
+			if snippet, ok = h.Snippet(chapter.doc.Content, snippetWidth); ok {
+				hit.ChapterID = chapter.doc.ID
+				hit.ChapterTitle = chapter.doc.Title
+			}
+		}
+		if !ok {
+			snippet, _ = h.Snippet(entry.doc.Description, snippetWidth)
+		}
+		hit.Snippet = snippet
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
+
+// score 计算每本命中小说的得分和命中的查询词
+func (m *MemoryIndex) score(terms []string) map[uint]*novelMatch {
+	matches := make(map[uint]*novelMatch)
+	get := func(novelID uint) *novelMatch {
+		match := matches[novelID]
+		if match == nil {
+			match = &novelMatch{matched: make(map[string]bool)}
+			matches[novelID] = match
+		}
+		return match
+	}
+
+	novelCount := float64(len(m.novels))
+	avgNovelLen := m.novelLenSum / math.Max(novelCount, 1)
+	chapterCount := float64(len(m.chapters))
+	avgChapterLen := float64(m.chapterLenSum) / math.Max(chapterCount, 1)
+
+	chapterScores := make(map[uint]float64)
+	for _, t := range terms {
+		postings := m.novelPostings[t]
+		idf := inverseDocumentFrequency(novelCount, len(postings))
+		for id, tf := range postings {
+			match := get(id)
+			match.score += idf * bm25(tf, m.novels[id].length, avgNovelLen)
+			match.matched[t] = true
+		}
+
+		chapterPostings := m.chapterPostings[t]
+		idf = inverseDocumentFrequency(chapterCount, len(chapterPostings))
+		for id, tf := range chapterPostings {
+			chapter := m.chapters[id]
+			chapterScores[id] += idf * bm25(float64(tf), float64(chapter.length), avgChapterLen)
+			get(chapter.doc.NovelID).matched[t] = true
+		}
+	}
+
+	for id, s := range chapterScores {
+		match := get(m.chapters[id].doc.NovelID)
+		if s > match.chapterScore {
+			match.chapterScore = s
+			match.chapterID = id
+		}
+	}
+	for _, match := range matches {
+		match.score += chapterScoreFactor * match.chapterScore
+	}
+	return matches
+}
+
+// accept 判断小说是否满足筛选条件
+func (e *novelEntry) accept(q Query) bool {
+	if len(q.Categories) > 0 && !slices.Contains(q.Categories, e.doc.Category) {
+		return false
+	}
+	if q.Status != nil && e.doc.Status != *q.Status {
+		return false
+	}
+	if q.MinWords > 0 && e.doc.WordCount < q.MinWords {
+		return false
+	}
+	if q.MaxWords > 0 && e.doc.WordCount > q.MaxWords {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !e.tags[strings.ToLower(tag)] {
+			return false
+		}
+	}
+	return true
+}
+
+func inverseDocumentFrequency(docCount float64, df int) float64 {
+	return math.Log(1 + (docCount-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+func bm25(tf, length, avgLength float64) float64 {
+	if avgLength <= 0 {
+		avgLength = 1
+	}
+	return tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/avgLength))
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	terms := tokens[:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
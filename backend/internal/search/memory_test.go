@@ -0,0 +1,165 @@
+package search
+
+import (
+	"slices"
+	"sort"
+	"testing"
+)
+
+func hitIDs(result *Result) []uint {
+	ids := make([]uint, 0, len(result.Hits))
+	for _, h := range result.Hits {
+		ids = append(ids, h.NovelID)
+	}
+	return ids
+}
+
+func newTestIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	m := NewMemoryIndex()
+	novels := []NovelDocument{
+		{ID: 1, Title: "星辰变", Description: "少年修仙的故事", Author: "我吃西红柿", Category: "玄幻", Status: 1, Tags: []string{"修仙", "热血"}, WordCount: 3000000},
+		{ID: 2, Title: "都市之王", Description: "一个关于星辰的传说", Author: "某作者", Category: "都市", Status: 0, Tags: []string{"都市"}, WordCount: 500000},
+		{ID: 3, Title: "Go 语言编程", Description: "programming in Go", Author: "gopher", Category: "科技", Status: 0, WordCount: 100000},
+		{ID: 4, Title: "龙", Description: "短标题", Author: "某作者", Category: "玄幻", Status: 1, Tags: []string{"修仙"}, WordCount: 800000},
+	}
+	for _, n := range novels {
+		if err := m.IndexNovel(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chapters := []ChapterDocument{
+		{ID: 10, NovelID: 2, Title: "第一章", Content: "他在城市中遇见了一位剑仙，剑仙传授他无上剑法。"},
+		{ID: 11, NovelID: 2, Title: "第二章", Content: "平凡的一天。"},
+	}
+	for _, c := range chapters {
+		if err := m.IndexChapter(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	m := newTestIndex(t)
+	status1 := 1
+	tests := []struct {
+		name  string
+		query Query
+		want  []uint
+	}{
+		{"title ranks above description", Query{Text: "星辰"}, []uint{1, 2}},
+		{"author", Query{Text: "西红柿"}, []uint{1}},
+		{"tag", Query{Text: "热血"}, []uint{1}},
+		{"chapter content", Query{Text: "剑仙"}, []uint{2}},
+		{"latin case insensitive", Query{Text: "GO"}, []uint{3}},
+		{"single character", Query{Text: "龙"}, []uint{4}},
+		{"most terms must match", Query{Text: "星辰剑法"}, []uint{}},
+		{"no match", Query{Text: "不存在的词"}, []uint{}},
+		{"category filter", Query{Text: "星辰", Categories: []string{"都市"}}, []uint{2}},
+		{"status filter", Query{Status: &status1}, []uint{4, 1}},
+		{"tags must all match", Query{Tags: []string{"修仙", "热血"}}, []uint{1}},
+		{"word count range", Query{MinWords: 400000, MaxWords: 1000000}, []uint{4, 2}},
+		{"filter only ordered by id", Query{}, []uint{4, 3, 2, 1}},
+		{"offset and limit", Query{Offset: 1, Limit: 2}, []uint{3, 2}},
+		{"offset past the end", Query{Offset: 10}, []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := m.Search(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hitIDs(result); !slices.Equal(got, tt.want) {
+				t.Fatalf("Search(%+v) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryIndexSearchHit(t *testing.T) {
+	m := newTestIndex(t)
+	result, err := m.Search(Query{Text: "剑仙"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Hits) != 1 {
+		t.Fatalf("Search() = %+v, want one hit", result)
+	}
+	hit := result.Hits[0]
+	if hit.ChapterID != 10 || hit.ChapterTitle != "第一章" {
+		t.Errorf("hit chapter = %d %q, want 10 第一章", hit.ChapterID, hit.ChapterTitle)
+	}
+	if want := "他在城市中遇见了一位<em>剑仙</em>，<em>剑仙</em>传授他无上剑法。"; hit.Snippet != want {
+		t.Errorf("hit snippet = %q, want %q", hit.Snippet, want)
+	}
+
+	result, err = m.Search(Query{Text: "星辰"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<em>星辰</em>变"; result.Hits[0].Title != want {
+		t.Errorf("hit title = %q, want %q", result.Hits[0].Title, want)
+	}
+	if result.Hits[0].Score <= result.Hits[1].Score {
+		t.Errorf("title match score %v should exceed description match score %v", result.Hits[0].Score, result.Hits[1].Score)
+	}
+}
+
+func TestMemoryIndexUpdateAndRemove(t *testing.T) {
+	m := newTestIndex(t)
+
+	// 更新后旧词不再命中
+	if err := m.IndexNovel(NovelDocument{ID: 1, Title: "完美世界"}); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := m.Search(Query{Text: "星辰"}); !slices.Equal(hitIDs(result), []uint{2}) {
+		t.Fatalf("after update Search(星辰) = %v, want [2]", hitIDs(result))
+	}
+	if result, _ := m.Search(Query{Text: "完美"}); !slices.Equal(hitIDs(result), []uint{1}) {
+		t.Fatalf("after update Search(完美) = %v, want [1]", hitIDs(result))
+	}
+
+	if err := m.RemoveChapter(10); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := m.Search(Query{Text: "剑仙"}); len(result.Hits) != 0 {
+		t.Fatalf("after removing chapter Search(剑仙) = %v, want none", hitIDs(result))
+	}
+
+	// 删除小说时一并删除其章节
+	if err := m.RemoveNovel(2); err != nil {
+		t.Fatal(err)
+	}
+	novels, chapters := m.IndexedIDs()
+	sort.Slice(novels, func(i, j int) bool { return novels[i] < novels[j] })
+	if !slices.Equal(novels, []uint{1, 3, 4}) || len(chapters) != 0 {
+		t.Fatalf("IndexedIDs() = %v, %v, want [1 3 4], []", novels, chapters)
+	}
+}
+
+func TestBM25(t *testing.T) {
+	tests := []struct {
+		name            string
+		tf, length, avg float64
+		less            [3]float64 // 另一组参数，得分应低于本组
+	}{
+		{"higher term frequency scores higher", 3, 100, 100, [3]float64{1, 100, 100}},
+		{"shorter document scores higher", 1, 50, 100, [3]float64{1, 200, 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bm25(tt.tf, tt.length, tt.avg)
+			other := bm25(tt.less[0], tt.less[1], tt.less[2])
+			if got <= other {
+				t.Fatalf("bm25(%v, %v, %v) = %v, want > %v", tt.tf, tt.length, tt.avg, got, other)
+			}
+			if got > bm25K1+1 {
+				t.Fatalf("bm25(%v, %v, %v) = %v exceeds the saturation bound %v", tt.tf, tt.length, tt.avg, got, bm25K1+1)
+			}
+		})
+	}
+	if rare, common := inverseDocumentFrequency(100, 1), inverseDocumentFrequency(100, 50); rare <= common {
+		t.Fatalf("idf of a rare term %v should exceed idf of a common term %v", rare, common)
+	}
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"unicode"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// gb2312Initials GB2312 一级汉字按拼音排序，每个声母对应一段连续编码，这里记录各段的起始编码
+var gb2312Initials = []struct {
+	start   uint16
+	initial byte
+}{
+	{0xB0A1, 'a'}, {0xB0C5, 'b'}, {0xB2C1, 'c'}, {0xB4EE, 'd'}, {0xB6EA, 'e'},
+	{0xB7A2, 'f'}, {0xB8C1, 'g'}, {0xB9FE, 'h'}, {0xBBF7, 'j'}, {0xBFA6, 'k'},
+	{0xC0AC, 'l'}, {0xC2E8, 'm'}, {0xC4C3, 'n'}, {0xC5B6, 'o'}, {0xC5BE, 'p'},
+	{0xC6DA, 'q'}, {0xC8BB, 'r'}, {0xC8F6, 's'}, {0xCBFA, 't'}, {0xCDDA, 'w'},
+	{0xCEF4, 'x'}, {0xD1B9, 'y'}, {0xD4D1, 'z'},
+}
+
+const gb2312Level1End = 0xD7F9
+
+// supplementalInitials 网文标题中常见、但不在 GB2312 一级字库（按部首排序）中的汉字
+var supplementalInitials = map[rune]byte{}
+
+func init() {
+	groups := map[byte]string{
+		'a': "骜",
+		'b': "犇汴鬓",
+		'c': "魑谶",
+		'd': "邸",
+		'g': "罡蛊",
+		'h': "昊",
+		'j': "烬珏瑾霁戟崛",
+		'k': "铠傀",
+		'l': "璃麟鸾凛魉戮儡罹岚",
+		'm': "淼溟魅暝",
+		'q': "穹琪麒阙",
+		'r': "娆苒嵘",
+		's': "笙晟弑觞殇噬",
+		't': "饕餮",
+		'w': "魍",
+		'x': "萱璇曦骁箫潇婿",
+		'y': "翊琰玥嫣妍魇胤垚焱曜烨熠煜琊赟",
+		'z': "祯谪诛峥赘",
+	}
+	for initial, chars := range groups {
+		for _, r := range chars {
+			supplementalInitials[r] = initial
+		}
+	}
+}
+
+// polyphoneInitials 标题中常见的多音字的各个读音首字母，GB2312 的排序只体现其中一个读音，
+// 例如“重”按 zhòng 排在 z 段，“重生”却读 chóng
+var polyphoneInitials = map[rune]string{
+	'重': "cz", '长': "cz", '行': "xh", '乐': "ly", '传': "cz", '藏': "cz", '朝': "cz",
+	'调': "dt", '降': "jx", '弹': "dt", '曾': "cz", '单': "dsc", '解': "jx", '参': "cs",
+	'奇': "qj", '率': "ls", '沈': "sc", '会': "hk", '校': "xj",
+}
+
+// maxPinyinVariants 多音字组合出的首字母串上限，超出后其余多音字只取第一个读音
+const maxPinyinVariants = 8
+
+// PinyinInitials 返回文本的拼音首字母（小写），如“斗破苍穹”返回 [dpcq]。
+// 含多音字时返回各读音的组合，如“重生”返回 [cs zs]。
+// 字母和数字原样保留；覆盖 GB2312 一级汉字和补充表，其他汉字无法确定首字母，返回 ok 为 false
+func PinyinInitials(text string) (variants []string, ok bool) {
+	encoder := simplifiedchinese.GBK.NewEncoder()
+	variants = []string{""}
+	// appendAll 在每个已有组合后追加 initials 中的任一首字母
+	appendAll := func(initials string) {
+		if len(variants)*len(initials) > maxPinyinVariants {
+			initials = initials[:1]
+		}
+		next := make([]string, 0, len(variants)*len(initials))
+		for _, v := range variants {
+			for i := 0; i < len(initials); i++ {
+				next = append(next, v+initials[i:i+1])
+			}
+		}
+		variants = next
+	}
+	hasHan := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			hasHan = true
+			if initials, found := polyphoneInitials[r]; found {
+				appendAll(initials)
+				continue
+			}
+			if initial, found := supplementalInitials[r]; found {
+				appendAll(string(initial))
+				continue
+			}
+			encoded, err := encoder.String(string(r))
+			if err != nil || len(encoded) != 2 {
+				return nil, false
+			}
+			initial := gb2312Initial(uint16(encoded[0])<<8 | uint16(encoded[1]))
+			if initial == 0 {
+				return nil, false
+			}
+			appendAll(string(initial))
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			for i := range variants {
+				variants[i] += string(unicode.ToLower(r))
+			}
+		}
+	}
+	if !hasHan {
+		return nil, false
+	}
+	return variants, true
+}
+
+func gb2312Initial(code uint16) byte {
+	if code < gb2312Initials[0].start || code > gb2312Level1End {
+		return 0
+	}
+	initial := gb2312Initials[0].initial
+	for _, r := range gb2312Initials {
+		if code < r.start {
+			break
+		}
+		initial = r.initial
+	}
+	return initial
+}
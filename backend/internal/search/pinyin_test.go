@@ -0,0 +1,48 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPinyinInitials(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   []string
+		wantOK bool
+	}{
+		{"GB2312 level 1", "斗破苍穹", []string{"dpcq"}, true},
+		{"supplemental character", "麒麟", []string{"ql"}, true},
+		{"polyphone", "重生", []string{"cs", "zs"}, true},
+		{"two polyphones", "长乐", []string{"cl", "cy", "zl", "zy"}, true},
+		{"letters and digits kept", "斗罗2", []string{"dl2"}, true},
+		{"latin lower-cased", "我的AI", []string{"wdai"}, true},
+		{"punctuation dropped", "凡人·修仙", []string{"frxx"}, true},
+		{"no Han characters", "Hello", nil, false},
+		{"unknown Han character", "𠀀", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PinyinInitials(tt.text)
+			if ok != tt.wantOK || !slices.Equal(got, tt.want) {
+				t.Fatalf("PinyinInitials(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPinyinInitialsLimitsVariants(t *testing.T) {
+	got, ok := PinyinInitials("重重重重重重")
+	if !ok {
+		t.Fatal("PinyinInitials() ok = false")
+	}
+	if len(got) > maxPinyinVariants {
+		t.Fatalf("PinyinInitials() returned %d variants, want at most %d", len(got), maxPinyinVariants)
+	}
+	for _, v := range got {
+		if len(v) != 6 {
+			t.Fatalf("variant %q has %d initials, want 6", v, len(v))
+		}
+	}
+}
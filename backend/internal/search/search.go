@@ -0,0 +1,61 @@
+package search
+
+// Searcher 搜索引擎接口。默认实现为内存倒排索引 MemoryIndex，
+// 数据量增大后可替换为外部搜索服务，调用方无需改动
+type Searcher interface {
+	IndexNovel(doc NovelDocument) error
+	RemoveNovel(novelID uint) error
+	IndexChapter(doc ChapterDocument) error
+	RemoveChapter(chapterID uint) error
+	Search(q Query) (*Result, error)
+	// IndexedIDs 返回已建立索引的小说和章节 ID，全量重建时用于清理已删除的文档
+	IndexedIDs() (novelIDs, chapterIDs []uint)
+}
+
+// NovelDocument 小说元数据，同时用于检索和筛选
+type NovelDocument struct {
+	ID          uint
+	Title       string
+	Description string
+	Author      string
+	Category    string
+	Status      int
+	Tags        []string
+	WordCount   int
+}
+
+// ChapterDocument 已发布章节，Content 为纯文本
+type ChapterDocument struct {
+	ID      uint
+	NovelID uint
+	Title   string
+	Content string
+}
+
+// Query 搜索条件；Text 为空时只按条件筛选
+type Query struct {
+	Text       string
+	Categories []string // 属于其中任一分类即可，为空表示不限
+	Status     *int     // nil 表示不限
+	Tags       []string // 需同时包含所有标签
+	MinWords   int      // 0 表示不限
+	MaxWords   int      // 0 表示不限
+	Offset     int
+	Limit      int
+}
+
+// Result 搜索结果
+type Result struct {
+	Total int   `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// Hit 命中的小说；Title 和 Snippet 为已转义的 HTML，命中词用 <em> 标记
+type Hit struct {
+	NovelID      uint    `json:"novelId"`
+	Score        float64 `json:"score"`
+	Title        string  `json:"title"`
+	Snippet      string  `json:"snippet"`
+	ChapterID    uint    `json:"chapterId,omitempty"` // 摘要来自章节正文时的章节
+	ChapterTitle string  `json:"chapterTitle,omitempty"`
+}
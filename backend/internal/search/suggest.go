@@ -0,0 +1,144 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// 建议类型
+const (
+	SuggestNovel     = "novel"
+	SuggestAuthor    = "author"
+	SuggestTag       = "tag"
+	SuggestCharacter = "character"
+)
+
+// suggestTopK 每个前缀节点保留的候选数（全部类型和每种类型各一份），需不小于单次请求的最大数量
+const suggestTopK = 20
+
+// Suggestion 搜索框输入建议
+type Suggestion struct {
+	Text       string  `json:"text"`
+	Type       string  `json:"type"`
+	NovelID    uint    `json:"novelId,omitempty"`
+	NovelTitle string  `json:"novelTitle,omitempty"` // 角色所属的小说
+	AuthorID   uint    `json:"authorId,omitempty"`
+	Weight     float64 `json:"-"` // 热度，越大越靠前
+}
+
+// Suggester 前缀建议，由 Replace 整体重建后原子替换，查询无需加锁
+type Suggester struct {
+	index atomic.Pointer[suggestIndex]
+}
+
+type suggestIndex struct {
+	root    *trieNode
+	entries []Suggestion
+}
+
+// trieNode 前缀树节点，top 为该前缀下热度最高的候选（entries 下标，按热度降序），
+// byType 按类型分别保留，按类型过滤时热门类型不会挤掉其他类型的候选
+type trieNode struct {
+	children map[rune]*trieNode
+	top      []int
+	byType   map[string][]int
+}
+
+func NewSuggester() *Suggester {
+	s := &Suggester{}
+	s.index.Store(&suggestIndex{root: &trieNode{}})
+	return s
+}
+
+// Replace 用新的候选集重建前缀树。每个候选可通过原文前缀或拼音首字母前缀命中
+func (s *Suggester) Replace(entries []Suggestion) {
+	// 先按热度排序，插入时每个节点只需保留最先到达的 suggestTopK 个候选
+	sorted := make([]Suggestion, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Weight > sorted[j].Weight })
+
+	index := &suggestIndex{root: &trieNode{}, entries: sorted}
+	for i, entry := range sorted {
+		key := suggestKey(entry.Text)
+		index.insert(key, i)
+		if variants, ok := PinyinInitials(entry.Text); ok {
+			for _, initials := range variants {
+				if initials != key {
+					index.insert(initials, i)
+				}
+			}
+		}
+	}
+	s.index.Store(index)
+}
+
+func (ix *suggestIndex) insert(key string, entry int) {
+	node := ix.root
+	for _, r := range key {
+		child := node.children[r]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[rune]*trieNode)
+			}
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+		node.top = appendTop(node.top, entry)
+		if node.byType == nil {
+			node.byType = make(map[string][]int)
+		}
+		typ := ix.entries[entry].Type
+		node.byType[typ] = appendTop(node.byType[typ], entry)
+	}
+}
+
+// appendTop 候选未满 suggestTopK 时追加。同一候选的多个键可能经过同一节点，
+// 候选按顺序插入，只需和最后一个比较
+func appendTop(top []int, entry int) []int {
+	if n := len(top); n < suggestTopK && (n == 0 || top[n-1] != entry) {
+		return append(top, entry)
+	}
+	return top
+}
+
+// Suggest 返回以 prefix 开头的候选，可按类型过滤（为空时不限）
+func (s *Suggester) Suggest(prefix string, limit int, suggestionType string) []Suggestion {
+	ix := s.index.Load()
+	key := suggestKey(prefix)
+	if key == "" {
+		return []Suggestion{}
+	}
+
+	node := ix.root
+	for _, r := range key {
+		if node = node.children[r]; node == nil {
+			return []Suggestion{}
+		}
+	}
+
+	top := node.top
+	if suggestionType != "" {
+		top = node.byType[suggestionType]
+	}
+	result := make([]Suggestion, 0, limit)
+	for _, i := range top {
+		if len(result) >= limit {
+			break
+		}
+		result = append(result, ix.entries[i])
+	}
+	return result
+}
+
+// suggestKey 归一化：转小写，去掉空白和标点
+func suggestKey(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, text)
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+)
+
+func suggestionTexts(suggestions []Suggestion) []string {
+	texts := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		texts = append(texts, s.Text)
+	}
+	return texts
+}
+
+func TestSuggest(t *testing.T) {
+	s := NewSuggester()
+	s.Replace([]Suggestion{
+		{Text: "斗破苍穹", Type: SuggestNovel, Weight: 100},
+		{Text: "斗罗大陆", Type: SuggestNovel, Weight: 200},
+		{Text: "斗气", Type: SuggestTag, Weight: 10},
+		{Text: "重生之都市", Type: SuggestNovel, Weight: 50},
+		{Text: "Go Programming", Type: SuggestNovel, Weight: 5},
+	})
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		typ    string
+		want   []string
+	}{
+		{"text prefix ordered by weight", "斗", 10, "", []string{"斗罗大陆", "斗破苍穹", "斗气"}},
+		{"limit", "斗", 1, "", []string{"斗罗大陆"}},
+		{"type filter", "斗", 10, SuggestTag, []string{"斗气"}},
+		{"pinyin initials", "dp", 10, "", []string{"斗破苍穹"}},
+		{"polyphone reading", "cs", 10, "", []string{"重生之都市"}},
+		{"other polyphone reading", "zs", 10, "", []string{"重生之都市"}},
+		{"case and spaces ignored", "go pro", 10, "", []string{"Go Programming"}},
+		{"no match", "xyz", 10, "", []string{}},
+		{"empty prefix", " ", 10, "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := suggestionTexts(s.Suggest(tt.prefix, tt.limit, tt.typ))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("Suggest(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+// 热门类型的候选很多时，按类型过滤仍能找到其他类型
+func TestSuggestTypeNotCrowdedOut(t *testing.T) {
+	var entries []Suggestion
+	for i := 0; i < suggestTopK*2; i++ {
+		entries = append(entries, Suggestion{Text: fmt.Sprintf("龙%d", i), Type: SuggestNovel, Weight: float64(100 + i)})
+	}
+	entries = append(entries, Suggestion{Text: "龙傲天", Type: SuggestCharacter, Weight: 1})
+	s := NewSuggester()
+	s.Replace(entries)
+
+	got := suggestionTexts(s.Suggest("龙", 5, SuggestCharacter))
+	if len(got) != 1 || got[0] != "龙傲天" {
+		t.Fatalf("Suggest() = %q, want [龙傲天]", got)
+	}
+	if n := len(s.Suggest("龙", suggestTopK*2, "")); n != suggestTopK {
+		t.Fatalf("Suggest() returned %d entries, want %d", n, suggestTopK)
+	}
+}
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"unicode"
+)
+
+// 字符类别
+const (
+	classOther = iota
+	classCJK
+	classWord
+)
+
+func runeClass(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r),
+		unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r):
+		return classCJK
+	case unicode.IsLetter(r), unicode.IsDigit(r):
+		return classWord
+	default:
+		return classOther
+	}
+}
+
+// IndexTokens 建索引用的分词：中日韩文字输出二元组和单字（单字用于支持单字查询），
+// 字母数字按连续单词输出，统一转为小写
+func IndexTokens(text string) []string {
+	return tokenize(text, true)
+}
+
+// QueryTokens 查询用的分词：中日韩文字只输出二元组，单独一个字时输出单字
+func QueryTokens(text string) []string {
+	return tokenize(text, false)
+}
+
+func tokenize(text string, unigrams bool) []string {
+	var tokens []string
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		class := runeClass(runes[i])
+		j := i + 1
+		for j < len(runes) && runeClass(runes[j]) == class {
+			j++
+		}
+
+		switch class {
+		case classCJK:
+			run := runes[i:j]
+			if len(run) == 1 {
+				tokens = append(tokens, string(run))
+				break
+			}
+			for k := 0; k < len(run); k++ {
+				if unigrams {
+					tokens = append(tokens, string(run[k]))
+				}
+				if k+1 < len(run) {
+					tokens = append(tokens, string(run[k:k+2]))
+				}
+			}
+		case classWord:
+			word := make([]rune, j-i)
+			for k, r := range runes[i:j] {
+				word[k] = unicode.ToLower(r)
+			}
+			tokens = append(tokens, string(word))
+		}
+		i = j
+	}
+	return tokens
+}
+
+// termFrequencies 统计词频
+func termFrequencies(tokens []string) map[string]int {
+	freq := make(map[string]int, len(tokens))
+	for _, t := range tokens {
+		freq[t]++
+	}
+	return freq
+}
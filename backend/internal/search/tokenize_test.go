@@ -0,0 +1,64 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIndexTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"single CJK character", "龙", []string{"龙"}},
+		{"CJK run", "斗破苍穹", []string{"斗", "斗破", "破", "破苍", "苍", "苍穹", "穹"}},
+		{"latin words lower-cased", "Hello, World", []string{"hello", "world"}},
+		{"mixed CJK and latin", "我爱Go语言", []string{"我", "我爱", "爱", "go", "语", "语言", "言"}},
+		{"digits join latin words", "第3章abc123", []string{"第", "3", "章", "abc123"}},
+		{"punctuation splits CJK runs", "天才，少年", []string{"天", "天才", "才", "少", "少年", "年"}},
+		{"kana is CJK", "すごい", []string{"す", "すご", "ご", "ごい", "い"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IndexTokens(tt.text); !slices.Equal(got, tt.want) {
+				t.Fatalf("IndexTokens(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single CJK character", "龙", []string{"龙"}},
+		{"single latin letter", "a", []string{"a"}},
+		{"CJK run uses bigrams only", "斗破苍穹", []string{"斗破", "破苍", "苍穹"}},
+		{"mixed CJK and latin", "我爱Go语言", []string{"我爱", "go", "语言"}},
+		{"single CJK between latin words", "A龙B", []string{"a", "龙", "b"}},
+		{"whitespace only", "  ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QueryTokens(tt.text); !slices.Equal(got, tt.want) {
+				t.Fatalf("QueryTokens(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// 查询词必须都能在索引词中找到，否则无法命中
+func TestQueryTokensAreIndexed(t *testing.T) {
+	for _, text := range []string{"龙", "斗破苍穹", "我爱Go语言", "第3章abc123"} {
+		indexed := IndexTokens(text)
+		for _, token := range QueryTokens(text) {
+			if !slices.Contains(indexed, token) {
+				t.Errorf("query token %q of %q is not indexed", token, text)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNovelNotFound       = errors.New("novel not found")
+	ErrNovelNotDeleted     = errors.New("novel is not deleted")
+	ErrChapterNotFound     = errors.New("chapter not found")
+	ErrChapterNotPublished = errors.New("only published chapters can be hidden")
+	ErrChapterHidden       = errors.New("chapter is hidden by moderator")
+	ErrChapterNotHidden    = errors.New("chapter is not hidden")
+)
+
+// AdminStats 管理后台的系统概况
+type AdminStats struct {
+	Users          int64 `json:"users"`
+	BannedUsers    int64 `json:"bannedUsers"`
+	NewUsersToday  int64 `json:"newUsersToday"`
+	Novels         int64 `json:"novels"`
+	HiddenNovels   int64 `json:"hiddenNovels"`
+	DeletedNovels  int64 `json:"deletedNovels"`
+	Chapters       int64 `json:"chapters"`
+	HiddenChapters int64 `json:"hiddenChapters"`
+	Comments       int64 `json:"comments"`
+	PendingReports int64 `json:"pendingReports"`
+}
+
+// AdminService 管理后台的内容管理：隐藏、恢复小说和章节，以及系统统计
+type AdminService struct {
+	db           *gorm.DB
+	search       *SearchService
+	tags         *TagService
+	readProgress *ReadProgressService
+}
+
+func NewAdminService(db *gorm.DB, searchService *SearchService, tags *TagService, readProgress *ReadProgressService) *AdminService {
+	return &AdminService{db: db, search: searchService, tags: tags, readProgress: readProgress}
+}
+
+// ListDeletedNovels 分页获取已删除的小说，供恢复时查找
+func (s *AdminService) ListDeletedNovels(page, pageSize int) ([]models.Novel, int64, error) {
+	query := s.db.Unscoped().Model(&models.Novel{}).Where("deleted_at IS NOT NULL")
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var novels []models.Novel
+	if err := query.Omit("novel_outline").Preload("Author").
+		Order("deleted_at desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&novels).Error; err != nil {
+		return nil, 0, err
+	}
+	return novels, total, nil
+}
+
+// HideNovel 隐藏小说：不再出现在列表、搜索、榜单、推荐和订阅源中，作者和管理员仍可查看
+func (s *AdminService) HideNovel(id uint, reason string) (*models.Novel, error) {
+	now := time.Now()
+	return s.updateNovelVisibility(id, &now, reason)
+}
+
+// UnhideNovel 取消隐藏小说
+func (s *AdminService) UnhideNovel(id uint) (*models.Novel, error) {
+	return s.updateNovelVisibility(id, nil, "")
+}
+
+func (s *AdminService) updateNovelVisibility(id uint, hiddenAt *time.Time, reason string) (*models.Novel, error) {
+	var novel models.Novel
+	if err := s.db.Omit("novel_outline").First(&novel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNovelNotFound
+		}
+		return nil, err
+	}
+	// 只改可见性，不更新 updated_at，避免隐藏操作影响“最近更新”排序
+	if err := s.db.Model(&novel).UpdateColumns(map[string]interface{}{
+		"hidden_at":     hiddenAt,
+		"hidden_reason": reason,
+	}).Error; err != nil {
+		return nil, err
+	}
+	novel.HiddenAt = hiddenAt
+	novel.HiddenReason = reason
+	s.reindexNovel(id)
+	// 隐藏的小说不能出现在输入建议中，不等待索引任务，直接标记重建
+	s.search.InvalidateSuggestions()
+	return &novel, nil
+}
+
+// RestoreNovel 恢复已删除的小说，并重新关联删除时释放的标签
+func (s *AdminService) RestoreNovel(id uint) (*models.Novel, error) {
+	var novel models.Novel
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Omit("novel_outline").First(&novel, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNovelNotFound
+			}
+			return err
+		}
+		if !novel.DeletedAt.Valid {
+			return ErrNovelNotDeleted
+		}
+		if err := tx.Unscoped().Model(&novel).UpdateColumn("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		novel.DeletedAt = gorm.DeletedAt{}
+		tags, err := s.tags.Resolve(tx, novel.Tags, false)
+		if err != nil {
+			return err
+		}
+		return s.tags.SetNovelTags(tx, novel.ID, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reindexNovel(id)
+	return &novel, nil
+}
+
+// HideChapter 隐藏已发布的章节，隐藏后作者不能自行改回发布状态
+func (s *AdminService) HideChapter(id uint, reason string) (*models.Chapter, error) {
+	return s.updateChapterVisibility(id, models.ChapterStatusPublished, models.ChapterStatusHidden, reason, ErrChapterNotPublished)
+}
+
+// UnhideChapter 取消隐藏，章节恢复为已发布；不会再次通知收藏的读者
+func (s *AdminService) UnhideChapter(id uint) (*models.Chapter, error) {
+	return s.updateChapterVisibility(id, models.ChapterStatusHidden, models.ChapterStatusPublished, "", ErrChapterNotHidden)
+}
+
+func (s *AdminService) updateChapterVisibility(id uint, from, to int, reason string, errState error) (*models.Chapter, error) {
+	result := s.db.Model(&models.Chapter{}).Where("id = ? AND status = ?", id, from).
+		UpdateColumns(map[string]interface{}{"status": to, "hidden_reason": reason})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var chapter models.Chapter
+	if err := s.db.First(&chapter, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChapterNotFound
+		}
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, errState
+	}
+	s.search.ChapterChanged(id)
+	s.readProgress.ForgetChapters(id)
+	return &chapter, nil
+}
+
+// reindexNovel 重新同步小说及其已发布章节的索引；隐藏和恢复都会影响章节是否可被搜索
+func (s *AdminService) reindexNovel(id uint) {
+	s.search.NovelChanged(id)
+	var chapterIDs []uint
+	if err := s.db.Model(&models.Chapter{}).
+		Where("novel_id = ? AND status = ?", id, models.ChapterStatusPublished).
+		Pluck("id", &chapterIDs).Error; err != nil {
+		log.Printf("Failed to load chapters of novel %d for reindex: %v", id, err)
+		return
+	}
+	for _, chapterID := range chapterIDs {
+		s.search.ChapterChanged(chapterID)
+	}
+}
+
+// Stats 系统概况
+func (s *AdminService) Stats() (*AdminStats, error) {
+	var stats AdminStats
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	counts := []struct {
+		query *gorm.DB
+		dest  *int64
+	}{
+		{s.db.Model(&models.User{}), &stats.Users},
+		{s.db.Model(&models.User{}).Where("banned_at IS NOT NULL AND (banned_until IS NULL OR banned_until > ?)", now), &stats.BannedUsers},
+		{s.db.Model(&models.User{}).Where("created_at >= ?", today), &stats.NewUsersToday},
+		{s.db.Model(&models.Novel{}), &stats.Novels},
+		{s.db.Model(&models.Novel{}).Where("hidden_at IS NOT NULL"), &stats.HiddenNovels},
+		{s.db.Unscoped().Model(&models.Novel{}).Where("deleted_at IS NOT NULL"), &stats.DeletedNovels},
+		{s.db.Model(&models.Chapter{}), &stats.Chapters},
+		{s.db.Model(&models.Chapter{}).Where("status = ?", models.ChapterStatusHidden), &stats.HiddenChapters},
+		{s.db.Model(&models.Comment{}), &stats.Comments},
+		{s.db.Model(&models.CommentReport{}).Where("status = ?", models.CommentReportPending), &stats.PendingReports},
+	}
+	for _, c := range counts {
+		if err := c.query.Count(c.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &stats, nil
+}
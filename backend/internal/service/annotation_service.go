@@ -0,0 +1,487 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	annotationContextLength = 32   // 保存的前后文长度（字符）
+	bookmarkQuoteLength     = 30   // 书签保存的文本片段长度（字符）
+	maxAnnotationLength     = 2000 // 单条划线的最大长度（字符）
+)
+
+var (
+	ErrAnnotationNotFound = errors.New("annotation not found")
+	ErrBookmarkNotFound   = errors.New("bookmark not found")
+)
+
+type AnnotationService struct {
+	db *gorm.DB
+}
+
+func NewAnnotationService(db *gorm.DB) *AnnotationService {
+	return &AnnotationService{db: db}
+}
+
+// AnnotationEntry 跨小说的笔记列表条目
+type AnnotationEntry struct {
+	models.Annotation
+	NovelTitle   string `json:"novelTitle"`
+	ChapterTitle string `json:"chapterTitle"`
+	ChapterOrder int    `json:"chapterOrder"`
+}
+
+// bookmarkEntry 导出时附带小说和章节标题的书签
+type bookmarkEntry struct {
+	models.Bookmark
+	NovelTitle   string
+	ChapterTitle string
+	ChapterOrder int
+}
+
+// CreateBookmark 创建书签
+func (s *AnnotationService) CreateBookmark(userID uint, bookmark *models.Bookmark) error {
+	color, err := normalizeColor(bookmark.Color)
+	if err != nil {
+		return err
+	}
+	bookmark.Color = color
+
+	chapter, err := s.getChapter(bookmark.ChapterID)
+	if err != nil {
+		return err
+	}
+
+	content := []rune(chapter.Content)
+	if bookmark.Offset < 0 || bookmark.Offset > len(content) {
+		return errors.New("bookmark offset out of range")
+	}
+
+	end := bookmark.Offset + bookmarkQuoteLength
+	if end > len(content) {
+		end = len(content)
+	}
+	bookmark.ID = 0
+	bookmark.UserID = userID
+	bookmark.NovelID = chapter.NovelID
+	bookmark.Quote = string(content[bookmark.Offset:end])
+	return s.db.Create(bookmark).Error
+}
+
+// ListBookmarks 获取用户书签，novelID 为 0 时返回全部
+func (s *AnnotationService) ListBookmarks(userID, novelID uint, page, limit int) ([]models.Bookmark, int64, error) {
+	var bookmarks []models.Bookmark
+	var total int64
+
+	query := s.db.Model(&models.Bookmark{}).Where("user_id = ?", userID)
+	if novelID > 0 {
+		query = query.Where("novel_id = ?", novelID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at desc").Offset(offset).Limit(limit).Find(&bookmarks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 章节修改后按保存的文本片段重新定位书签
+	chapterIDs := make([]uint, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		chapterIDs = append(chapterIDs, b.ChapterID)
+	}
+	var chapters []models.Chapter
+	if err := s.db.Select("id", "content").Where("id IN ?", chapterIDs).Find(&chapters).Error; err != nil {
+		return nil, 0, err
+	}
+	contents := make(map[uint]string, len(chapters))
+	for _, c := range chapters {
+		contents[c.ID] = c.Content
+	}
+	for i := range bookmarks {
+		b := &bookmarks[i]
+		quoteLen := utf8.RuneCountInString(b.Quote)
+		if start, _, ok := ResolveTextAnchor(contents[b.ChapterID], b.Offset, b.Offset+quoteLen, b.Quote, "", ""); ok {
+			b.Offset = start
+		}
+	}
+	return bookmarks, total, nil
+}
+
+// UpdateBookmark 更新书签颜色、备注和公开状态
+func (s *AnnotationService) UpdateBookmark(userID, id uint, color, note string, isPublic bool) (*models.Bookmark, error) {
+	var bookmark models.Bookmark
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&bookmark).Error; err != nil {
+		return nil, ErrBookmarkNotFound
+	}
+
+	if color != "" {
+		bookmark.Color = color
+	}
+	normalized, err := normalizeColor(bookmark.Color)
+	if err != nil {
+		return nil, err
+	}
+	bookmark.Color = normalized
+	bookmark.Note = note
+	bookmark.IsPublic = isPublic
+	if err := s.db.Save(&bookmark).Error; err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// DeleteBookmark 删除书签
+func (s *AnnotationService) DeleteBookmark(userID, id uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBookmarkNotFound
+	}
+	return nil
+}
+
+// CreateAnnotation 创建划线批注，引用文本和前后文从章节内容中截取
+func (s *AnnotationService) CreateAnnotation(userID uint, annotation *models.Annotation) error {
+	if err := validateAnnotationColor(annotation); err != nil {
+		return err
+	}
+
+	chapter, err := s.getChapter(annotation.ChapterID)
+	if err != nil {
+		return err
+	}
+
+	content := []rune(chapter.Content)
+	start, end := annotation.StartOffset, annotation.EndOffset
+	if start < 0 || end > len(content) || start >= end {
+		return errors.New("annotation range out of bounds")
+	}
+	if end-start > maxAnnotationLength {
+		return fmt.Errorf("annotation exceeds %d characters", maxAnnotationLength)
+	}
+
+	annotation.ID = 0
+	annotation.UserID = userID
+	annotation.NovelID = chapter.NovelID
+	annotation.Quote = string(content[start:end])
+	annotation.Prefix = string(content[max(0, start-annotationContextLength):start])
+	annotation.Suffix = string(content[end:min(len(content), end+annotationContextLength)])
+	return s.db.Create(annotation).Error
+}
+
+// UpdateAnnotation 更新批注颜色、笔记和公开状态
+func (s *AnnotationService) UpdateAnnotation(userID, id uint, color, note string, isPublic bool) (*models.Annotation, error) {
+	var annotation models.Annotation
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&annotation).Error; err != nil {
+		return nil, ErrAnnotationNotFound
+	}
+
+	if color != "" {
+		annotation.Color = color
+	}
+	if err := validateAnnotationColor(&annotation); err != nil {
+		return nil, err
+	}
+	annotation.Note = note
+	annotation.IsPublic = isPublic
+	if err := s.db.Save(&annotation).Error; err != nil {
+		return nil, err
+	}
+	return &annotation, nil
+}
+
+// DeleteAnnotation 删除批注
+func (s *AnnotationService) DeleteAnnotation(userID, id uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Annotation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAnnotationNotFound
+	}
+	return nil
+}
+
+// ListChapterAnnotations 获取章节中当前用户的批注和他人公开的批注，并按最新内容重新定位
+func (s *AnnotationService) ListChapterAnnotations(userID, chapterID uint) ([]models.Annotation, error) {
+	chapter, err := s.getChapter(chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var annotations []models.Annotation
+	if err := s.db.Where("chapter_id = ? AND (user_id = ? OR is_public = ?)", chapterID, userID, true).
+		Order("start_offset asc").
+		Find(&annotations).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range annotations {
+		a := &annotations[i]
+		start, end, ok := ResolveTextAnchor(chapter.Content, a.StartOffset, a.EndOffset, a.Quote, a.Prefix, a.Suffix)
+		if !ok {
+			a.Orphaned = true
+			continue
+		}
+		if start != a.StartOffset || end != a.EndOffset {
+			a.StartOffset, a.EndOffset = start, end
+			// 仅修正自己的批注位置，避免在读取他人批注时产生写入
+			if a.UserID == userID {
+				s.db.Model(&models.Annotation{}).Where("id = ?", a.ID).
+					UpdateColumns(map[string]interface{}{"start_offset": start, "end_offset": end})
+			}
+		}
+	}
+	return annotations, nil
+}
+
+// ListChapterBookmarks 获取章节中当前用户的书签和他人公开的书签，并按最新内容重新定位
+func (s *AnnotationService) ListChapterBookmarks(userID, chapterID uint) ([]models.Bookmark, error) {
+	chapter, err := s.getChapter(chapterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var bookmarks []models.Bookmark
+	if err := s.db.Where("chapter_id = ? AND (user_id = ? OR is_public = ?)", chapterID, userID, true).
+		Order("`offset` asc").
+		Find(&bookmarks).Error; err != nil {
+		return nil, err
+	}
+	for i := range bookmarks {
+		b := &bookmarks[i]
+		quoteLen := utf8.RuneCountInString(b.Quote)
+		if start, _, ok := ResolveTextAnchor(chapter.Content, b.Offset, b.Offset+quoteLen, b.Quote, "", ""); ok {
+			b.Offset = start
+		}
+	}
+	return bookmarks, nil
+}
+
+// ListUserAnnotations 获取用户在所有小说中的笔记
+func (s *AnnotationService) ListUserAnnotations(userID, novelID uint, page, limit int) ([]AnnotationEntry, int64, error) {
+	var entries []AnnotationEntry
+	var total int64
+
+	query := s.db.Table("annotations").
+		Joins("JOIN novels ON novels.id = annotations.novel_id AND novels.deleted_at IS NULL").
+		Joins("JOIN chapters ON chapters.id = annotations.chapter_id").
+		Where("annotations.user_id = ?", userID)
+	if novelID > 0 {
+		query = query.Where("annotations.novel_id = ?", novelID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Select("annotations.*, novels.title AS novel_title, chapters.title AS chapter_title, chapters.`order` AS chapter_order").
+		Order("annotations.updated_at desc").
+		Offset(offset).Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// exportNote 导出的一条笔记，来自划线批注或带备注的书签
+type exportNote struct {
+	NovelID      uint
+	NovelTitle   string
+	ChapterID    uint
+	ChapterTitle string
+	ChapterOrder int
+	Offset       int
+	Quote        string
+	Note         string
+	Bookmark     bool
+}
+
+// ExportAnnotationsMarkdown 将用户的划线批注和书签备注导出为 Markdown，按小说和章节顺序分组
+func (s *AnnotationService) ExportAnnotationsMarkdown(userID, novelID uint) ([]byte, error) {
+	var entries []AnnotationEntry
+	query := s.db.Table("annotations").
+		Joins("JOIN novels ON novels.id = annotations.novel_id AND novels.deleted_at IS NULL").
+		Joins("JOIN chapters ON chapters.id = annotations.chapter_id").
+		Where("annotations.user_id = ?", userID)
+	if novelID > 0 {
+		query = query.Where("annotations.novel_id = ?", novelID)
+	}
+	if err := query.Select("annotations.*, novels.title AS novel_title, chapters.title AS chapter_title, chapters.`order` AS chapter_order").
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	// 没有备注的书签只是阅读位置，不算笔记
+	var bookmarks []bookmarkEntry
+	query = s.db.Table("bookmarks").
+		Joins("JOIN novels ON novels.id = bookmarks.novel_id AND novels.deleted_at IS NULL").
+		Joins("JOIN chapters ON chapters.id = bookmarks.chapter_id").
+		Where("bookmarks.user_id = ? AND bookmarks.note <> ''", userID)
+	if novelID > 0 {
+		query = query.Where("bookmarks.novel_id = ?", novelID)
+	}
+	if err := query.Select("bookmarks.*, novels.title AS novel_title, chapters.title AS chapter_title, chapters.`order` AS chapter_order").
+		Scan(&bookmarks).Error; err != nil {
+		return nil, err
+	}
+
+	notes := make([]exportNote, 0, len(entries)+len(bookmarks))
+	for _, e := range entries {
+		notes = append(notes, exportNote{
+			NovelID: e.NovelID, NovelTitle: e.NovelTitle, ChapterID: e.ChapterID, ChapterTitle: e.ChapterTitle,
+			ChapterOrder: e.ChapterOrder, Offset: e.StartOffset, Quote: e.Quote, Note: e.Note,
+		})
+	}
+	for _, b := range bookmarks {
+		notes = append(notes, exportNote{
+			NovelID: b.NovelID, NovelTitle: b.NovelTitle, ChapterID: b.ChapterID, ChapterTitle: b.ChapterTitle,
+			ChapterOrder: b.ChapterOrder, Offset: b.Offset, Quote: b.Quote, Note: b.Note, Bookmark: true,
+		})
+	}
+	sort.SliceStable(notes, func(i, j int) bool {
+		a, b := notes[i], notes[j]
+		if a.NovelTitle != b.NovelTitle {
+			return a.NovelTitle < b.NovelTitle
+		}
+		if a.NovelID != b.NovelID {
+			return a.NovelID < b.NovelID
+		}
+		if a.ChapterOrder != b.ChapterOrder {
+			return a.ChapterOrder < b.ChapterOrder
+		}
+		return a.Offset < b.Offset
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("# 我的笔记\n\n")
+	fmt.Fprintf(&buf, "导出时间：%s\n", time.Now().Format("2006-01-02 15:04"))
+
+	var lastNovel, lastChapter uint
+	for _, n := range notes {
+		if n.NovelID != lastNovel {
+			fmt.Fprintf(&buf, "\n## %s\n", n.NovelTitle)
+			lastNovel, lastChapter = n.NovelID, 0
+		}
+		if n.ChapterID != lastChapter {
+			fmt.Fprintf(&buf, "\n### 第%d章 %s\n", n.ChapterOrder, n.ChapterTitle)
+			lastChapter = n.ChapterID
+		}
+		buf.WriteString("\n")
+		if n.Bookmark {
+			fmt.Fprintf(&buf, "书签：%s……\n", strings.Join(strings.Fields(n.Quote), " "))
+		} else {
+			for _, line := range strings.Split(strings.TrimSpace(n.Quote), "\n") {
+				fmt.Fprintf(&buf, "> %s\n", line)
+			}
+		}
+		if note := strings.TrimSpace(n.Note); note != "" {
+			fmt.Fprintf(&buf, "\n%s\n", note)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ResolveTextAnchor 根据保存的引用文本和前后文在章节内容中重新定位批注区间（字符偏移）。
+// 原位置文本未变时直接返回；否则在所有出现位置中选择前后文最匹配、距离原位置最近的一处。
+func ResolveTextAnchor(content string, start, end int, quote, prefix, suffix string) (int, int, bool) {
+	runes := []rune(content)
+	quoteRunes := []rune(quote)
+	if len(quoteRunes) == 0 {
+		return 0, 0, false
+	}
+	if start >= 0 && end <= len(runes) && start < end && string(runes[start:end]) == quote {
+		return start, end, true
+	}
+
+	best, bestScore, bestDistance := -1, -1, 0
+	for i := 0; i+len(quoteRunes) <= len(runes); i++ {
+		if runes[i] != quoteRunes[0] || string(runes[i:i+len(quoteRunes)]) != quote {
+			continue
+		}
+		score := commonSuffixLength(string(runes[max(0, i-len([]rune(prefix))):i]), prefix) +
+			commonPrefixLength(string(runes[i+len(quoteRunes):min(len(runes), i+len(quoteRunes)+len([]rune(suffix)))]), suffix)
+		distance := i - start
+		if distance < 0 {
+			distance = -distance
+		}
+		if score > bestScore || score == bestScore && distance < bestDistance {
+			best, bestScore, bestDistance = i, score, distance
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	return best, best + len(quoteRunes), true
+}
+
+func (s *AnnotationService) getChapter(chapterID uint) (*models.Chapter, error) {
+	var chapter models.Chapter
+	if err := s.db.First(&chapter, chapterID).Error; err != nil {
+		return nil, errors.New("chapter not found")
+	}
+	return &chapter, nil
+}
+
+func validateAnnotationColor(annotation *models.Annotation) error {
+	color, err := normalizeColor(annotation.Color)
+	if err != nil {
+		return err
+	}
+	annotation.Color = color
+	return nil
+}
+
+// normalizeColor 校验书签和批注的颜色，为空时使用默认颜色
+func normalizeColor(color string) (string, error) {
+	if color == "" {
+		return models.AnnotationColors[0], nil
+	}
+	for _, c := range models.AnnotationColors {
+		if c == color {
+			return color, nil
+		}
+	}
+	return "", fmt.Errorf("invalid color: %s", color)
+}
+
+func commonPrefixLength(a, b string) int {
+	n := 0
+	for len(a) > 0 && len(b) > 0 {
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if ra != rb {
+			break
+		}
+		n++
+		a, b = a[sa:], b[sb:]
+	}
+	return n
+}
+
+func commonSuffixLength(a, b string) int {
+	n := 0
+	for len(a) > 0 && len(b) > 0 {
+		ra, sa := utf8.DecodeLastRuneInString(a)
+		rb, sb := utf8.DecodeLastRuneInString(b)
+		if ra != rb {
+			break
+		}
+		n++
+		a, b = a[:len(a)-sa], b[:len(b)-sb]
+	}
+	return n
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 审计动作
+const (
+	AuditLogin             = "auth.login"
+	AuditLoginFailed       = "auth.login_failed"
+	AuditPasswordChange    = "user.password_change"
+	AuditPasswordReset     = "user.password_reset"
+	AuditEmailChange       = "user.email_change"
+	AuditUserBan           = "user.ban"
+	AuditUserUnban         = "user.unban"
+	AuditUserForceReset    = "user.force_password_reset"
+	AuditUserRoleChange    = "user.role_change"
+	AuditNovelDelete       = "novel.delete"
+	AuditNovelHide         = "novel.hide"
+	AuditNovelUnhide       = "novel.unhide"
+	AuditNovelRestore      = "novel.restore"
+	AuditOutlineImport     = "outline.import"
+	AuditOutlineRestore    = "outline.restore"
+	AuditChapterDelete     = "chapter.delete"
+	AuditChapterHide       = "chapter.hide"
+	AuditChapterUnhide     = "chapter.unhide"
+	AuditCategoryCreate    = "category.create"
+	AuditCategoryUpdate    = "category.update"
+	AuditCategoryDelete    = "category.delete"
+	AuditTagMerge          = "tag.merge"
+	AuditTagSynonymAdd     = "tag.synonym_add"
+	AuditTagSynonymDelete  = "tag.synonym_delete"
+	AuditTagCategory       = "tag.category"
+	AuditReportResolve     = "report.resolve"
+	AuditModerationApprove = "moderation.approve"
+	AuditModerationReject  = "moderation.reject"
+	AuditModerationReload  = "moderation.reload_words"
+	AuditDuplicateConfirm  = "duplicate.confirm"
+	AuditDuplicateDismiss  = "duplicate.dismiss"
+)
+
+// securityActions 用户“安全记录”中展示的动作，都与账号本身相关
+var securityActions = []string{
+	AuditLogin, AuditLoginFailed, AuditPasswordChange, AuditPasswordReset, AuditEmailChange,
+	AuditUserBan, AuditUserUnban, AuditUserForceReset, AuditUserRoleChange,
+}
+
+const auditTextMaxLength = 255
+
+// AuditFilter 管理后台的审计日志查询条件，零值表示不筛选
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	RequestID  string
+	From       time.Time
+	To         time.Time
+}
+
+// SecurityEvent 用户可见的账号安全记录；管理员执行的操作不展示管理员的网络信息
+type SecurityEvent struct {
+	ID        uint      `json:"id"`
+	Action    string    `json:"action"`
+	ByAdmin   bool      `json:"byAdmin"`
+	Reason    string    `json:"reason,omitempty"`
+	Before    string    `json:"before,omitempty"`
+	After     string    `json:"after,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AuditService 审计日志，只提供追加写入和查询
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record 写入一条审计日志；过长的字段截断后保存，截断时会追加省略号
+func (s *AuditService) Record(entry *models.AuditLog) error {
+	entry.ID = 0
+	entry.UserAgent = truncateRunes(entry.UserAgent, auditTextMaxLength-1)
+	entry.Reason = truncateRunes(entry.Reason, auditTextMaxLength-1)
+	return s.db.Create(entry).Error
+}
+
+// List 按条件分页查询审计日志，最新的在前
+func (s *AuditService) List(filter AuditFilter, page, pageSize int) ([]models.AuditLog, int64, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []models.AuditLog
+	if err := query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// ListSecurityActivity 获取针对用户账号的安全记录，包括本人、他人登录尝试和管理员的操作
+func (s *AuditService) ListSecurityActivity(userID uint, page, pageSize int) ([]SecurityEvent, int64, error) {
+	query := s.db.Model(&models.AuditLog{}).
+		Where("action IN ?", securityActions).
+		Where("target_type = ? AND target_id = ?", models.AuditTargetUser, userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var logs []models.AuditLog
+	if err := query.Order("id desc").Offset((page - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	events := make([]SecurityEvent, 0, len(logs))
+	for _, l := range logs {
+		event := SecurityEvent{
+			ID:        l.ID,
+			Action:    l.Action,
+			Before:    l.Before,
+			After:     l.After,
+			CreatedAt: l.CreatedAt,
+		}
+		// 本人操作和匿名操作（如登录失败）展示来源，便于用户发现异常登录
+		if l.ActorID == userID || l.ActorID == 0 {
+			event.IP = l.IP
+			event.UserAgent = l.UserAgent
+		} else {
+			event.ByAdmin = true
+			event.Reason = l.Reason
+		}
+		events = append(events, event)
+	}
+	return events, total, nil
+}
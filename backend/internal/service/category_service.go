@@ -0,0 +1,431 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+	"gorm.io/gorm"
+)
+
+// fallbackCategorySlug 无法识别的旧分类统一归入“其他”
+const fallbackCategorySlug = "other"
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrInvalidCategory  = errors.New("invalid category")
+	ErrCategoryInUse    = errors.New("category is in use")
+)
+
+var categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// categorySeed 预置分类
+type categorySeed struct {
+	slug     string
+	name     string
+	icon     string
+	aliases  []string
+	children []categorySeed
+}
+
+var defaultCategories = []categorySeed{
+	{slug: "fantasy", name: "玄幻奇幻", icon: "🐉", aliases: []string{"玄幻", "奇幻", "魔幻"}, children: []categorySeed{
+		{slug: "eastern-fantasy", name: "东方玄幻", aliases: []string{"东方", "高武"}},
+		{slug: "western-fantasy", name: "西方奇幻", aliases: []string{"西幻", "剑与魔法"}},
+	}},
+	{slug: "xianxia", name: "仙侠修真", icon: "⛰️", aliases: []string{"仙侠", "修真", "修仙"}},
+	{slug: "martial-arts", name: "武侠", icon: "⚔️", aliases: []string{"wuxia", "江湖"}},
+	{slug: "urban", name: "都市", icon: "🏙️", aliases: []string{"city", "现代", "都市生活"}},
+	{slug: "romance", name: "言情", icon: "💕", aliases: []string{"love", "爱情", "恋爱"}, children: []categorySeed{
+		{slug: "modern-romance", name: "现代言情", aliases: []string{"现言"}},
+		{slug: "ancient-romance", name: "古代言情", aliases: []string{"古言"}},
+	}},
+	{slug: "scifi", name: "科幻", icon: "🚀", aliases: []string{"sci-fi", "science fiction", "sf"}, children: []categorySeed{
+		{slug: "space-opera", name: "星际", aliases: []string{"太空", "星际科幻"}},
+		{slug: "apocalypse", name: "末世", aliases: []string{"末日", "废土"}},
+	}},
+	{slug: "mystery", name: "悬疑推理", icon: "🔍", aliases: []string{"悬疑", "推理", "detective", "suspense"}},
+	{slug: "history", name: "历史", icon: "📜", aliases: []string{"historical", "架空历史"}},
+	{slug: "game", name: "游戏竞技", icon: "🎮", aliases: []string{"游戏", "网游", "电竞", "gaming"}},
+	{slug: fallbackCategorySlug, name: "其他", icon: "📚", aliases: []string{"未分类", "unknown", "misc"}},
+}
+
+type CategoryService struct {
+	db *gorm.DB
+}
+
+func NewCategoryService(db *gorm.DB) *CategoryService {
+	return &CategoryService{db: db}
+}
+
+// EnsureDefaultCategories 分类表为空时写入预置分类，之后由管理员维护
+func (s *CategoryService) EnsureDefaultCategories() error {
+	var count int64
+	if err := s.db.Model(&models.Category{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var create func(seeds []categorySeed, parentID *uint) error
+		create = func(seeds []categorySeed, parentID *uint) error {
+			for i, seed := range seeds {
+				category := models.Category{
+					ParentID:  parentID,
+					Slug:      seed.slug,
+					Name:      seed.name,
+					Icon:      seed.icon,
+					SortOrder: i,
+					Aliases:   models.StringArray(seed.aliases),
+				}
+				if err := tx.Create(&category).Error; err != nil {
+					return err
+				}
+				if err := create(seed.children, &category.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return create(defaultCategories, nil)
+	})
+}
+
+// MigrateLegacyCategories 把小说上不属于分类目录的旧分类文本映射到最接近的分类
+func (s *CategoryService) MigrateLegacyCategories(ctx context.Context) error {
+	categories, err := s.all()
+	if err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+	slugs := make([]string, 0, len(categories))
+	for _, c := range categories {
+		slugs = append(slugs, c.Slug)
+	}
+
+	var legacy []string
+	if err := s.db.WithContext(ctx).Unscoped().Model(&models.Novel{}).
+		Where("category <> '' AND category NOT IN ?", slugs).
+		Distinct().Pluck("category", &legacy).Error; err != nil {
+		return err
+	}
+	for _, value := range legacy {
+		slug := matchCategory(value, categories)
+		if err := s.db.WithContext(ctx).Unscoped().Model(&models.Novel{}).
+			Where("category = ?", value).
+			UpdateColumn("category", slug).Error; err != nil {
+			return err
+		}
+		log.Printf("Migrated novel category %q to %q", value, slug)
+	}
+	return nil
+}
+
+// categoryKey 用于模糊匹配的分类写法：全角转半角、转小写，只保留文字和数字
+func categoryKey(s string) string {
+	s = strings.ToLower(width.Fold.String(s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// matchCategory 为旧分类文本找最接近的分类：先比较 slug、名称和别名，
+// 再看是否互相包含（取最长的匹配），最后允许英文拼写有少量差异，都不满足时归入“其他”
+func matchCategory(value string, categories []models.Category) string {
+	key := categoryKey(value)
+	if key == "" {
+		return fallbackCategorySlug
+	}
+
+	type candidate struct {
+		slug string
+		key  string
+	}
+	var candidates []candidate
+	for _, c := range categories {
+		for _, k := range append([]string{c.Slug, c.Name}, c.Aliases...) {
+			if k = categoryKey(k); k != "" {
+				candidates = append(candidates, candidate{slug: c.Slug, key: k})
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		if c.key == key {
+			return c.slug
+		}
+	}
+
+	best, bestLen := "", 0
+	for _, c := range candidates {
+		if (strings.Contains(key, c.key) || strings.Contains(c.key, key)) && len(c.key) > bestLen {
+			best, bestLen = c.slug, len(c.key)
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	bestDist := -1
+	for _, c := range candidates {
+		limit := len([]rune(c.key)) / 3
+		if limit > 2 {
+			limit = 2
+		}
+		if d := editDistance(key, c.key); d <= limit && (bestDist < 0 || d < bestDist) {
+			best, bestDist = c.slug, d
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return fallbackCategorySlug
+}
+
+// editDistance 按字符计算的编辑距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func (s *CategoryService) all() ([]models.Category, error) {
+	var categories []models.Category
+	err := s.db.Order("sort_order asc, id asc").Find(&categories).Error
+	return categories, err
+}
+
+// GetTree 获取分类树，每个分类附带本分类及子分类下的小说数
+func (s *CategoryService) GetTree() ([]models.Category, error) {
+	categories, err := s.all()
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	if err := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Select("category, COUNT(*) AS count").
+		Group("category").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counts[r.Category] = r.Count
+	}
+
+	children := make(map[uint][]models.Category)
+	var roots []models.Category
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+	var build func(c models.Category) models.Category
+	build = func(c models.Category) models.Category {
+		c.NovelCount = counts[c.Slug]
+		for _, child := range children[c.ID] {
+			child = build(child)
+			c.NovelCount += child.NovelCount
+			c.Children = append(c.Children, child)
+		}
+		return c
+	}
+	tree := make([]models.Category, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree, nil
+}
+
+// Expand 返回分类及其所有子分类的 slug，用于按父分类筛选小说
+func (s *CategoryService) Expand(slug string) ([]string, error) {
+	categories, err := s.all()
+	if err != nil {
+		return nil, err
+	}
+	var rootID uint
+	children := make(map[uint][]models.Category)
+	for _, c := range categories {
+		if c.Slug == slug {
+			rootID = c.ID
+		}
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+	if rootID == 0 {
+		return nil, ErrCategoryNotFound
+	}
+
+	slugs := []string{slug}
+	queue := []uint{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			slugs = append(slugs, child.Slug)
+			queue = append(queue, child.ID)
+		}
+	}
+	return slugs, nil
+}
+
+// ValidateSlug 检查小说引用的分类是否存在，空字符串表示未分类
+func (s *CategoryService) ValidateSlug(slug string) error {
+	if slug == "" {
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(&models.Category{}).Where("slug = ?", slug).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: 分类 %q 不存在", ErrInvalidCategory, slug)
+	}
+	return nil
+}
+
+// CategoryName 获取分类显示名，分类不存在时返回 slug 本身
+func (s *CategoryService) CategoryName(slug string) string {
+	var names []string
+	s.db.Model(&models.Category{}).Where("slug = ?", slug).Limit(1).Pluck("name", &names)
+	if len(names) == 0 {
+		return slug
+	}
+	return names[0]
+}
+
+// CreateCategory 创建分类
+func (s *CategoryService) CreateCategory(category *models.Category) error {
+	if err := s.validate(s.db, category); err != nil {
+		return err
+	}
+	return s.db.Create(category).Error
+}
+
+// UpdateCategory 更新分类；修改 slug 时同步更新引用该分类的小说
+func (s *CategoryService) UpdateCategory(id uint, input *models.Category) (*models.Category, error) {
+	var category models.Category
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&category, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		oldSlug := category.Slug
+		category.ParentID = input.ParentID
+		category.Slug = input.Slug
+		category.Name = input.Name
+		category.Icon = input.Icon
+		category.SortOrder = input.SortOrder
+		category.Aliases = input.Aliases
+		if err := s.validate(tx, &category); err != nil {
+			return err
+		}
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+		if category.Slug != oldSlug {
+			return tx.Unscoped().Model(&models.Novel{}).Where("category = ?", oldSlug).
+				UpdateColumn("category", category.Slug).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// DeleteCategory 删除分类，仍有子分类或小说时不允许删除
+func (s *CategoryService) DeleteCategory(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		var children, novels int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Novel{}).Where("category = ?", category.Slug).Count(&novels).Error; err != nil {
+			return err
+		}
+		if children > 0 || novels > 0 {
+			return ErrCategoryInUse
+		}
+		return tx.Delete(&category).Error
+	})
+}
+
+// validate 检查 slug 格式与唯一性、名称，以及父分类存在且不会形成环
+func (s *CategoryService) validate(tx *gorm.DB, category *models.Category) error {
+	category.Slug = strings.TrimSpace(category.Slug)
+	category.Name = strings.TrimSpace(category.Name)
+	if !categorySlugPattern.MatchString(category.Slug) || len(category.Slug) > 50 {
+		return fmt.Errorf("%w: slug 只能包含小写字母、数字和连字符", ErrInvalidCategory)
+	}
+	if category.Name == "" {
+		return fmt.Errorf("%w: 分类名称不能为空", ErrInvalidCategory)
+	}
+
+	var count int64
+	if err := tx.Model(&models.Category{}).Where("slug = ? AND id <> ?", category.Slug, category.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: slug %q 已被使用", ErrInvalidCategory, category.Slug)
+	}
+
+	// 沿父分类向上查找，确认父分类存在且不是自己的子孙
+	for parentID := category.ParentID; parentID != nil; {
+		if category.ID != 0 && *parentID == category.ID {
+			return fmt.Errorf("%w: 不能移动到自己的子分类下", ErrInvalidCategory)
+		}
+		var parent models.Category
+		if err := tx.Select("id", "parent_id").First(&parent, *parentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: 父分类不存在", ErrInvalidCategory)
+			}
+			return err
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
@@ -3,15 +3,20 @@ package service
 import (
 	"ai-novel-platform/internal/models"
 	"errors"
+	"time"
 	"gorm.io/gorm"
 )
 
 type ChapterService struct {
-	db *gorm.DB
+	db            *gorm.DB
+	notifications *NotificationService
+	search        *SearchService
+	duplicates    *DuplicateService
+	readProgress  *ReadProgressService
 }
 
-func NewChapterService(db *gorm.DB) *ChapterService {
-	return &ChapterService{db: db}
+func NewChapterService(db *gorm.DB, notifications *NotificationService, search *SearchService, duplicates *DuplicateService, readProgress *ReadProgressService) *ChapterService {
+	return &ChapterService{db: db, notifications: notifications, search: search, duplicates: duplicates, readProgress: readProgress}
 }
 
 // CreateChapter 创建新章节
@@ -26,7 +31,15 @@ func (s *ChapterService) CreateChapter(chapter *models.Chapter) error {
 		Scan(&maxOrder)
 
 	chapter.Order = maxOrder.MaxOrder + 1
-	return s.db.Create(chapter).Error
+	if err := s.db.Create(chapter).Error; err != nil {
+		return err
+	}
+	s.search.ChapterChanged(chapter.ID)
+	s.duplicates.ChapterChanged(chapter.ID)
+	if chapter.Status == models.ChapterStatusPublished {
+		s.notifyPublished(chapter)
+	}
+	return nil
 }
 
 // GetChapter 获取章节详情
@@ -40,12 +53,21 @@ func (s *ChapterService) GetChapter(id uint) (*models.Chapter, error) {
 
 // UpdateChapter 更新章节
 func (s *ChapterService) UpdateChapter(id uint, updates map[string]interface{}) error {
-	return s.db.Model(&models.Chapter{}).Where("id = ?", id).Updates(updates).Error
+	if err := s.db.Model(&models.Chapter{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return err
+	}
+	s.search.ChapterChanged(id)
+	s.duplicates.ChapterChanged(id)
+	return nil
 }
 
 // DeleteChapter 删除章节
 func (s *ChapterService) DeleteChapter(id uint) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	defer s.search.ChapterChanged(id)
+	defer s.duplicates.ChapterChanged(id)
+	// 删除的章节和顺序前移的后续章节
+	changed := []uint{id}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		var chapter models.Chapter
 		if err := tx.First(&chapter, id).Error; err != nil {
 			return err
@@ -57,25 +79,47 @@ func (s *ChapterService) DeleteChapter(id uint) error {
 		}
 
 		// 更新后续章节的顺序
-		return tx.Model(&models.Chapter{}).
-			Where("novel_id = ? AND `order` > ?", chapter.NovelID, chapter.Order).
-			UpdateColumn("`order`", gorm.Expr("`order` - 1")).
-			Error
+		later := tx.Model(&models.Chapter{}).Where("novel_id = ? AND `order` > ?", chapter.NovelID, chapter.Order)
+		var laterIDs []uint
+		if err := later.Session(&gorm.Session{}).Pluck("id", &laterIDs).Error; err != nil {
+			return err
+		}
+		changed = append(changed, laterIDs...)
+		return later.UpdateColumn("`order`", gorm.Expr("`order` - 1")).Error
 	})
+	s.readProgress.ForgetChapters(changed...)
+	return err
 }
 
-// ListNovelChapters 获取小说的章节列表
-func (s *ChapterService) ListNovelChapters(novelID uint) ([]models.Chapter, error) {
-	var chapters []models.Chapter
-	err := s.db.Where("novel_id = ?", novelID).
-		Order("`order` asc").
-		Find(&chapters).Error
+// ChapterListItem 章节列表项，不含正文
+type ChapterListItem struct {
+	ID           uint      `json:"id"`
+	NovelID      uint      `json:"novelId"`
+	Title        string    `json:"title"`
+	WordCount    int       `json:"wordCount"`
+	Order        int       `json:"order"`
+	Status       int       `json:"status"`
+	ReadCount    int       `json:"readCount"`
+	HiddenReason string    `json:"hiddenReason,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updateTime"`
+}
+
+// ListNovelChapters 获取小说的章节列表，includeUnpublished 为 false 时只返回已发布的章节
+func (s *ChapterService) ListNovelChapters(novelID uint, includeUnpublished bool) ([]ChapterListItem, error) {
+	chapters := []ChapterListItem{}
+	query := s.db.Model(&models.Chapter{}).Where("novel_id = ?", novelID)
+	if !includeUnpublished {
+		query = query.Where("status = ?", models.ChapterStatusPublished)
+	}
+	err := query.Order("`order` asc").Find(&chapters).Error
 	return chapters, err
 }
 
 // MoveChapter 移动章节位置
 func (s *ChapterService) MoveChapter(id uint, direction string) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	var moved []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		var chapter models.Chapter
 		if err := tx.First(&chapter, id).Error; err != nil {
 			return err
@@ -107,8 +151,15 @@ func (s *ChapterService) MoveChapter(id uint, direction string) error {
 		if err := tx.Save(&chapter).Error; err != nil {
 			return err
 		}
-		return tx.Save(&targetChapter).Error
+		if err := tx.Save(&targetChapter).Error; err != nil {
+			return err
+		}
+		moved = []uint{chapter.ID, targetChapter.ID}
+		return nil
 	})
+	// 提交后再清除缓存，避免并发读取把旧顺序重新写入缓存
+	s.readProgress.ForgetChapters(moved...)
+	return err
 }
 
 // UpdateChapterContent 更新章节内容
@@ -118,7 +169,12 @@ func (s *ChapterService) UpdateChapterContent(id uint, title string, content str
 		"content":    content,
 		"word_count": wordCount,
 	}
-	return s.db.Model(&models.Chapter{}).Where("id = ?", id).Updates(updates).Error
+	if err := s.db.Model(&models.Chapter{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		return err
+	}
+	s.search.ChapterChanged(id)
+	s.duplicates.ChapterChanged(id)
+	return nil
 }
 
 // GetChaptersByNovelID 获取小说的所有章节
@@ -130,7 +186,60 @@ func (s *ChapterService) GetChaptersByNovelID(novelID uint) ([]models.Chapter, e
 	return chapters, err
 }
 
-// UpdateChapterStatus 更新章节状态
+// UpdateChapterStatus 更新章节状态，首次变为已发布时通知收藏该小说的读者；被管理员隐藏的章节不能修改状态
 func (s *ChapterService) UpdateChapterStatus(id uint, status int) error {
-	return s.db.Model(&models.Chapter{}).Where("id = ?", id).Update("status", status).Error
+	// 隐藏状态只能在管理后台设置和解除
+	if status == models.ChapterStatusHidden {
+		return ErrChapterHidden
+	}
+	result := s.db.Model(&models.Chapter{}).
+		Where("id = ? AND status <> ? AND status <> ?", id, status, models.ChapterStatusHidden).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.search.ChapterChanged(id)
+		s.readProgress.ForgetChapters(id)
+	}
+	if result.RowsAffected > 0 && status == models.ChapterStatusPublished {
+		chapter, err := s.GetChapter(id)
+		if err != nil {
+			return err
+		}
+		s.notifyPublished(chapter)
+	}
+	return nil
+}
+
+// FinishReview 结束章节的人工审核：通过时发布并通知读者，未通过时退回草稿。
+// 章节已不在审核中（如作者已撤回）时不做修改
+func (s *ChapterService) FinishReview(id uint, approved bool) error {
+	status := models.ChapterStatusDraft
+	if approved {
+		status = models.ChapterStatusPublished
+	}
+	result := s.db.Model(&models.Chapter{}).
+		Where("id = ? AND status = ?", id, models.ChapterStatusReviewing).
+		Update("status", status)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	s.search.ChapterChanged(id)
+	if approved {
+		chapter, err := s.GetChapter(id)
+		if err != nil {
+			return err
+		}
+		s.notifyPublished(chapter)
+	}
+	return nil
+}
+
+func (s *ChapterService) notifyPublished(chapter *models.Chapter) {
+	s.notifications.Publish(NotificationEvent{
+		Type:      models.NotificationNewChapter,
+		NovelID:   chapter.NovelID,
+		ChapterID: chapter.ID,
+	})
 } 
\ No newline at end of file
@@ -0,0 +1,657 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/policy"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxCommentLength       = 2000
+	maxPinnedComments      = 3
+	commentReportThreshold = 5 // 待处理举报达到该数量时自动隐藏评论
+)
+
+// 评论排序方式
+const (
+	CommentSortLatest = "latest"
+	CommentSortHot    = "hot"
+)
+
+var (
+	ErrCommentNotFound    = errors.New("comment not found")
+	ErrCommentForbidden   = errors.New("no permission to operate this comment")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrTooManyPinned      = fmt.Errorf("at most %d comments can be pinned", maxPinnedComments)
+	ErrCommentReportExist = errors.New("comment already reported")
+)
+
+type CommentService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewCommentService(db *gorm.DB, notifications *NotificationService) *CommentService {
+	return &CommentService{db: db, notifications: notifications}
+}
+
+// CommentPage 游标分页的评论列表
+type CommentPage struct {
+	Pinned     []models.Comment `json:"pinned,omitempty"` // 置顶评论，仅在第一页返回
+	Comments   []models.Comment `json:"comments"`
+	NextCursor string           `json:"nextCursor"` // 为空表示没有更多
+}
+
+// CommentReportEntry 举报列表条目
+type CommentReportEntry struct {
+	models.CommentReport
+	NovelID        uint   `json:"novelId"`
+	ChapterID      uint   `json:"chapterId"`
+	CommentContent string `json:"commentContent"`
+	CommentUserID  uint   `json:"commentUserId"`
+}
+
+// CreateComment 发表评论或回复；回复时小说和章节从被回复的评论继承。
+// 调用方可以把状态设为待审核，待审核的评论不公开，审核通过后才计入评论数并发送回复通知
+func (s *CommentService) CreateComment(userID uint, comment *models.Comment) error {
+	comment.Content = strings.TrimSpace(comment.Content)
+	if comment.Content == "" {
+		return errors.New("comment content is required")
+	}
+	if utf8.RuneCountInString(comment.Content) > maxCommentLength {
+		return fmt.Errorf("comment exceeds %d characters", maxCommentLength)
+	}
+
+	if comment.ParentID > 0 {
+		var parent models.Comment
+		if err := s.db.First(&parent, comment.ParentID).Error; err != nil {
+			return ErrCommentNotFound
+		}
+		if err := s.checkCommentTarget(parent.NovelID, parent.ChapterID); err != nil {
+			return err
+		}
+		comment.NovelID = parent.NovelID
+		comment.ChapterID = parent.ChapterID
+		comment.ReplyToUserID = parent.UserID
+		comment.ParagraphIndex = parent.ParagraphIndex
+		comment.ParagraphHash = parent.ParagraphHash
+		comment.ParagraphText = parent.ParagraphText
+		comment.AnchorOrphaned = parent.AnchorOrphaned
+		comment.RootID = parent.RootID
+		if comment.RootID == 0 {
+			comment.RootID = parent.ID
+		}
+	} else {
+		if err := s.checkCommentTarget(comment.NovelID, comment.ChapterID); err != nil {
+			return err
+		}
+		if comment.ParagraphIndex != nil {
+			if err := s.anchorParagraph(comment); err != nil {
+				return err
+			}
+		}
+		comment.RootID = 0
+		comment.ReplyToUserID = 0
+	}
+
+	comment.UserID = userID
+	comment.LikeCount = 0
+	comment.ReplyCount = 0
+	comment.IsPinned = false
+	if comment.Status != models.CommentStatusPending {
+		comment.Status = models.CommentStatusNormal
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if comment.Status == models.CommentStatusPending {
+			return nil
+		}
+		return countComment(tx, comment, 1)
+	})
+	if err != nil {
+		return err
+	}
+
+	if comment.Status == models.CommentStatusNormal {
+		s.notifyReply(comment)
+	}
+	return nil
+}
+
+// ApprovePendingComment 公开审核通过的评论并补发回复通知
+func (s *CommentService) ApprovePendingComment(id uint) error {
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	approved := false
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Comment{}).
+			Where("id = ? AND status = ?", id, models.CommentStatusPending).
+			UpdateColumn("status", models.CommentStatusNormal)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		approved = true
+		return countComment(tx, comment, 1)
+	})
+	if err != nil {
+		return err
+	}
+	if approved {
+		s.notifyReply(comment)
+	}
+	return nil
+}
+
+// countComment 更新一级评论的回复数和小说的评论数；待审核的评论不计入，公开后才计数
+func countComment(tx *gorm.DB, comment *models.Comment, delta int) error {
+	if comment.RootID > 0 {
+		if err := tx.Model(&models.Comment{}).Unscoped().Where("id = ? AND reply_count + ? >= 0", comment.RootID, delta).
+			UpdateColumn("reply_count", gorm.Expr("reply_count + ?", delta)).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Model(&models.Novel{}).Where("id = ? AND comment_count + ? >= 0", comment.NovelID, delta).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+// RejectPendingComment 删除审核未通过的评论
+func (s *CommentService) RejectPendingComment(id uint) error {
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.Status != models.CommentStatusPending {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return deleteComment(tx, comment)
+	})
+}
+
+func (s *CommentService) notifyReply(comment *models.Comment) {
+	if comment.ReplyToUserID == 0 {
+		return
+	}
+	s.notifications.Publish(NotificationEvent{
+		Type:        models.NotificationCommentReply,
+		ActorID:     comment.UserID,
+		RecipientID: comment.ReplyToUserID,
+		NovelID:     comment.NovelID,
+		ChapterID:   comment.ChapterID,
+		CommentID:   comment.ID,
+		Content:     comment.Content,
+	})
+}
+
+// checkCommentTarget 检查被评论的小说和章节是否存在，小说不能被隐藏，章节需已发布
+func (s *CommentService) checkCommentTarget(novelID, chapterID uint) error {
+	var count int64
+	if err := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).Where("id = ?", novelID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("novel not found")
+	}
+	if chapterID == 0 {
+		return nil
+	}
+	if err := s.db.Model(&models.Chapter{}).
+		Where("id = ? AND novel_id = ? AND status = ?", chapterID, novelID, models.ChapterStatusPublished).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("chapter not found")
+	}
+	return nil
+}
+
+// DeleteComment 删除评论，评论者本人、小说作者或版主可删除；一级评论仍有回复时保留为“已删除”占位
+func (s *CommentService) DeleteComment(actor policy.Actor, id uint) error {
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != actor.UserID {
+		allowed, err := s.canModerate(actor, comment.NovelID)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrCommentForbidden
+		}
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return deleteComment(tx, comment)
+	})
+}
+
+func deleteComment(tx *gorm.DB, comment *models.Comment) error {
+	result := tx.Delete(comment)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	if comment.IsPinned {
+		if err := tx.Model(&models.Comment{}).Unscoped().Where("id = ?", comment.ID).
+			UpdateColumn("is_pinned", false).Error; err != nil {
+			return err
+		}
+	}
+	if comment.Status != models.CommentStatusPending {
+		if err := countComment(tx, comment, -1); err != nil {
+			return err
+		}
+	}
+	// 评论删除后，未处理的举报视为已处理
+	return tx.Model(&models.CommentReport{}).
+		Where("comment_id = ? AND status = ?", comment.ID, models.CommentReportPending).
+		Update("status", models.CommentReportResolved).Error
+}
+
+// CommentTarget 评论列表的查询对象
+type CommentTarget struct {
+	NovelID   uint
+	ChapterID uint // 0 表示整部小说的评论
+	Paragraph *int // 指定时只查询该段落的段评
+	Orphaned  bool // 查询章节修改后无法重新定位的段评
+}
+
+// ListComments 获取小说、章节或段落的一级评论；章节评论不包含段评
+func (s *CommentService) ListComments(viewerID uint, target CommentTarget, sort, cursor string, limit int) (*CommentPage, error) {
+	query := s.db.Unscoped().Model(&models.Comment{}).
+		Where("novel_id = ? AND chapter_id = ? AND root_id = 0 AND status = ?", target.NovelID, target.ChapterID, models.CommentStatusNormal).
+		Where("deleted_at IS NULL OR reply_count > 0")
+	switch {
+	case target.Orphaned:
+		query = query.Where("paragraph_index IS NOT NULL AND anchor_orphaned = ?", true)
+	case target.Paragraph != nil:
+		query = query.Where("paragraph_index = ? AND anchor_orphaned = ?", *target.Paragraph, false)
+	default:
+		query = query.Where("paragraph_index IS NULL")
+	}
+
+	page := &CommentPage{Comments: []models.Comment{}}
+	if cursor == "" {
+		if err := query.Session(&gorm.Session{}).Where("is_pinned = ? AND deleted_at IS NULL", true).
+			Order("updated_at desc").Find(&page.Pinned).Error; err != nil {
+			return nil, err
+		}
+	}
+	query = query.Where("is_pinned = ?", false)
+
+	if sort == CommentSortHot {
+		if cursor != "" {
+			likes, id, err := decodeCommentCursor(cursor, 2)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("like_count < ? OR (like_count = ? AND id < ?)", likes, likes, id)
+		}
+		query = query.Order("like_count desc, id desc")
+	} else {
+		if cursor != "" {
+			_, id, err := decodeCommentCursor(cursor, 1)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("id < ?", id)
+		}
+		query = query.Order("id desc")
+	}
+
+	if err := query.Limit(limit + 1).Find(&page.Comments).Error; err != nil {
+		return nil, err
+	}
+	if len(page.Comments) > limit {
+		page.Comments = page.Comments[:limit]
+		last := page.Comments[limit-1]
+		if sort == CommentSortHot {
+			page.NextCursor = encodeCommentCursor(last.LikeCount, last.ID)
+		} else {
+			page.NextCursor = encodeCommentCursor(last.ID)
+		}
+	}
+
+	if err := s.hydrateComments(viewerID, page.Pinned); err != nil {
+		return nil, err
+	}
+	if err := s.hydrateComments(viewerID, page.Comments); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListReplies 按时间顺序获取一级评论下的回复
+func (s *CommentService) ListReplies(viewerID, rootID uint, cursor string, limit int) (*CommentPage, error) {
+	query := s.db.Where("root_id = ? AND status = ?", rootID, models.CommentStatusNormal)
+	if cursor != "" {
+		_, id, err := decodeCommentCursor(cursor, 1)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("id > ?", id)
+	}
+
+	page := &CommentPage{Comments: []models.Comment{}}
+	if err := query.Order("id asc").Limit(limit + 1).Find(&page.Comments).Error; err != nil {
+		return nil, err
+	}
+	if len(page.Comments) > limit {
+		page.Comments = page.Comments[:limit]
+		page.NextCursor = encodeCommentCursor(page.Comments[limit-1].ID)
+	}
+	if err := s.hydrateComments(viewerID, page.Comments); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// hydrateComments 填充评论的用户信息和当前用户的点赞状态，并隐藏已删除评论的内容
+func (s *CommentService) hydrateComments(viewerID uint, comments []models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uint, 0, len(comments)*2)
+	commentIDs := make([]uint, 0, len(comments))
+	for _, c := range comments {
+		userIDs = append(userIDs, c.UserID)
+		if c.ReplyToUserID > 0 {
+			userIDs = append(userIDs, c.ReplyToUserID)
+		}
+		commentIDs = append(commentIDs, c.ID)
+	}
+
+	var users []models.CommentUser
+	if err := s.db.Model(&models.User{}).Select("id, username, avatar").
+		Where("id IN ?", userIDs).Scan(&users).Error; err != nil {
+		return err
+	}
+	userMap := make(map[uint]*models.CommentUser, len(users))
+	for i := range users {
+		userMap[users[i].ID] = &users[i]
+	}
+
+	liked := make(map[uint]bool)
+	if viewerID > 0 {
+		var likedIDs []uint
+		if err := s.db.Model(&models.CommentLike{}).
+			Where("user_id = ? AND comment_id IN ?", viewerID, commentIDs).
+			Pluck("comment_id", &likedIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	for i := range comments {
+		c := &comments[i]
+		if c.DeletedAt.Valid {
+			c.Deleted = true
+			c.Content = ""
+			c.UserID = 0
+			continue
+		}
+		c.Author = userMap[c.UserID]
+		c.ReplyToUser = userMap[c.ReplyToUserID]
+		c.Liked = liked[c.ID]
+	}
+	return nil
+}
+
+// LikeComment 点赞评论，重复点赞不会重复计数
+func (s *CommentService) LikeComment(userID, id uint) (int, error) {
+	if _, err := s.getComment(id); err != nil {
+		return 0, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.CommentLike{CommentID: id, UserID: userID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", id).
+			UpdateColumn("like_count", gorm.Expr("like_count + 1")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.likeCount(id)
+}
+
+// UnlikeComment 取消点赞
+func (s *CommentService) UnlikeComment(userID, id uint) (int, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("comment_id = ? AND user_id = ?", id, userID).Delete(&models.CommentLike{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ? AND like_count > 0", id).
+			UpdateColumn("like_count", gorm.Expr("like_count - 1")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.likeCount(id)
+}
+
+func (s *CommentService) likeCount(id uint) (int, error) {
+	var count int
+	err := s.db.Model(&models.Comment{}).Where("id = ?", id).Select("like_count").Scan(&count).Error
+	return count, err
+}
+
+// PinComment 作者或版主置顶、取消置顶一级评论
+func (s *CommentService) PinComment(actor policy.Actor, id uint, pinned bool) error {
+	comment, err := s.getComment(id)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.canModerate(actor, comment.NovelID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrCommentForbidden
+	}
+	if comment.RootID != 0 {
+		return errors.New("only top-level comments can be pinned")
+	}
+
+	if pinned && !comment.IsPinned {
+		var count int64
+		if err := s.db.Model(&models.Comment{}).
+			Where("novel_id = ? AND chapter_id = ? AND is_pinned = ?", comment.NovelID, comment.ChapterID, true).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxPinnedComments {
+			return ErrTooManyPinned
+		}
+	}
+	return s.db.Model(comment).Update("is_pinned", pinned).Error
+}
+
+// ReportComment 举报评论，待处理举报过多时自动隐藏
+func (s *CommentService) ReportComment(userID, id uint, reason string) error {
+	if _, err := s.getComment(id); err != nil {
+		return err
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CommentReport{
+			CommentID: id,
+			UserID:    userID,
+			Reason:    strings.TrimSpace(reason),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCommentReportExist
+		}
+		if err := tx.Model(&models.Comment{}).Where("id = ?", id).
+			UpdateColumn("report_count", gorm.Expr("report_count + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Comment{}).Where("id = ? AND report_count >= ?", id, commentReportThreshold).
+			UpdateColumn("status", models.CommentStatusHidden).Error
+	})
+}
+
+// ListCommentReports 获取作者小说下待处理的评论举报
+func (s *CommentService) ListCommentReports(authorID uint, page, limit int) ([]CommentReportEntry, int64, error) {
+	query := s.reportQuery().
+		Where("novels.author_id = ? AND comment_reports.status = ?", authorID, models.CommentReportPending)
+	return s.scanReports(query, page, limit)
+}
+
+// ListAllCommentReports 获取全站的评论举报，供管理后台使用；status 为 -1 时不按状态筛选。
+// 已处理的举报对应的评论通常已被删除，因此这里包含已删除的评论
+func (s *CommentService) ListAllCommentReports(status, page, limit int) ([]CommentReportEntry, int64, error) {
+	query := s.db.Table("comment_reports").
+		Joins("JOIN comments ON comments.id = comment_reports.comment_id")
+	if status != -1 {
+		query = query.Where("comment_reports.status = ?", status)
+	}
+	return s.scanReports(query, page, limit)
+}
+
+func (s *CommentService) reportQuery() *gorm.DB {
+	return s.db.Table("comment_reports").
+		Joins("JOIN comments ON comments.id = comment_reports.comment_id AND comments.deleted_at IS NULL").
+		Joins("JOIN novels ON novels.id = comments.novel_id AND novels.deleted_at IS NULL")
+}
+
+func (s *CommentService) scanReports(query *gorm.DB, page, limit int) ([]CommentReportEntry, int64, error) {
+	var entries []CommentReportEntry
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Select(`comment_reports.*, comments.novel_id, comments.chapter_id,
+			comments.content AS comment_content, comments.user_id AS comment_user_id`).
+		Order("comment_reports.created_at asc").
+		Offset(offset).Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// ResolveCommentReport 作者或版主处理举报：remove 删除评论，reject 驳回该评论的全部举报并恢复显示
+func (s *CommentService) ResolveCommentReport(actor policy.Actor, reportID uint, action string) error {
+	var report models.CommentReport
+	if err := s.db.First(&report, reportID).Error; err != nil {
+		return errors.New("report not found")
+	}
+	comment, err := s.getComment(report.CommentID)
+	if err != nil {
+		return err
+	}
+	allowed, err := s.canModerate(actor, comment.NovelID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrCommentForbidden
+	}
+
+	switch action {
+	case "remove":
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			return deleteComment(tx, comment)
+		})
+	case "reject":
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.CommentReport{}).
+				Where("comment_id = ? AND status = ?", comment.ID, models.CommentReportPending).
+				Update("status", models.CommentReportRejected).Error; err != nil {
+				return err
+			}
+			updates := map[string]interface{}{"report_count": 0}
+			// 只恢复因举报被隐藏的评论，待审核的评论仍需等待审核
+			if comment.Status == models.CommentStatusHidden {
+				updates["status"] = models.CommentStatusNormal
+			}
+			return tx.Model(comment).Updates(updates).Error
+		})
+	default:
+		return fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+func (s *CommentService) getComment(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := s.db.First(&comment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// canModerate 小说作者和有评论管理权限的用户可以管理小说下的评论
+func (s *CommentService) canModerate(actor policy.Actor, novelID uint) (bool, error) {
+	if actor.Can(policy.PermCommentModerate) {
+		return true, nil
+	}
+	return s.isNovelAuthor(novelID, actor.UserID)
+}
+
+func (s *CommentService) isNovelAuthor(novelID, userID uint) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.Novel{}).Where("id = ? AND author_id = ?", novelID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// encodeCommentCursor 将排序键编码为不透明的游标
+func encodeCommentCursor(keys ...interface{}) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprint(k)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, ":")))
+}
+
+// decodeCommentCursor 解析游标，n 为 2 时返回 (点赞数, ID)，为 1 时仅返回 ID
+func decodeCommentCursor(cursor string, n int) (int, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	parts := strings.Split(string(data), ":")
+	if len(parts) != n {
+		return 0, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseUint(parts[n-1], 10, 32)
+	if err != nil {
+		return 0, 0, ErrInvalidCursor
+	}
+	likes := 0
+	if n == 2 {
+		if likes, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, 0, ErrInvalidCursor
+		}
+	}
+	return likes, uint(id), nil
+}
@@ -0,0 +1,461 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/simhash"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	duplicateQueueSize        = 256
+	duplicateMaxDistance      = simhash.BandCount - 1 // 不超过该海明距离视为近似重复，保证至少一段指纹相同
+	duplicateMinShingles      = 200                   // 特征太少的短章节不计算指纹，避免误报
+	duplicateBackfillBatch    = 200
+	duplicatePassageMinLength = 10        // 参与比对的段落最少字数，过短的段落（如对话“嗯。”）容易重复
+	duplicatePassageMinScore  = 0.8       // 段落相似度达到该值视为相同段落
+	duplicateMaxPassages      = 200       // 证据中最多列出的段落数
+	duplicateBackfillEvery    = time.Hour // 定期补算期间修改过但未能入队的章节
+)
+
+var (
+	ErrDuplicateNotFound = errors.New("duplicate flag not found")
+	ErrDuplicateReviewed = errors.New("duplicate flag already reviewed")
+)
+
+// DuplicateEntry 疑似重复列表条目，附带双方章节和小说的标题
+type DuplicateEntry struct {
+	models.DuplicateFlag
+	ChapterTitle      string `json:"chapterTitle"`
+	NovelTitle        string `json:"novelTitle"`
+	MatchChapterTitle string `json:"matchChapterTitle"`
+	MatchNovelTitle   string `json:"matchNovelTitle"`
+}
+
+// DuplicateSide 对比中的一方章节
+type DuplicateSide struct {
+	ChapterID    uint      `json:"chapterId"`
+	ChapterTitle string    `json:"chapterTitle"`
+	NovelID      uint      `json:"novelId"`
+	NovelTitle   string    `json:"novelTitle"`
+	AuthorID     uint      `json:"authorId"`
+	AuthorName   string    `json:"authorName"`
+	WordCount    int       `json:"wordCount"`
+	Status       int       `json:"status"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Deleted      bool      `json:"deleted"` // 章节已被删除，无法对比正文
+}
+
+// DuplicatePassage 双方相同或高度相似的段落，段落序号与 SplitParagraphs 一致
+type DuplicatePassage struct {
+	Paragraph      int     `json:"paragraph"`
+	MatchParagraph int     `json:"matchParagraph"`
+	Text           string  `json:"text"`
+	MatchText      string  `json:"matchText"`
+	Similarity     float64 `json:"similarity"`
+}
+
+// DuplicateEvidence 供审核人员逐段对照的证据
+type DuplicateEvidence struct {
+	Flag     models.DuplicateFlag `json:"flag"`
+	Chapter  DuplicateSide        `json:"chapter"`
+	Match    DuplicateSide        `json:"match"`
+	Passages []DuplicatePassage   `json:"passages"`
+	Coverage float64              `json:"coverage"` // 疑似章节中与对方相同的段落按字数计算的占比
+}
+
+// DuplicateService 章节近似重复检测：章节保存后异步计算 SimHash 指纹，
+// 查找其他作者指纹相近的章节并标记给审核人员
+type DuplicateService struct {
+	db    *gorm.DB
+	tasks chan uint
+}
+
+func NewDuplicateService(db *gorm.DB) *DuplicateService {
+	return &DuplicateService{db: db, tasks: make(chan uint, duplicateQueueSize)}
+}
+
+// Start 启动两个后台任务，ctx 结束时退出：一个持续处理章节变更；
+// 另一个启动时为没有指纹或指纹过期的章节补算，之后每隔 duplicateBackfillEvery 补算期间修改过的章节，
+// 队列已满时丢弃的变更由补算恢复
+func (s *DuplicateService) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case chapterID := <-s.tasks:
+				s.apply(chapterID)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(duplicateBackfillEvery)
+		defer ticker.Stop()
+		var since time.Time
+		for {
+			started := time.Now()
+			if n, err := s.backfill(ctx, since); err != nil {
+				log.Printf("Failed to backfill chapter fingerprints: %v", err)
+			} else {
+				if n > 0 {
+					log.Printf("Fingerprinted %d chapters in %v", n, time.Since(started))
+				}
+				// 下次只检查本次开始之后修改过的章节
+				since = started
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ChapterChanged 章节新增、修改正文或删除后调用，异步更新指纹并查找重复
+func (s *DuplicateService) ChapterChanged(chapterID uint) {
+	if s == nil {
+		return
+	}
+	// 不阻塞保存章节的请求，队列已满时丢弃，由定期补算恢复
+	select {
+	case s.tasks <- chapterID:
+	default:
+		log.Printf("Duplicate queue full, dropped chapter %d", chapterID)
+	}
+}
+
+func (s *DuplicateService) apply(chapterID uint) {
+	if err := s.syncChapter(chapterID); err != nil {
+		log.Printf("Failed to check duplicates of chapter %d: %v", chapterID, err)
+	}
+}
+
+// backfill 分批重新计算没有指纹或指纹早于章节最后修改时间的章节，并清理已删除章节的指纹，返回处理的章节数。
+// since 不为零时只检查此后修改过的章节；过短的章节不保存指纹，只在修改后重新检查
+func (s *DuplicateService) backfill(ctx context.Context, since time.Time) (int, error) {
+	count := 0
+	var lastID uint
+	for ctx.Err() == nil {
+		query := s.db.Model(&models.Chapter{}).
+			Joins("LEFT JOIN chapter_fingerprints ON chapter_fingerprints.chapter_id = chapters.id").
+			Where("chapters.id > ?", lastID).
+			Where("chapter_fingerprints.chapter_id IS NULL OR chapter_fingerprints.updated_at < chapters.updated_at")
+		if !since.IsZero() {
+			query = query.Where("chapters.updated_at >= ?", since)
+		}
+		var ids []uint
+		if err := query.Order("chapters.id asc").Limit(duplicateBackfillBatch).
+			Pluck("chapters.id", &ids).Error; err != nil {
+			return count, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			s.apply(id)
+		}
+		count += len(ids)
+		lastID = ids[len(ids)-1]
+	}
+
+	var orphans []uint
+	if err := s.db.Model(&models.ChapterFingerprint{}).
+		Joins("LEFT JOIN chapters ON chapters.id = chapter_fingerprints.chapter_id").
+		Where("chapters.id IS NULL").
+		Pluck("chapter_fingerprints.chapter_id", &orphans).Error; err != nil {
+		return count, err
+	}
+	for _, id := range orphans {
+		if err := s.removeChapter(id); err != nil {
+			log.Printf("Failed to remove fingerprint of deleted chapter %d: %v", id, err)
+		}
+	}
+	return count + len(orphans), nil
+}
+
+// syncChapter 重新计算章节指纹，标记新发现的重复，并清理修改后已不再相似的待处理标记
+func (s *DuplicateService) syncChapter(chapterID uint) error {
+	var chapter models.Chapter
+	err := s.db.Select("id", "novel_id", "content").First(&chapter, chapterID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.removeChapter(chapterID)
+	}
+	if err != nil {
+		return err
+	}
+	var novel models.Novel
+	err = s.db.Select("id", "author_id").First(&novel, chapter.NovelID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.removeChapter(chapterID)
+	}
+	if err != nil {
+		return err
+	}
+
+	fp := simhash.Compute(strings.Join(SplitParagraphs(chapter.Content), "\n"))
+	if fp.Shingles < duplicateMinShingles {
+		return s.removeChapter(chapterID)
+	}
+	bands := simhash.Bands(fp.Hash)
+	record := models.ChapterFingerprint{
+		ChapterID: chapter.ID,
+		NovelID:   chapter.NovelID,
+		AuthorID:  novel.AuthorID,
+		SimHash:   fp.Hash,
+		Band0:     bands[0],
+		Band1:     bands[1],
+		Band2:     bands[2],
+		Band3:     bands[3],
+		Shingles:  fp.Shingles,
+	}
+	if err := s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&record).Error; err != nil {
+		return err
+	}
+
+	// 海明距离不超过段数减一时至少有一段相同，只需比较至少一段相同的候选
+	var candidates []models.ChapterFingerprint
+	if err := s.db.Where("author_id <> ?", novel.AuthorID).
+		Where("(band0 = ? OR band1 = ? OR band2 = ? OR band3 = ?)", bands[0], bands[1], bands[2], bands[3]).
+		Find(&candidates).Error; err != nil {
+		return err
+	}
+
+	matched := make(map[uint]bool)
+	for _, c := range candidates {
+		distance := simhash.Distance(fp.Hash, c.SimHash)
+		if distance > duplicateMaxDistance {
+			continue
+		}
+		matched[c.ChapterID] = true
+		if err := s.flag(record, c, distance); err != nil {
+			return err
+		}
+	}
+	return s.clearStaleFlags(chapterID, matched)
+}
+
+// flag 记录一对近似重复的章节，先创建的章节作为原文；已存在的标记只更新相似度，不改变处理状态
+func (s *DuplicateService) flag(a, b models.ChapterFingerprint, distance int) error {
+	if a.ChapterID < b.ChapterID {
+		a, b = b, a
+	}
+	flag := models.DuplicateFlag{
+		ChapterID:      a.ChapterID,
+		MatchChapterID: b.ChapterID,
+		NovelID:        a.NovelID,
+		MatchNovelID:   b.NovelID,
+		AuthorID:       a.AuthorID,
+		MatchAuthorID:  b.AuthorID,
+		Distance:       distance,
+		Similarity:     simhash.Similarity(a.SimHash, b.SimHash),
+		Status:         models.DuplicatePending,
+	}
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chapter_id"}, {Name: "match_chapter_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"distance", "similarity", "updated_at"}),
+	}).Create(&flag).Error
+}
+
+// clearStaleFlags 删除章节修改后已不再相似的待处理标记，已处理的标记保留作为记录
+func (s *DuplicateService) clearStaleFlags(chapterID uint, matched map[uint]bool) error {
+	var flags []models.DuplicateFlag
+	if err := s.db.Where("status = ? AND (chapter_id = ? OR match_chapter_id = ?)", models.DuplicatePending, chapterID, chapterID).
+		Find(&flags).Error; err != nil {
+		return err
+	}
+	var stale []uint
+	for _, f := range flags {
+		other := f.MatchChapterID
+		if other == chapterID {
+			other = f.ChapterID
+		}
+		if !matched[other] {
+			stale = append(stale, f.ID)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return s.db.Delete(&models.DuplicateFlag{}, stale).Error
+}
+
+// removeChapter 章节被删除或正文过短时删除指纹和相关的待处理标记
+func (s *DuplicateService) removeChapter(chapterID uint) error {
+	if err := s.db.Delete(&models.ChapterFingerprint{}, chapterID).Error; err != nil {
+		return err
+	}
+	return s.clearStaleFlags(chapterID, nil)
+}
+
+// List 疑似重复列表，status 为 -1 时不按状态筛选，novelID 不为 0 时只返回涉及该小说的记录；相似度高的在前
+func (s *DuplicateService) List(status int, novelID uint, page, pageSize int) ([]DuplicateEntry, int64, error) {
+	query := s.db.Table("duplicate_flags")
+	if status != -1 {
+		query = query.Where("duplicate_flags.status = ?", status)
+	}
+	if novelID != 0 {
+		query = query.Where("(duplicate_flags.novel_id = ? OR duplicate_flags.match_novel_id = ?)", novelID, novelID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []DuplicateEntry
+	if err := query.Select(`duplicate_flags.*, c.title AS chapter_title, n.title AS novel_title,
+			mc.title AS match_chapter_title, mn.title AS match_novel_title`).
+		Joins("LEFT JOIN chapters c ON c.id = duplicate_flags.chapter_id").
+		Joins("LEFT JOIN novels n ON n.id = duplicate_flags.novel_id").
+		Joins("LEFT JOIN chapters mc ON mc.id = duplicate_flags.match_chapter_id").
+		Joins("LEFT JOIN novels mn ON mn.id = duplicate_flags.match_novel_id").
+		Order("duplicate_flags.similarity desc, duplicate_flags.id desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Scan(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// GetEvidence 读取双方章节并逐段比对，列出相同或高度相似的段落
+func (s *DuplicateService) GetEvidence(id uint) (*DuplicateEvidence, error) {
+	flag, err := s.getFlag(id)
+	if err != nil {
+		return nil, err
+	}
+	evidence := &DuplicateEvidence{Flag: *flag, Passages: []DuplicatePassage{}}
+	var paragraphs, matchParagraphs []string
+	if evidence.Chapter, paragraphs, err = s.loadSide(flag.ChapterID); err != nil {
+		return nil, err
+	}
+	if evidence.Match, matchParagraphs, err = s.loadSide(flag.MatchChapterID); err != nil {
+		return nil, err
+	}
+	evidence.Passages, evidence.Coverage = matchPassages(paragraphs, matchParagraphs)
+	return evidence, nil
+}
+
+func (s *DuplicateService) loadSide(chapterID uint) (DuplicateSide, []string, error) {
+	side := DuplicateSide{ChapterID: chapterID}
+	var chapter models.Chapter
+	err := s.db.First(&chapter, chapterID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		side.Deleted = true
+		return side, nil, nil
+	}
+	if err != nil {
+		return side, nil, err
+	}
+	side.ChapterTitle = chapter.Title
+	side.NovelID = chapter.NovelID
+	side.WordCount = chapter.WordCount
+	side.Status = chapter.Status
+	side.CreatedAt = chapter.CreatedAt
+
+	// 小说可能已被删除，仍展示标题便于追溯
+	var novel models.Novel
+	if err := s.db.Unscoped().Omit("novel_outline").Preload("Author").First(&novel, chapter.NovelID).Error; err == nil {
+		side.NovelTitle = novel.Title
+		side.AuthorID = novel.AuthorID
+		side.AuthorName = novel.Author.Username
+	}
+	return side, SplitParagraphs(chapter.Content), nil
+}
+
+// matchPassages 为疑似章节的每个段落在对方章节中找相同或最相似的段落，返回匹配的段落和按字数计算的覆盖率
+func matchPassages(paragraphs, matchParagraphs []string) ([]DuplicatePassage, float64) {
+	normalized := make([]string, len(matchParagraphs))
+	exact := make(map[string]int, len(matchParagraphs))
+	for j, p := range matchParagraphs {
+		normalized[j] = normalizeParagraph(p)
+		if _, ok := exact[normalized[j]]; !ok {
+			exact[normalized[j]] = j
+		}
+	}
+
+	passages := []DuplicatePassage{}
+	total, covered := 0, 0
+	for i, p := range paragraphs {
+		text := normalizeParagraph(p)
+		length := utf8.RuneCountInString(text)
+		total += length
+		if length < duplicatePassageMinLength {
+			continue
+		}
+
+		best, bestScore := -1, 0.0
+		if j, ok := exact[text]; ok {
+			best, bestScore = j, 1
+		} else {
+			for j, other := range normalized {
+				if score := bigramSimilarity(text, other); score > bestScore {
+					best, bestScore = j, score
+				}
+			}
+		}
+		if best < 0 || bestScore < duplicatePassageMinScore {
+			continue
+		}
+		covered += length
+		if len(passages) < duplicateMaxPassages {
+			passages = append(passages, DuplicatePassage{
+				Paragraph:      i,
+				MatchParagraph: best,
+				Text:           p,
+				MatchText:      matchParagraphs[best],
+				Similarity:     bestScore,
+			})
+		}
+	}
+	if total == 0 {
+		return passages, 0
+	}
+	return passages, float64(covered) / float64(total)
+}
+
+// Review 确认或驳回疑似重复，已处理过时返回 ErrDuplicateReviewed
+func (s *DuplicateService) Review(id, reviewerID uint, status int, note string) (*models.DuplicateFlag, error) {
+	flag, err := s.getFlag(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	note = truncateRunes(note, 254)
+	result := s.db.Model(flag).Where("status = ?", models.DuplicatePending).Updates(map[string]interface{}{
+		"status":      status,
+		"note":        note,
+		"reviewer_id": reviewerID,
+		"reviewed_at": &now,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDuplicateReviewed
+	}
+	flag.Status = status
+	flag.Note = note
+	flag.ReviewerID = reviewerID
+	flag.ReviewedAt = &now
+	return flag, nil
+}
+
+func (s *DuplicateService) getFlag(id uint) (*models.DuplicateFlag, error) {
+	var flag models.DuplicateFlag
+	if err := s.db.First(&flag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDuplicateNotFound
+		}
+		return nil, err
+	}
+	return &flag, nil
+}
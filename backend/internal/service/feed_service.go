@@ -0,0 +1,195 @@
+package service
+
+import (
+	"ai-novel-platform/internal/feed"
+	"ai-novel-platform/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	feedEntryLimit    = 20
+	feedExcerptLength = 200
+)
+
+var ErrFeedNotFound = errors.New("feed not found")
+
+type FeedService struct {
+	db      *gorm.DB
+	siteURL string // 前台站点地址，用于生成条目链接
+}
+
+func NewFeedService(db *gorm.DB, siteURL string) *FeedService {
+	return &FeedService{db: db, siteURL: strings.TrimRight(siteURL, "/")}
+}
+
+// feedChapter 订阅源中的章节
+type feedChapter struct {
+	ID         uint
+	NovelID    uint
+	NovelTitle string
+	Title      string
+	Content    string
+	Order      int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// FeedVersion 订阅源的版本，根据最后修改时间和章节数计算，不需要读取章节正文，
+// 用于在生成 feed 前处理条件请求
+type FeedVersion struct {
+	Updated time.Time
+	ETag    string
+}
+
+// NovelFeedVersion 小说订阅源的版本
+func (s *FeedService) NovelFeedVersion(novelID uint) (*FeedVersion, error) {
+	var novel models.Novel
+	if err := s.db.Select("id", "updated_at").Scopes(models.VisibleNovels).First(&novel, novelID).Error; err != nil {
+		return nil, ErrFeedNotFound
+	}
+	return s.feedVersion(s.db.Where("chapters.novel_id = ?", novelID), novel.UpdatedAt)
+}
+
+// AuthorFeedVersion 作者订阅源的版本，作品标题出现在条目中，也计入版本
+func (s *FeedService) AuthorFeedVersion(authorID uint) (*FeedVersion, error) {
+	var author models.User
+	if err := s.db.Select("id", "updated_at").First(&author, authorID).Error; err != nil {
+		return nil, ErrFeedNotFound
+	}
+	var novels struct{ Updated *time.Time }
+	if err := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Where("author_id = ?", authorID).
+		Select("MAX(updated_at) AS updated").Scan(&novels).Error; err != nil {
+		return nil, err
+	}
+	updated := author.UpdatedAt
+	if novels.Updated != nil && novels.Updated.After(updated) {
+		updated = *novels.Updated
+	}
+	return s.feedVersion(s.db.Where("novels.author_id = ?", authorID), updated)
+}
+
+// feedVersion 汇总订阅源中章节的最后修改时间和数量；章节被删除或撤回时数量变化，ETag 随之变化
+func (s *FeedService) feedVersion(scope *gorm.DB, updated time.Time) (*FeedVersion, error) {
+	var row struct {
+		Updated *time.Time
+		Count   int64
+	}
+	if err := s.publishedScope(scope).
+		Select("MAX(chapters.updated_at) AS updated, COUNT(*) AS count").
+		Scan(&row).Error; err != nil {
+		return nil, err
+	}
+	if row.Updated != nil && row.Updated.After(updated) {
+		updated = *row.Updated
+	}
+	updated = updated.UTC().Truncate(time.Second)
+	return &FeedVersion{
+		Updated: updated,
+		ETag:    fmt.Sprintf(`W/"%x-%x"`, updated.Unix(), row.Count),
+	}, nil
+}
+
+// NovelFeed 生成小说最近发布章节的 Atom feed
+func (s *FeedService) NovelFeed(novelID uint, selfURL string) (*feed.Feed, error) {
+	var novel models.Novel
+	if err := s.db.Preload("Author").Scopes(models.VisibleNovels).First(&novel, novelID).Error; err != nil {
+		return nil, ErrFeedNotFound
+	}
+
+	chapters, err := s.publishedChapters(s.db.Where("chapters.novel_id = ?", novelID))
+	if err != nil {
+		return nil, err
+	}
+
+	novelURL := fmt.Sprintf("%s/novels/%d", s.siteURL, novel.ID)
+	f := &feed.Feed{
+		ID:       novelURL,
+		Title:    novel.Title,
+		Subtitle: novel.Description,
+		Author:   &feed.Person{Name: novel.Author.Username},
+		Icon:     novel.CoverURL,
+		Links: []feed.Link{
+			{Rel: "self", Href: selfURL, Type: feed.AtomMIME},
+			{Rel: "alternate", Href: novelURL, Type: "text/html"},
+		},
+	}
+	s.fillEntries(f, chapters, novel.UpdatedAt)
+	return f, nil
+}
+
+// AuthorFeed 生成作者所有作品最近发布章节的 Atom feed
+func (s *FeedService) AuthorFeed(authorID uint, selfURL string) (*feed.Feed, error) {
+	var author models.User
+	if err := s.db.Select("id", "username", "created_at").First(&author, authorID).Error; err != nil {
+		return nil, ErrFeedNotFound
+	}
+
+	chapters, err := s.publishedChapters(s.db.Where("novels.author_id = ?", authorID))
+	if err != nil {
+		return nil, err
+	}
+
+	authorURL := fmt.Sprintf("%s/authors/%d", s.siteURL, author.ID)
+	f := &feed.Feed{
+		ID:     authorURL,
+		Title:  fmt.Sprintf("%s 的作品更新", author.Username),
+		Author: &feed.Person{Name: author.Username},
+		Links: []feed.Link{
+			{Rel: "self", Href: selfURL, Type: feed.AtomMIME},
+			{Rel: "alternate", Href: authorURL, Type: "text/html"},
+		},
+	}
+	s.fillEntries(f, chapters, author.CreatedAt)
+	return f, nil
+}
+
+// publishedScope 可见小说中已发布的章节；草稿、待审核和被隐藏的章节不会出现在订阅源中
+func (s *FeedService) publishedScope(scope *gorm.DB) *gorm.DB {
+	return s.db.Table("chapters").
+		Joins("JOIN novels ON novels.id = chapters.novel_id AND novels.deleted_at IS NULL AND novels.hidden_at IS NULL").
+		Where(scope).
+		Where("chapters.status = ?", models.ChapterStatusPublished)
+}
+
+// publishedChapters 查询最近更新的已发布章节
+func (s *FeedService) publishedChapters(scope *gorm.DB) ([]feedChapter, error) {
+	var chapters []feedChapter
+	err := s.publishedScope(scope).
+		Select("chapters.id, chapters.novel_id, novels.title AS novel_title, chapters.title, chapters.content, chapters.`order`, chapters.created_at, chapters.updated_at").
+		Order("chapters.updated_at desc, chapters.id desc").
+		Limit(feedEntryLimit).
+		Scan(&chapters).Error
+	return chapters, err
+}
+
+// fillEntries 填充条目并设置 feed 的更新时间；没有章节时使用 fallback
+func (s *FeedService) fillEntries(f *feed.Feed, chapters []feedChapter, fallback time.Time) {
+	updated := fallback
+	for _, c := range chapters {
+		if c.UpdatedAt.After(updated) {
+			updated = c.UpdatedAt
+		}
+		chapterURL := fmt.Sprintf("%s/novels/%d/chapter/%d", s.siteURL, c.NovelID, c.ID)
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:         chapterURL,
+			Title:      fmt.Sprintf("%s 第%d章 %s", c.NovelTitle, c.Order, c.Title),
+			Updated:    feed.FormatTime(c.UpdatedAt),
+			Published:  feed.FormatTime(c.CreatedAt),
+			Categories: []feed.Category{{Term: c.NovelTitle}},
+			Summary:    &feed.Text{Type: "text", Body: chapterExcerpt(c.Content)},
+			Links:      []feed.Link{{Rel: "alternate", Href: chapterURL, Type: "text/html"}},
+		})
+	}
+	f.Updated = feed.FormatTime(updated)
+}
+
+// chapterExcerpt 截取章节开头作为摘要
+func chapterExcerpt(content string) string {
+	return truncateRunes(strings.Join(SplitParagraphs(content), "\n"), feedExcerptLength)
+}
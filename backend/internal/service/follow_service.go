@@ -0,0 +1,122 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrFollowSelf = errors.New("cannot follow yourself")
+
+type FollowService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewFollowService(db *gorm.DB, notifications *NotificationService) *FollowService {
+	return &FollowService{db: db, notifications: notifications}
+}
+
+// FollowUser 用户信息（关注列表条目）
+type FollowUser struct {
+	ID         uint      `json:"id"`
+	Username   string    `json:"username"`
+	Avatar     string    `json:"avatar"`
+	Bio        string    `json:"bio"`
+	FollowedAt time.Time `json:"followedAt"`
+}
+
+// FollowStats 关注数据
+type FollowStats struct {
+	Followers int64 `json:"followers"`
+	Following int64 `json:"following"`
+	Followed  bool  `json:"followed"` // 当前用户是否已关注
+}
+
+// Follow 关注作者，重复关注不报错
+func (s *FollowService) Follow(followerID, followeeID uint) error {
+	if followerID == followeeID {
+		return ErrFollowSelf
+	}
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("id = ?", followeeID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("user not found")
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.Follow{FollowerID: followerID, FolloweeID: followeeID})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.notifications.Publish(NotificationEvent{
+			Type:        models.NotificationNewFollower,
+			ActorID:     followerID,
+			RecipientID: followeeID,
+		})
+	}
+	return nil
+}
+
+// Unfollow 取消关注
+func (s *FollowService) Unfollow(followerID, followeeID uint) error {
+	return s.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.Follow{}).Error
+}
+
+// GetFollowStats 获取用户的关注数据
+func (s *FollowService) GetFollowStats(viewerID, userID uint) (*FollowStats, error) {
+	stats := &FollowStats{}
+	if err := s.db.Model(&models.Follow{}).Where("followee_id = ?", userID).Count(&stats.Followers).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.Follow{}).Where("follower_id = ?", userID).Count(&stats.Following).Error; err != nil {
+		return nil, err
+	}
+	if viewerID > 0 && viewerID != userID {
+		var count int64
+		if err := s.db.Model(&models.Follow{}).
+			Where("follower_id = ? AND followee_id = ?", viewerID, userID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		stats.Followed = count > 0
+	}
+	return stats, nil
+}
+
+// ListFollowers 获取关注该用户的人
+func (s *FollowService) ListFollowers(userID uint, page, limit int) ([]FollowUser, int64, error) {
+	return s.listFollows("follows.followee_id = ?", "follows.follower_id", userID, page, limit)
+}
+
+// ListFollowing 获取该用户关注的人
+func (s *FollowService) ListFollowing(userID uint, page, limit int) ([]FollowUser, int64, error) {
+	return s.listFollows("follows.follower_id = ?", "follows.followee_id", userID, page, limit)
+}
+
+func (s *FollowService) listFollows(where, joinColumn string, userID uint, page, limit int) ([]FollowUser, int64, error) {
+	var users []FollowUser
+	var total int64
+
+	query := s.db.Table("follows").Where(where, userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Joins("JOIN users ON users.id = " + joinColumn).
+		Select("users.id, users.username, users.avatar, users.bio, follows.created_at AS followed_at").
+		Order("follows.id desc").
+		Offset(offset).Limit(limit).
+		Scan(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
@@ -0,0 +1,326 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/moderation"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// wordListCheckEvery 检查词库文件是否修改的间隔
+const wordListCheckEvery = 30 * time.Second
+
+// excerptRadius 审核摘录中命中位置前后保留的字数
+const excerptRadius = 40
+
+// 因内容审核被隐藏的小说使用的隐藏原因
+const (
+	ModerationHiddenReason   = "内容审核中"
+	moderationRejectedPrefix = "审核未通过："
+)
+
+var (
+	ErrContentBlocked         = errors.New("content contains prohibited words")
+	ErrModerationItemNotFound = errors.New("moderation item not found")
+	ErrModerationItemReviewed = errors.New("moderation item already reviewed")
+)
+
+// moderationPolicies 各类内容命中敏感词后的处理方式。用户名不能打码也不值得排队审核，一律拒绝；
+// 章节篇幅长，拒绝会让作者无从修改，严重的词也转人工审核
+var moderationPolicies = map[string]moderation.Policy{
+	models.ModerationContentUsername: {
+		moderation.SeverityLow:    moderation.ActionBlock,
+		moderation.SeverityMedium: moderation.ActionBlock,
+		moderation.SeverityHigh:   moderation.ActionBlock,
+	},
+	models.ModerationContentComment: {
+		moderation.SeverityLow:    moderation.ActionMask,
+		moderation.SeverityMedium: moderation.ActionReview,
+		moderation.SeverityHigh:   moderation.ActionBlock,
+	},
+	models.ModerationContentNovel: {
+		moderation.SeverityLow:    moderation.ActionMask,
+		moderation.SeverityMedium: moderation.ActionReview,
+		moderation.SeverityHigh:   moderation.ActionBlock,
+	},
+	models.ModerationContentChapter: {
+		moderation.SeverityLow:    moderation.ActionMask,
+		moderation.SeverityMedium: moderation.ActionReview,
+		moderation.SeverityHigh:   moderation.ActionReview,
+	},
+}
+
+// ScreenResult 内容检测结果
+type ScreenResult struct {
+	Action  moderation.Action
+	Hits    []moderation.Hit
+	Excerpt string // 第一处需要审核或拒绝的命中附近的原文
+}
+
+// NeedsReview 内容需要人工审核后才能公开
+func (r ScreenResult) NeedsReview() bool {
+	return r.Action == moderation.ActionReview
+}
+
+// Err 内容被拒绝时返回包含命中词的错误
+func (r ScreenResult) Err() error {
+	if r.Action != moderation.ActionBlock {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrContentBlocked, strings.Join(r.words(), ", "))
+}
+
+func (r ScreenResult) words() []string {
+	var words []string
+	seen := make(map[string]bool)
+	for _, h := range r.Hits {
+		if !seen[h.Word] {
+			seen[h.Word] = true
+			words = append(words, h.Word)
+		}
+	}
+	return words
+}
+
+func (r ScreenResult) categories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, h := range r.Hits {
+		if !seen[h.Category] {
+			seen[h.Category] = true
+			categories = append(categories, h.Category)
+		}
+	}
+	return categories
+}
+
+// ModerationService 敏感词检测和人工审核队列
+type ModerationService struct {
+	db       *gorm.DB
+	words    *moderation.WordList
+	chapters *ChapterService
+	comments *CommentService
+	admin    *AdminService
+}
+
+func NewModerationService(db *gorm.DB, words *moderation.WordList, chapters *ChapterService, comments *CommentService, admin *AdminService) *ModerationService {
+	return &ModerationService{db: db, words: words, chapters: chapters, comments: comments, admin: admin}
+}
+
+// Start 加载词库，并定期检查词库文件，修改后自动重新加载
+func (s *ModerationService) Start(ctx context.Context) {
+	if n, err := s.words.Reload(); err != nil {
+		log.Printf("Failed to load sensitive words: %v", err)
+	} else {
+		log.Printf("Loaded %d sensitive words", n)
+	}
+	go func() {
+		ticker := time.NewTicker(wordListCheckEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reloaded, err := s.words.ReloadIfChanged()
+				if err != nil {
+					log.Printf("Failed to reload sensitive words: %v", err)
+				} else if reloaded {
+					log.Printf("Reloaded %d sensitive words", s.words.Matcher().Len())
+				}
+			}
+		}
+	}()
+}
+
+// ReloadWords 立即重新加载词库，返回词数
+func (s *ModerationService) ReloadWords() (int, error) {
+	return s.words.Reload()
+}
+
+// Screen 按内容类型的策略检测文本，需要打码的词直接在传入的文本上替换；
+// 多段文本（如标题和简介）按最严格的结果处理
+func (s *ModerationService) Screen(contentType string, texts ...*string) ScreenResult {
+	policy := moderationPolicies[contentType]
+	result := ScreenResult{Action: moderation.ActionPass}
+	for _, text := range texts {
+		verdict := moderation.Check(s.words, policy, *text)
+		for _, h := range verdict.Hits {
+			if result.Excerpt == "" && policy[h.Severity] >= moderation.ActionReview {
+				result.Excerpt = excerpt(*text, h)
+			}
+		}
+		if verdict.Action > result.Action {
+			result.Action = verdict.Action
+		}
+		result.Hits = append(result.Hits, verdict.Hits...)
+		*text = verdict.Text
+	}
+	return result
+}
+
+// excerpt 截取命中位置前后的原文
+func excerpt(text string, hit moderation.Hit) string {
+	runes := []rune(text)
+	start, end := hit.Start-excerptRadius, hit.End+excerptRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(runes) {
+		end, suffix = len(runes), ""
+	}
+	return prefix + string(runes[start:end]) + suffix
+}
+
+// Enqueue 把需要审核的内容加入队列；同一内容已在队列中时更新命中信息，不重复排队
+func (s *ModerationService) Enqueue(contentType string, contentID, authorID uint, title string, result ScreenResult) error {
+	item := models.ModerationItem{
+		ContentType: contentType,
+		ContentID:   contentID,
+		AuthorID:    authorID,
+		Status:      models.ModerationPending,
+		Title:       truncateRunes(title, 254),
+		Words:       truncateRunes(strings.Join(result.words(), ","), 254),
+		Categories:  truncateRunes(strings.Join(result.categories(), ","), 254),
+		Excerpt:     result.Excerpt,
+	}
+	var exist models.ModerationItem
+	err := s.db.Where("content_type = ? AND content_id = ? AND status = ?", contentType, contentID, models.ModerationPending).
+		First(&exist).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.db.Create(&item).Error
+	}
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&exist).Updates(map[string]interface{}{
+		"title":      item.Title,
+		"words":      item.Words,
+		"categories": item.Categories,
+		"excerpt":    item.Excerpt,
+	}).Error
+}
+
+// ListQueue 审核队列，status 为 -1 时不按状态筛选，contentType 为空时包含所有类型；最早提交的在前
+func (s *ModerationService) ListQueue(status int, contentType string, page, pageSize int) ([]models.ModerationItem, int64, error) {
+	query := s.db.Model(&models.ModerationItem{})
+	if status != -1 {
+		query = query.Where("status = ?", status)
+	}
+	if contentType != "" {
+		query = query.Where("content_type = ?", contentType)
+	}
+	return s.paginate(query, "id asc", page, pageSize)
+}
+
+// ListAuthorItems 作者自己被送审的内容和审核结果，最新的在前
+func (s *ModerationService) ListAuthorItems(authorID uint, page, pageSize int) ([]models.ModerationItem, int64, error) {
+	query := s.db.Model(&models.ModerationItem{}).Where("author_id = ?", authorID)
+	return s.paginate(query, "id desc", page, pageSize)
+}
+
+func (s *ModerationService) paginate(query *gorm.DB, order string, page, pageSize int) ([]models.ModerationItem, int64, error) {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var items []models.ModerationItem
+	if err := query.Order(order).Offset((page - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+// Approve 审核通过并公开内容。内容已不在审核状态（如作者撤回了章节）时只记录审核结果
+func (s *ModerationService) Approve(id, reviewerID uint, reason string) (*models.ModerationItem, error) {
+	return s.review(id, reviewerID, models.ModerationApproved, reason)
+}
+
+// Reject 审核未通过：章节退回草稿，评论被删除，小说保持隐藏并向作者展示原因
+func (s *ModerationService) Reject(id, reviewerID uint, reason string) (*models.ModerationItem, error) {
+	return s.review(id, reviewerID, models.ModerationRejected, reason)
+}
+
+func (s *ModerationService) review(id, reviewerID uint, status int, reason string) (*models.ModerationItem, error) {
+	var item models.ModerationItem
+	if err := s.db.First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModerationItemNotFound
+		}
+		return nil, err
+	}
+	if item.Status != models.ModerationPending {
+		return nil, ErrModerationItemReviewed
+	}
+	if err := s.applyReview(&item, status == models.ModerationApproved, reason); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := s.db.Model(&item).Where("status = ?", models.ModerationPending).Updates(map[string]interface{}{
+		"status":      status,
+		"reason":      truncateRunes(reason, 254),
+		"reviewer_id": reviewerID,
+		"reviewed_at": &now,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrModerationItemReviewed
+	}
+	item.Status = status
+	item.Reason = truncateRunes(reason, 254)
+	item.ReviewerID = reviewerID
+	item.ReviewedAt = &now
+	return &item, nil
+}
+
+// applyReview 按审核结果处理内容，内容已不在审核状态时不做修改
+func (s *ModerationService) applyReview(item *models.ModerationItem, approved bool, reason string) error {
+	switch item.ContentType {
+	case models.ModerationContentChapter:
+		return s.chapters.FinishReview(item.ContentID, approved)
+	case models.ModerationContentComment:
+		var err error
+		if approved {
+			err = s.comments.ApprovePendingComment(item.ContentID)
+		} else {
+			err = s.comments.RejectPendingComment(item.ContentID)
+		}
+		if errors.Is(err, ErrCommentNotFound) {
+			return nil
+		}
+		return err
+	case models.ModerationContentNovel:
+		var novel models.Novel
+		if err := s.db.Select("id", "hidden_at", "hidden_reason").First(&novel, item.ContentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if !IsAwaitingModeration(&novel) {
+			return nil
+		}
+		if approved {
+			_, err := s.admin.UnhideNovel(novel.ID)
+			return err
+		}
+		return s.db.Model(&novel).UpdateColumn("hidden_reason", truncateRunes(moderationRejectedPrefix+reason, 254)).Error
+	}
+	return nil
+}
+
+// IsAwaitingModeration 小说是否因内容审核被隐藏（审核中或未通过），而不是被管理员手动隐藏
+func IsAwaitingModeration(novel *models.Novel) bool {
+	return novel.HiddenAt != nil &&
+		(novel.HiddenReason == ModerationHiddenReason || strings.HasPrefix(novel.HiddenReason, moderationRejectedPrefix))
+}
@@ -0,0 +1,468 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/realtime"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	notificationQueueSize  = 1024
+	notificationBatchSize  = 500
+	notificationSnippet    = 60          // 通知中引用评论、书评内容的长度
+	notificationRetryEvery = time.Minute // 定期分发暂存的事件，并继续中断的章节通知
+)
+
+// NotificationEvent 待分发的通知事件
+type NotificationEvent struct {
+	Type        string
+	ActorID     uint
+	RecipientID uint // 单一接收者的事件（回复、关注、书评）
+	NovelID     uint
+	ChapterID   uint
+	CommentID   uint
+	ReviewID    uint
+	Content     string
+}
+
+type NotificationService struct {
+	db     *gorm.DB
+	hub    *realtime.Hub
+	events chan NotificationEvent
+}
+
+func NewNotificationService(db *gorm.DB, hub *realtime.Hub) *NotificationService {
+	return &NotificationService{
+		db:     db,
+		hub:    hub,
+		events: make(chan NotificationEvent, notificationQueueSize),
+	}
+}
+
+// Publish 异步分发通知事件，不阻塞调用方；队列已满时把事件写入数据库，由后台任务稍后分发
+func (s *NotificationService) Publish(event NotificationEvent) {
+	select {
+	case s.events <- event:
+	default:
+		s.persist(event)
+	}
+}
+
+// persist 把事件暂存到数据库
+func (s *NotificationService) persist(event NotificationEvent) {
+	payload, err := json.Marshal(event)
+	if err == nil {
+		err = s.db.Create(&models.NotificationOutbox{Payload: string(payload)}).Error
+	}
+	if err != nil {
+		log.Printf("Failed to save %s event (novel %d, chapter %d): %v", event.Type, event.NovelID, event.ChapterID, err)
+	}
+}
+
+// Start 启动后台分发协程，ctx 结束时把队列中剩余的事件写入数据库后退出，返回的 channel 在退出后关闭
+func (s *NotificationService) Start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(notificationRetryEvery)
+		defer ticker.Stop()
+		s.retryPending()
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case event := <-s.events:
+						s.persist(event)
+					default:
+						return
+					}
+				}
+			case event := <-s.events:
+				if err := s.dispatch(event); err != nil {
+					log.Printf("Failed to dispatch %s notification: %v", event.Type, err)
+				}
+			case <-ticker.C:
+				s.retryPending()
+			}
+		}
+	}()
+	return done
+}
+
+// retryPending 继续中断的章节通知，并分发暂存的事件；分发失败的事件保留到下次重试
+func (s *NotificationService) retryPending() {
+	var dispatches []uint
+	if err := s.db.Model(&models.NotificationDispatch{}).Where("in_progress = ?", true).
+		Pluck("id", &dispatches).Error; err != nil {
+		log.Printf("Failed to load pending notification dispatches: %v", err)
+	}
+	for _, id := range dispatches {
+		if err := s.resumeDispatch(id); err != nil {
+			log.Printf("Failed to resume notification dispatch %d: %v", id, err)
+		}
+	}
+
+	var lastID uint
+	for {
+		var rows []models.NotificationOutbox
+		if err := s.db.Where("id > ?", lastID).Order("id asc").Limit(notificationBatchSize).
+			Find(&rows).Error; err != nil {
+			log.Printf("Failed to load saved notification events: %v", err)
+			return
+		}
+		if len(rows) == 0 {
+			return
+		}
+		lastID = rows[len(rows)-1].ID
+
+		for _, row := range rows {
+			var event NotificationEvent
+			if err := json.Unmarshal([]byte(row.Payload), &event); err != nil {
+				log.Printf("Discarding malformed notification event %d: %v", row.ID, err)
+			} else if err := s.dispatch(event); err != nil {
+				log.Printf("Failed to dispatch saved %s notification: %v", event.Type, err)
+				continue
+			}
+			if err := s.db.Delete(&row).Error; err != nil {
+				log.Printf("Failed to delete saved notification event %d: %v", row.ID, err)
+			}
+		}
+	}
+}
+
+// dispatch 将事件展开为每个接收者的通知
+func (s *NotificationService) dispatch(event NotificationEvent) error {
+	if event.Type == models.NotificationNewChapter {
+		return s.fanOutNewChapter(event)
+	}
+	return s.notifyRecipient(event)
+}
+
+// fanOutNewChapter 通知收藏了该小说的所有用户，同一章节只通知一次
+func (s *NotificationService) fanOutNewChapter(event NotificationEvent) error {
+	dispatch := models.NotificationDispatch{
+		ChapterID:  event.ChapterID,
+		Type:       event.Type,
+		NovelID:    event.NovelID,
+		InProgress: true,
+	}
+	claim := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&dispatch)
+	if claim.Error != nil {
+		return claim.Error
+	}
+	if claim.RowsAffected == 0 {
+		if err := s.db.Where("chapter_id = ? AND type = ?", event.ChapterID, event.Type).
+			First(&dispatch).Error; err != nil {
+			return err
+		}
+		if !dispatch.InProgress {
+			// 章节撤回后重新发布
+			return nil
+		}
+	}
+	return s.resumeDispatch(dispatch.ID)
+}
+
+// resumeDispatch 从上次的进度继续分发章节通知。每批在事务中锁定进度记录，
+// 写入通知并推进 LastFavoriteID，中断或并发重试都不会重复通知同一用户
+func (s *NotificationService) resumeDispatch(id uint) error {
+	var dispatch models.NotificationDispatch
+	if err := s.db.First(&dispatch, id).Error; err != nil {
+		return err
+	}
+	var info struct {
+		NovelTitle   string
+		ChapterTitle string
+		AuthorID     uint
+	}
+	if err := s.db.Table("chapters").
+		Joins("JOIN novels ON novels.id = chapters.novel_id").
+		Where("chapters.id = ?", dispatch.ChapterID).
+		Select("novels.title AS novel_title, chapters.title AS chapter_title, novels.author_id").
+		Scan(&info).Error; err != nil {
+		return err
+	}
+	title := fmt.Sprintf("《%s》更新了", info.NovelTitle)
+
+	for {
+		var notifications []models.Notification
+		finished := false
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var current models.NotificationDispatch
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, id).Error; err != nil {
+				return err
+			}
+			if !current.InProgress {
+				finished = true
+				return nil
+			}
+
+			// 按收藏记录分批处理，避免热门小说一次加载全部收藏用户
+			var favorites []models.Favorite
+			if err := tx.Select("id", "user_id").
+				Where("novel_id = ? AND id > ?", current.NovelID, current.LastFavoriteID).
+				Order("id asc").Limit(notificationBatchSize).
+				Find(&favorites).Error; err != nil {
+				return err
+			}
+			if len(favorites) == 0 {
+				finished = true
+				return tx.Model(&current).Update("in_progress", false).Error
+			}
+
+			userIDs := make([]uint, 0, len(favorites))
+			for _, f := range favorites {
+				userIDs = append(userIDs, f.UserID)
+			}
+			userIDs, err := s.filterEnabled(tx, userIDs, dispatch.Type)
+			if err != nil {
+				return err
+			}
+			for _, userID := range userIDs {
+				notifications = append(notifications, models.Notification{
+					UserID:    userID,
+					Type:      dispatch.Type,
+					ActorID:   info.AuthorID,
+					NovelID:   current.NovelID,
+					ChapterID: current.ChapterID,
+					Title:     title,
+					Content:   info.ChapterTitle,
+				})
+			}
+			if len(notifications) > 0 {
+				if err := tx.CreateInBatches(notifications, notificationBatchSize).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&current).Update("last_favorite_id", favorites[len(favorites)-1].ID).Error
+		})
+		if err != nil {
+			return err
+		}
+		s.push(notifications)
+		if finished {
+			return nil
+		}
+	}
+}
+
+// notifyRecipient 处理只有单一接收者的事件
+func (s *NotificationService) notifyRecipient(event NotificationEvent) error {
+	if event.RecipientID == 0 || event.RecipientID == event.ActorID {
+		return nil
+	}
+	enabled, err := s.filterEnabled(s.db, []uint{event.RecipientID}, event.Type)
+	if err != nil || len(enabled) == 0 {
+		return err
+	}
+
+	var actor string
+	s.db.Model(&models.User{}).Where("id = ?", event.ActorID).Select("username").Scan(&actor)
+
+	var title string
+	switch event.Type {
+	case models.NotificationCommentReply:
+		title = fmt.Sprintf("%s 回复了你的评论", actor)
+	case models.NotificationNewFollower:
+		title = fmt.Sprintf("%s 关注了你", actor)
+	case models.NotificationNovelReview:
+		var novelTitle string
+		s.db.Model(&models.Novel{}).Where("id = ?", event.NovelID).Select("title").Scan(&novelTitle)
+		title = fmt.Sprintf("%s 评价了《%s》", actor, novelTitle)
+	default:
+		title = actor
+	}
+
+	return s.save([]models.Notification{{
+		UserID:    event.RecipientID,
+		Type:      event.Type,
+		ActorID:   event.ActorID,
+		NovelID:   event.NovelID,
+		ChapterID: event.ChapterID,
+		CommentID: event.CommentID,
+		ReviewID:  event.ReviewID,
+		Title:     title,
+		Content:   truncateRunes(event.Content, notificationSnippet),
+	}})
+}
+
+// save 写入收件箱并实时推送给在线用户
+func (s *NotificationService) save(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	if err := s.db.CreateInBatches(notifications, notificationBatchSize).Error; err != nil {
+		return err
+	}
+	s.push(notifications)
+	return nil
+}
+
+// push 实时推送给在线用户，推送失败不影响收件箱
+func (s *NotificationService) push(notifications []models.Notification) {
+	if s.hub == nil {
+		return
+	}
+	for _, n := range notifications {
+		if err := s.hub.Publish(n.UserID, realtime.EventNotification, n); err != nil {
+			log.Printf("Failed to push notification %d: %v", n.ID, err)
+		}
+	}
+}
+
+// filterEnabled 去掉关闭了该类型通知的用户
+func (s *NotificationService) filterEnabled(db *gorm.DB, userIDs []uint, notificationType string) ([]uint, error) {
+	var disabled []uint
+	if err := db.Model(&models.NotificationPreference{}).
+		Where("user_id IN ? AND type = ? AND enabled = ?", userIDs, notificationType, false).
+		Pluck("user_id", &disabled).Error; err != nil {
+		return nil, err
+	}
+	if len(disabled) == 0 {
+		return userIDs, nil
+	}
+
+	skip := make(map[uint]bool, len(disabled))
+	for _, id := range disabled {
+		skip[id] = true
+	}
+	enabled := userIDs[:0]
+	for _, id := range userIDs {
+		if !skip[id] {
+			enabled = append(enabled, id)
+		}
+	}
+	return enabled, nil
+}
+
+// ListNotifications 分页获取通知，可按类型和未读筛选
+func (s *NotificationService) ListNotifications(userID uint, notificationType string, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var total int64
+
+	query := s.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("id desc").Offset(offset).Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+// GetUnreadCounts 按类型统计未读通知数量
+func (s *NotificationService) GetUnreadCounts(userID uint) (map[string]int64, error) {
+	var rows []struct {
+		Type  string
+		Count int64
+	}
+	if err := s.db.Model(&models.Notification{}).
+		Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Group("type").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(models.NotificationTypes)+1)
+	var total int64
+	for _, t := range models.NotificationTypes {
+		counts[t] = 0
+	}
+	for _, r := range rows {
+		counts[r.Type] = r.Count
+		total += r.Count
+	}
+	counts["total"] = total
+	return counts, nil
+}
+
+// MarkRead 批量标记已读：指定 ids 时只标记这些通知，否则标记该类型（为空时为全部）的未读通知
+func (s *NotificationService) MarkRead(userID uint, ids []uint, notificationType string) (int64, error) {
+	query := s.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	} else if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+	result := query.Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteNotification 删除通知
+func (s *NotificationService) DeleteNotification(userID, id uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Notification{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPreferences 获取用户各类型通知的开关，未设置的类型默认开启
+func (s *NotificationService) GetPreferences(userID uint) (map[string]bool, error) {
+	var prefs []models.NotificationPreference
+	if err := s.db.Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		result[t] = true
+	}
+	for _, p := range prefs {
+		if _, ok := result[p.Type]; ok {
+			result[p.Type] = p.Enabled
+		}
+	}
+	return result, nil
+}
+
+// UpdatePreferences 更新通知开关，忽略未知类型
+func (s *NotificationService) UpdatePreferences(userID uint, prefs map[string]bool) (map[string]bool, error) {
+	known := make(map[string]bool, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		known[t] = true
+	}
+
+	rows := make([]models.NotificationPreference, 0, len(prefs))
+	for t, enabled := range prefs {
+		if known[t] {
+			rows = append(rows, models.NotificationPreference{UserID: userID, Type: t, Enabled: enabled})
+		}
+	}
+	if len(rows) > 0 {
+		if err := s.db.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+		}).Create(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	return s.GetPreferences(userID)
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
@@ -41,25 +41,25 @@ func (s *NovelService) GetNovelStats(novelID uint) (*AuthorStats, error) {
 	stats.TotalChapters = result.TotalChapters
 	stats.TotalWords = result.TotalWords
 
-	// 获取总浏览量、点赞数和评论数
+	// 获取总阅读量、收藏数和评论数
+	var counts struct {
+		TotalViews    int64
+		TotalLikes    int64
+		TotalComments int64
+	}
 	if err := s.db.Model(&models.Novel{}).
-		Select("SUM(views) as total_views, SUM(likes) as total_likes, SUM(comments) as total_comments").
+		Select("COALESCE(SUM(read_count), 0) as total_views, COALESCE(SUM(favorite_count), 0) as total_likes, COALESCE(SUM(comment_count), 0) as total_comments").
 		Where("id = ?", novelID).
-		Scan(&struct {
-			TotalViews    int64
-			TotalLikes    int64
-			TotalComments int64
-		}{
-			TotalViews:    stats.TotalViews,
-			TotalLikes:    stats.TotalLikes,
-			TotalComments: stats.TotalComments,
-		}).Error; err != nil {
+		Scan(&counts).Error; err != nil {
 		return nil, err
 	}
+	stats.TotalViews = counts.TotalViews
+	stats.TotalLikes = counts.TotalLikes
+	stats.TotalComments = counts.TotalComments
 
 	// 获取平均评分
 	if err := s.db.Model(&models.Novel{}).
-		Select("COALESCE(AVG(rating), 0) as average_rating").
+		Select("rating_average").
 		Where("id = ?", novelID).
 		Scan(&stats.AverageRating).Error; err != nil {
 		return nil, err
@@ -96,6 +96,7 @@ func (s *NovelService) FavoriteNovel(userID, novelID uint) error {
 	if err := s.db.Model(&novel).UpdateColumn("favorite_count", gorm.Expr("favorite_count + ?", 1)).Error; err != nil {
 		return err
 	}
+	s.rankings.RecordFavorite(novelID, 1)
 
 	return nil
 }
@@ -119,6 +120,7 @@ func (s *NovelService) UnfavoriteNovel(userID, novelID uint) error {
 		if err := s.db.Model(&novel).UpdateColumn("favorite_count", gorm.Expr("favorite_count - ?", 1)).Error; err != nil {
 			return err
 		}
+		s.rankings.RecordFavorite(novelID, -1)
 	}
 
 	return nil
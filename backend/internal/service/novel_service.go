@@ -2,21 +2,53 @@ package service
 
 import (
 	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/policy"
+	"ai-novel-platform/internal/search"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type NovelService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	search   *SearchService
+	rankings *RankingService
+	tags       *TagService
+	categories *CategoryService
 }
 
-func NewNovelService(db *gorm.DB) *NovelService {
-	return &NovelService{db: db}
+func NewNovelService(db *gorm.DB, searchService *SearchService, rankings *RankingService, tags *TagService, categories *CategoryService) *NovelService {
+	return &NovelService{db: db, search: searchService, rankings: rankings, tags: tags, categories: categories}
 }
 
+// CreateNovel 创建小说，校验分类并规范化标签后写入
 func (s *NovelService) CreateNovel(novel *models.Novel) error {
-	return s.db.Create(novel).Error
+	if err := s.categories.ValidateSlug(novel.Category); err != nil {
+		return err
+	}
+	novel.CompletedAt = completionTime(nil, novel.Status)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tags, err := s.tags.Resolve(tx, novel.Tags, true)
+		if err != nil {
+			return err
+		}
+		novel.Tags = TagNames(tags)
+		if err := tx.Create(novel).Error; err != nil {
+			return err
+		}
+		// 读者发布第一部作品后成为作者
+		if err := tx.Model(&models.User{}).Where("id = ? AND role = ?", novel.AuthorID, policy.RoleReader).
+			Update("role", policy.RoleAuthor).Error; err != nil {
+			return err
+		}
+		return s.tags.SetNovelTags(tx, novel.ID, tags)
+	})
+	if err != nil {
+		return err
+	}
+	s.search.NovelChanged(novel.ID)
+	return nil
 }
 
 func (s *NovelService) GetNovel(id uint) (*models.Novel, error) {
@@ -27,34 +59,147 @@ func (s *NovelService) GetNovel(id uint) (*models.Novel, error) {
 	return &novel, nil
 }
 
-func (s *NovelService) UpdateNovel(novel *models.Novel) error {
-	return s.db.Save(novel).Error
+// UpdateNovel 保存作者对小说信息的修改。holdForReview 为 true 时同时隐藏小说等待审核，
+// 被管理员以其他原因隐藏的小说保持原状
+func (s *NovelService) UpdateNovel(novel *models.Novel, holdForReview bool) error {
+	if err := s.categories.ValidateSlug(novel.Category); err != nil {
+		return err
+	}
+	novel.CompletedAt = completionTime(novel.CompletedAt, novel.Status)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tags, err := s.tags.Resolve(tx, novel.Tags, true)
+		if err != nil {
+			return err
+		}
+		novel.Tags = TagNames(tags)
+		// 只写作者可编辑的列。阅读数、字数、评分汇总由后台任务、AddWordCount 和 ReviewService 用 SQL 表达式增量维护，
+		// 大纲只能通过 CommitNovelOutline 在行锁内修改，隐藏状态由管理员和审核流程修改，
+		// 整行写回先前读出的旧值会覆盖这些并发修改
+		if err := tx.Model(novel).Select("title", "description", "cover_url", "category", "status", "tags",
+			"completed_at", "updated_at").Updates(novel).Error; err != nil {
+			return err
+		}
+		if holdForReview {
+			if err := holdNovelForReview(tx, novel); err != nil {
+				return err
+			}
+		}
+		return s.tags.SetNovelTags(tx, novel.ID, tags)
+	})
+	if err != nil {
+		return err
+	}
+	s.search.NovelChanged(novel.ID)
+	return nil
+}
+
+// holdNovelForReview 隐藏小说等待审核。条件写在 UPDATE 中，不覆盖管理员以其他原因做的隐藏
+func holdNovelForReview(tx *gorm.DB, novel *models.Novel) error {
+	now := time.Now()
+	result := tx.Model(&models.Novel{}).
+		Where("id = ? AND (hidden_at IS NULL OR hidden_reason = ? OR hidden_reason LIKE ?)",
+			novel.ID, ModerationHiddenReason, moderationRejectedPrefix+"%").
+		UpdateColumns(map[string]interface{}{"hidden_at": now, "hidden_reason": ModerationHiddenReason})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		novel.HiddenAt = &now
+		novel.HiddenReason = ModerationHiddenReason
+	}
+	return nil
+}
+
+// AddWordCount 原子地调整小说字数，用于章节编辑
+func (s *NovelService) AddWordCount(id uint, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	if err := s.db.Model(&models.Novel{}).Where("id = ?", id).
+		UpdateColumn("word_count", gorm.Expr("word_count + ?", delta)).Error; err != nil {
+		return err
+	}
+	s.search.NovelChanged(id)
+	return nil
 }
 
 func (s *NovelService) UpdateNovelStatus(id uint, status int, authorID uint) error {
-	result := s.db.Model(&models.Novel{}).Where("id = ? AND author_id = ?", id, authorID).Update("status", status)
+	var novel models.Novel
+	if err := s.db.Select("id", "completed_at").Where("id = ? AND author_id = ?", id, authorID).First(&novel).Error; err != nil {
+		return errors.New("novel not found or not authorized")
+	}
+	result := s.db.Model(&models.Novel{}).Where("id = ? AND author_id = ?", id, authorID).Updates(map[string]interface{}{
+		"status":       status,
+		"completed_at": completionTime(novel.CompletedAt, status),
+	})
 	if result.RowsAffected == 0 {
 		return errors.New("novel not found or not authorized")
 	}
+	if result.Error == nil {
+		s.search.NovelChanged(id)
+	}
 	return result.Error
 }
 
+// completionTime 计算完结时间：首次标记完结时记为当前时间，改回连载时清空
+func completionTime(completedAt *time.Time, status int) *time.Time {
+	if status != models.NovelStatusCompleted {
+		return nil
+	}
+	if completedAt != nil {
+		return completedAt
+	}
+	now := time.Now()
+	return &now
+}
+
 func (s *NovelService) DeleteNovel(id uint, authorID uint) error {
-	result := s.db.Where("id = ? AND author_id = ?", id, authorID).Delete(&models.Novel{})
-	if result.RowsAffected == 0 {
-		return errors.New("novel not found or not authorized")
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND author_id = ?", id, authorID).Delete(&models.Novel{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("novel not found or not authorized")
+		}
+		// 释放标签关联，保持标签使用次数准确
+		return s.tags.SetNovelTags(tx, id, nil)
+	})
+	if err == nil {
+		s.search.NovelChanged(id)
 	}
-	return result.Error
+	return err
 }
 
 func (s *NovelService) ListNovels(page, pageSize int, category string, status int, keyword string) ([]models.Novel, int64, error) {
 	var novels []models.Novel
 	var total int64
 
-	query := s.db.Model(&models.Novel{}).Preload("Author")
+	// 按父分类筛选时包含所有子分类
+	var categories []string
+	if category != "" {
+		expanded, err := s.categories.Expand(category)
+		if err != nil {
+			if errors.Is(err, ErrCategoryNotFound) {
+				return []models.Novel{}, 0, nil
+			}
+			return nil, 0, err
+		}
+		categories = expanded
+	}
+	// 有关键词时走全文索引，按相关度排序
+	if keyword != "" && s.search != nil {
+		q := search.Query{Text: keyword, Categories: categories, Offset: (page - 1) * pageSize, Limit: pageSize}
+		if status != -1 {
+			q.Status = &status
+		}
+		return s.search.SearchNovels(q)
+	}
+
+	query := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).Preload("Author")
 
-	if category != "" && category != "all" {
-		query = query.Where("category = ?", category)
+	if len(categories) > 0 {
+		query = query.Where("category IN ?", categories)
 	}
 	if status != -1 {
 		query = query.Where("status = ?", status)
@@ -159,38 +304,16 @@ func (s *NovelService) GetNovelOutline(novelID uint, authorID uint) (*models.Nov
 		return nil, err
 	}
 
-	if novel.NovelOutline.Outline == nil {
-		novel.NovelOutline.Outline = []models.OutlineItem{}
-	}
-	if novel.NovelOutline.WorldBuilding.Characters == nil {
-		novel.NovelOutline.WorldBuilding.Characters = []models.Character{}
-	}
-	if novel.NovelOutline.WorldBuilding.Locations == nil {
-		novel.NovelOutline.WorldBuilding.Locations = []models.Location{}
+	if novel.NovelOutline == nil {
+		return emptyOutline(), nil
 	}
+	normalizeOutline(novel.NovelOutline)
 
 	return novel.NovelOutline, nil
 }
 
-// UpdateNovelOutline 更新小说大纲
+// UpdateNovelOutline 更新小说大纲（直接覆盖并记录新版本）
 func (s *NovelService) UpdateNovelOutline(novelID uint, authorID uint, outline *models.NovelOutline) error {
-	// 确保切片不为 nil
-	if outline.Outline == nil {
-		outline.Outline = []models.OutlineItem{}
-	}
-	if outline.WorldBuilding.Characters == nil {
-		outline.WorldBuilding.Characters = []models.Character{}
-	}
-	if outline.WorldBuilding.Locations == nil {
-		outline.WorldBuilding.Locations = []models.Location{}
-	}
-
-	result := s.db.Model(&models.Novel{}).
-		Where("id = ? AND author_id = ?", novelID, authorID).
-		Update("novel_outline", outline)
-
-	if result.RowsAffected == 0 {
-		return errors.New("novel not found or not authorized")
-	}
-	return result.Error
+	_, err := s.CommitNovelOutline(novelID, authorID, outline, OutlineCommitOptions{Source: models.OutlineSourceManual})
+	return err
 }
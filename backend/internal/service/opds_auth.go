@@ -0,0 +1,146 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	opdsAuthCacheTTL     = 5 * time.Minute  // Basic 认证成功后的缓存时间，电子阅读器每个请求都会带上密码
+	opdsAuthFailWindow   = 15 * time.Minute // 失败次数的统计窗口
+	opdsAuthMaxFailsIP   = 20               // 窗口内单个 IP 允许的失败次数
+	opdsAuthMaxFailsUser = 5                // 窗口内单个用户名允许的失败次数
+	opdsAuthPruneEvery   = time.Minute
+)
+
+var ErrOPDSRateLimited = errors.New("too many failed opds logins")
+
+// opdsAuthEntry 缓存的 Basic 认证结果，记录当时的密码哈希，改密码后缓存随即失效
+type opdsAuthEntry struct {
+	userID       uint
+	passwordHash string
+	cachedAt     time.Time
+}
+
+type opdsAuthFailures struct {
+	count int
+	reset time.Time
+}
+
+// opdsAuthGuard Basic 认证的失败限流和成功缓存，保存在进程内存中。
+// 缓存键是用户名和密码的 HMAC，密钥在进程启动时随机生成，内存中不保留明文密码
+type opdsAuthGuard struct {
+	key       []byte
+	mu        sync.Mutex
+	cache     map[string]opdsAuthEntry
+	failures  map[string]*opdsAuthFailures
+	lastPrune time.Time
+}
+
+func newOPDSAuthGuard() *opdsAuthGuard {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return &opdsAuthGuard{
+		key:      key,
+		cache:    make(map[string]opdsAuthEntry),
+		failures: make(map[string]*opdsAuthFailures),
+	}
+}
+
+func (g *opdsAuthGuard) cacheKey(username, password string) string {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write([]byte(username))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (g *opdsAuthGuard) cached(key string, now time.Time) (opdsAuthEntry, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	entry, ok := g.cache[key]
+	if !ok || now.Sub(entry.cachedAt) > opdsAuthCacheTTL {
+		return opdsAuthEntry{}, false
+	}
+	return entry, true
+}
+
+func (g *opdsAuthGuard) forget(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.cache, key)
+}
+
+// limited IP 或用户名在窗口内的失败次数达到上限
+func (g *opdsAuthGuard) limited(ip, username string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prune(now)
+	if f := g.failures["ip:"+ip]; f != nil && f.count >= opdsAuthMaxFailsIP {
+		return true
+	}
+	if f := g.failures["user:"+username]; f != nil && f.count >= opdsAuthMaxFailsUser {
+		return true
+	}
+	return false
+}
+
+func (g *opdsAuthGuard) fail(ip, username string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, key := range []string{"ip:" + ip, "user:" + username} {
+		f := g.failures[key]
+		if f == nil || now.After(f.reset) {
+			f = &opdsAuthFailures{reset: now.Add(opdsAuthFailWindow)}
+			g.failures[key] = f
+		}
+		f.count++
+	}
+}
+
+// succeed 缓存认证结果并清除该用户名的失败次数；IP 的失败次数保留，避免用一个自己的账号重置限流
+func (g *opdsAuthGuard) succeed(key, username string, entry opdsAuthEntry) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cache[key] = entry
+	delete(g.failures, "user:"+username)
+}
+
+// prune 定期清理过期的缓存和失败记录，调用方需持有锁
+func (g *opdsAuthGuard) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < opdsAuthPruneEvery {
+		return
+	}
+	g.lastPrune = now
+	for key, entry := range g.cache {
+		if now.Sub(entry.cachedAt) > opdsAuthCacheTTL {
+			delete(g.cache, key)
+		}
+	}
+	for key, f := range g.failures {
+		if now.After(f.reset) {
+			delete(g.failures, key)
+		}
+	}
+}
+
+// stillValid 缓存的认证结果是否仍然有效：密码未修改，账号未被封禁或要求重置密码，会话未被吊销
+func (s *OPDSService) stillValid(entry opdsAuthEntry) bool {
+	var user models.User
+	if err := s.db.Select("id", "password_hash", "banned_at", "banned_until", "password_reset_required", "sessions_revoked_at").
+		First(&user, entry.userID).Error; err != nil {
+		return false
+	}
+	if user.PasswordHash != entry.passwordHash || user.IsBanned(time.Now()) || user.PasswordResetRequired {
+		return false
+	}
+	return user.SessionsRevokedAt == nil || !entry.cachedAt.Before(*user.SessionsRevokedAt)
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"ai-novel-platform/internal/epub"
+	"ai-novel-platform/internal/feed"
+	"ai-novel-platform/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	opdsPageSize        = 20
+	feedTokenBytes      = 24
+	feedTokenTouchEvery = time.Hour // 最后使用时间的更新间隔，避免每次请求都写库
+)
+
+var (
+	ErrOPDSUnauthorized = errors.New("opds unauthorized")
+	ErrBookNotFound     = errors.New("book not found")
+)
+
+type OPDSService struct {
+	db          *gorm.DB
+	userService *UserService
+	siteURL     string // 前台站点地址，用于生成网页链接
+	authGuard   *opdsAuthGuard
+}
+
+func NewOPDSService(db *gorm.DB, userService *UserService, siteURL string) *OPDSService {
+	return &OPDSService{db: db, userService: userService, siteURL: strings.TrimRight(siteURL, "/"), authGuard: newOPDSAuthGuard()}
+}
+
+// AuthenticateBasic 使用用户名和密码认证（HTTP Basic）。成功结果短时间缓存，避免每个请求都计算 bcrypt；
+// 同一 IP 或用户名失败过多时返回 ErrOPDSRateLimited，不再校验密码
+func (s *OPDSService) AuthenticateBasic(username, password, ip string) (uint, error) {
+	now := time.Now()
+	key := s.authGuard.cacheKey(username, password)
+	if entry, ok := s.authGuard.cached(key, now); ok {
+		if s.stillValid(entry) {
+			return entry.userID, nil
+		}
+		s.authGuard.forget(key)
+	}
+	if s.authGuard.limited(ip, username, now) {
+		return 0, ErrOPDSRateLimited
+	}
+
+	user, err := s.userService.Login(username, password)
+	if err != nil {
+		s.authGuard.fail(ip, username, now)
+		return 0, fmt.Errorf("%w: %v", ErrOPDSUnauthorized, err)
+	}
+	s.authGuard.succeed(key, username, opdsAuthEntry{userID: user.ID, passwordHash: user.PasswordHash, cachedAt: now})
+	return user.ID, nil
+}
+
+// AuthenticateToken 使用订阅令牌认证
+func (s *OPDSService) AuthenticateToken(token string) (uint, error) {
+	var feedToken models.FeedToken
+	if err := s.db.Where("token = ?", token).First(&feedToken).Error; err != nil {
+		return 0, ErrOPDSUnauthorized
+	}
+	// 账号被封禁或会话被吊销后，之前创建的令牌同样失效
+	if err := s.userService.ValidateSession(feedToken.UserID, feedToken.CreatedAt); err != nil {
+		return 0, ErrOPDSUnauthorized
+	}
+
+	now := time.Now()
+	s.db.Model(&models.FeedToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", feedToken.ID, now.Add(-feedTokenTouchEvery)).
+		UpdateColumn("last_used_at", now)
+	return feedToken.UserID, nil
+}
+
+// GetFeedToken 获取用户的订阅令牌，未创建时返回 gorm.ErrRecordNotFound
+func (s *OPDSService) GetFeedToken(userID uint) (*models.FeedToken, error) {
+	var feedToken models.FeedToken
+	if err := s.db.Where("user_id = ?", userID).First(&feedToken).Error; err != nil {
+		return nil, err
+	}
+	return &feedToken, nil
+}
+
+// RotateFeedToken 生成新的订阅令牌，旧令牌立即失效
+func (s *OPDSService) RotateFeedToken(userID uint) (*models.FeedToken, error) {
+	buf := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+
+	feedToken := models.FeedToken{UserID: userID, Token: hex.EncodeToString(buf)}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.FeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&feedToken).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &feedToken, nil
+}
+
+// RevokeFeedToken 删除订阅令牌
+func (s *OPDSService) RevokeFeedToken(userID uint) error {
+	return s.db.Where("user_id = ?", userID).Delete(&models.FeedToken{}).Error
+}
+
+// Root 书库首页（导航 feed）
+func (s *OPDSService) Root(base string) *feed.Feed {
+	f := s.navigationFeed(base, "", "AI 小说书库")
+	now := time.Now()
+	entries := []struct {
+		path, title, content, kind string
+	}{
+		{"/latest", "最近更新", "按更新时间排列的作品", feed.OPDSAcquisitionMIME},
+		{"/popular", "热门作品", "按阅读量排列的作品", feed.OPDSAcquisitionMIME},
+		{"/categories", "分类", "按分类浏览作品", feed.OPDSNavigationMIME},
+		{"/favorites", "我的收藏", "收藏的作品", feed.OPDSAcquisitionMIME},
+	}
+	for _, e := range entries {
+		f.Entries = append(f.Entries, navigationEntry(base+e.path, e.title, e.content, e.kind, now))
+	}
+	f.Links = append(f.Links,
+		feed.Link{Rel: feed.RelNew, Href: base + "/latest", Type: feed.OPDSAcquisitionMIME, Title: "最近更新"},
+		feed.Link{Rel: feed.RelPopular, Href: base + "/popular", Type: feed.OPDSAcquisitionMIME, Title: "热门作品"},
+	)
+	f.Updated = feed.FormatTime(now)
+	return f
+}
+
+// Latest 最近更新的作品
+func (s *OPDSService) Latest(base string, page int) (*feed.Feed, time.Time, error) {
+	query := s.catalogNovels().Order("novels.updated_at desc, novels.id desc")
+	return s.acquisitionFeed(base, "/latest", "最近更新", query, page)
+}
+
+// Popular 按阅读量排序的作品
+func (s *OPDSService) Popular(base string, page int) (*feed.Feed, time.Time, error) {
+	query := s.catalogNovels().Order("novels.read_count desc, novels.id desc")
+	return s.acquisitionFeed(base, "/popular", "热门作品", query, page)
+}
+
+// Categories 分类导航，每个分类显示作品数量
+func (s *OPDSService) Categories(base string) (*feed.Feed, time.Time, error) {
+	var rows []struct {
+		Category  string
+		Name      string
+		Count     int64
+		UpdatedAt time.Time
+	}
+	if err := s.catalogNovels().
+		Joins("LEFT JOIN categories ON categories.slug = novels.category").
+		Where("novels.category <> ''").
+		Select("novels.category, MAX(categories.name) AS name, COUNT(*) AS count, MAX(novels.updated_at) AS updated_at").
+		Group("novels.category").
+		Order("count desc").
+		Scan(&rows).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+
+	f := s.navigationFeed(base, "/categories", "分类")
+	var updated time.Time
+	for _, r := range rows {
+		if r.UpdatedAt.After(updated) {
+			updated = r.UpdatedAt
+		}
+		href := base + "/categories/" + url.PathEscape(r.Category)
+		content := fmt.Sprintf("共 %d 部作品", r.Count)
+		title := r.Name
+		if title == "" {
+			title = r.Category
+		}
+		f.Entries = append(f.Entries, navigationEntry(href, title, content, feed.OPDSAcquisitionMIME, r.UpdatedAt))
+	}
+	f.Updated = feed.FormatTime(updated)
+	return f, updated, nil
+}
+
+// Category 某个分类下的作品
+func (s *OPDSService) Category(base, category string, page int) (*feed.Feed, time.Time, error) {
+	query := s.catalogNovels().
+		Where("novels.category = ?", category).
+		Order("novels.updated_at desc, novels.id desc")
+	title := category
+	var names []string
+	if err := s.db.Model(&models.Category{}).Where("slug = ?", category).Limit(1).Pluck("name", &names).Error; err == nil && len(names) > 0 {
+		title = names[0]
+	}
+	return s.acquisitionFeed(base, "/categories/"+url.PathEscape(category), title, query, page)
+}
+
+// Favorites 用户收藏的作品，按收藏时间倒序
+func (s *OPDSService) Favorites(base string, userID uint, page int) (*feed.Feed, time.Time, error) {
+	query := s.catalogNovels().
+		Joins("JOIN favorites ON favorites.novel_id = novels.id AND favorites.user_id = ?", userID).
+		Order("favorites.created_at desc, favorites.id desc")
+	return s.acquisitionFeed(base, "/favorites", "我的收藏", query, page)
+}
+
+// Book 生成小说的 EPUB，只包含已发布章节；返回最后更新时间用于缓存校验
+func (s *OPDSService) Book(novelID uint) (*epub.Book, time.Time, error) {
+	var novel models.Novel
+	if err := s.db.Preload("Author").Scopes(models.VisibleNovels).First(&novel, novelID).Error; err != nil {
+		return nil, time.Time{}, ErrBookNotFound
+	}
+
+	var chapters []models.Chapter
+	if err := s.db.Where("novel_id = ? AND status = ? AND created_at <= ?", novelID, models.ChapterStatusPublished, time.Now()).
+		Order("`order` asc, id asc").
+		Find(&chapters).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(chapters) == 0 {
+		return nil, time.Time{}, ErrBookNotFound
+	}
+
+	updated := novel.UpdatedAt
+	book := &epub.Book{
+		Identifier:  fmt.Sprintf("%s/novels/%d", s.siteURL, novel.ID),
+		Title:       novel.Title,
+		Author:      novel.Author.Username,
+		Description: novel.Description,
+	}
+	for _, c := range chapters {
+		if c.UpdatedAt.After(updated) {
+			updated = c.UpdatedAt
+		}
+		book.Chapters = append(book.Chapters, epub.Chapter{
+			Title:      fmt.Sprintf("第%d章 %s", c.Order, c.Title),
+			Paragraphs: SplitParagraphs(c.Content),
+		})
+	}
+	book.Modified = updated
+	return book, updated, nil
+}
+
+// catalogNovels 书库中可见的小说：至少有一个已发布章节
+func (s *OPDSService) catalogNovels() *gorm.DB {
+	return s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Where("EXISTS (SELECT 1 FROM chapters WHERE chapters.novel_id = novels.id AND chapters.status = ?)", models.ChapterStatusPublished)
+}
+
+func (s *OPDSService) navigationFeed(base, path, title string) *feed.Feed {
+	return &feed.Feed{
+		ID:     base + path,
+		Title:  title,
+		Author: &feed.Person{Name: "AI 小说平台", URI: s.siteURL},
+		Links: []feed.Link{
+			{Rel: "self", Href: base + path, Type: feed.OPDSNavigationMIME},
+			{Rel: "start", Href: base, Type: feed.OPDSNavigationMIME},
+		},
+	}
+}
+
+// acquisitionFeed 分页查询小说并生成获取 feed；多查一条用于判断是否有下一页
+func (s *OPDSService) acquisitionFeed(base, path, title string, query *gorm.DB, page int) (*feed.Feed, time.Time, error) {
+	if page < 1 {
+		page = 1
+	}
+	var novels []models.Novel
+	if err := query.Preload("Author").
+		Offset((page - 1) * opdsPageSize).
+		Limit(opdsPageSize + 1).
+		Find(&novels).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+	hasNext := len(novels) > opdsPageSize
+	if hasNext {
+		novels = novels[:opdsPageSize]
+	}
+
+	self := base + path
+	if page > 1 {
+		self = fmt.Sprintf("%s?page=%d", self, page)
+	}
+	f := &feed.Feed{
+		ID:     base + path,
+		Title:  title,
+		Author: &feed.Person{Name: "AI 小说平台", URI: s.siteURL},
+		Links: []feed.Link{
+			{Rel: "self", Href: self, Type: feed.OPDSAcquisitionMIME},
+			{Rel: "start", Href: base, Type: feed.OPDSNavigationMIME},
+			{Rel: "up", Href: base, Type: feed.OPDSNavigationMIME},
+		},
+	}
+	if page > 1 {
+		f.Links = append(f.Links, feed.Link{Rel: "previous", Href: fmt.Sprintf("%s%s?page=%d", base, path, page-1), Type: feed.OPDSAcquisitionMIME})
+	}
+	if hasNext {
+		f.Links = append(f.Links, feed.Link{Rel: "next", Href: fmt.Sprintf("%s%s?page=%d", base, path, page+1), Type: feed.OPDSAcquisitionMIME})
+	}
+
+	var updated time.Time
+	for _, novel := range novels {
+		if novel.UpdatedAt.After(updated) {
+			updated = novel.UpdatedAt
+		}
+		f.Entries = append(f.Entries, s.novelEntry(base, novel))
+	}
+	f.Updated = feed.FormatTime(updated)
+	return f, updated, nil
+}
+
+// novelEntry 小说条目，包含 EPUB 下载链接和封面
+func (s *OPDSService) novelEntry(base string, novel models.Novel) feed.Entry {
+	novelURL := fmt.Sprintf("%s/novels/%d", s.siteURL, novel.ID)
+	entry := feed.Entry{
+		ID:        novelURL,
+		Title:     novel.Title,
+		Updated:   feed.FormatTime(novel.UpdatedAt),
+		Published: feed.FormatTime(novel.CreatedAt),
+		Authors:   []feed.Person{{Name: novel.Author.Username, URI: fmt.Sprintf("%s/authors/%d", s.siteURL, novel.AuthorID)}},
+		Links: []feed.Link{
+			{Rel: feed.RelAcquisition, Href: fmt.Sprintf("%s/novels/%d/book.epub", base, novel.ID), Type: epub.MIME},
+			{Rel: "alternate", Href: novelURL, Type: "text/html"},
+		},
+	}
+	if novel.Category != "" {
+		entry.Categories = []feed.Category{{Term: novel.Category}}
+	}
+	if novel.Description != "" {
+		entry.Summary = &feed.Text{Type: "text", Body: novel.Description}
+	}
+	if novel.CoverURL != "" {
+		entry.Links = append(entry.Links,
+			feed.Link{Rel: feed.RelImage, Href: novel.CoverURL, Type: imageMIME(novel.CoverURL)},
+			feed.Link{Rel: feed.RelThumbnail, Href: novel.CoverURL, Type: imageMIME(novel.CoverURL)},
+		)
+	}
+	return entry
+}
+
+func navigationEntry(href, title, content, kind string, updated time.Time) feed.Entry {
+	return feed.Entry{
+		ID:      href,
+		Title:   title,
+		Updated: feed.FormatTime(updated),
+		Content: &feed.Text{Type: "text", Body: content},
+		Links:   []feed.Link{{Rel: feed.RelSubsection, Href: href, Type: kind}},
+	}
+}
+
+// imageMIME 根据封面地址的扩展名推断图片类型
+func imageMIME(u string) string {
+	switch {
+	case strings.HasSuffix(strings.ToLower(u), ".png"):
+		return "image/png"
+	case strings.HasSuffix(strings.ToLower(u), ".webp"):
+		return "image/webp"
+	case strings.HasSuffix(strings.ToLower(u), ".gif"):
+		return "image/gif"
+	default:
+		return "image/jpeg"
+	}
+}
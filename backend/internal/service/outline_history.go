@@ -0,0 +1,405 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxOutlineVersions 每部小说保留的大纲历史版本数
+const MaxOutlineVersions = 200
+
+var (
+	ErrOutlineConflict    = errors.New("outline has conflicting concurrent changes")
+	ErrOutlineDuplicateID = errors.New("outline contains duplicate node id")
+)
+
+// OutlineCommitOptions 提交大纲时的选项
+type OutlineCommitOptions struct {
+	BaseVersion int    // 客户端编辑时基于的版本，0 表示直接覆盖
+	Source      string // 版本来源，见 models.OutlineSource*
+}
+
+// OutlineCommitResult 大纲提交结果
+type OutlineCommitResult struct {
+	Version   int                  `json:"version"`
+	Merged    bool                 `json:"merged"`
+	Outline   *models.NovelOutline `json:"outline"`
+	Conflicts []OutlineConflict    `json:"conflicts,omitempty"`
+	Changes   []OutlineChange      `json:"changes"`
+}
+
+// CommitNovelOutline 保存大纲并生成新版本；BaseVersion 落后于最新版本时进行三方合并
+func (s *NovelService) CommitNovelOutline(novelID uint, authorID uint, outline *models.NovelOutline, opts OutlineCommitOptions) (*OutlineCommitResult, error) {
+	normalizeOutline(outline)
+	if err := checkOutlineIDs(outline.Outline); err != nil {
+		return nil, err
+	}
+	if err := checkSettingIDs(characterSettings(outline.WorldBuilding.Characters)); err != nil {
+		return nil, err
+	}
+	if err := checkSettingIDs(locationSettings(outline.WorldBuilding.Locations)); err != nil {
+		return nil, err
+	}
+	if opts.Source == "" {
+		opts.Source = models.OutlineSourceManual
+	}
+
+	result := &OutlineCommitResult{Outline: outline}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var novel models.Novel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND author_id = ?", novelID, authorID).
+			First(&novel).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("novel not found or not authorized")
+			}
+			return err
+		}
+
+		head := novel.NovelOutline
+		if head == nil {
+			head = emptyOutline()
+		}
+		normalizeOutline(head)
+
+		// 启用历史之前已有的大纲作为初始版本 1 保存
+		saveInitial := novel.OutlineVersion == 0 && countOutlineNodes(head.Outline) > 0
+		headVersion := novel.OutlineVersion
+		if saveInitial {
+			headVersion = 1
+		}
+
+		var base *models.NovelOutline
+		if opts.BaseVersion > 0 && opts.BaseVersion != headVersion {
+			var v models.OutlineVersion
+			if err := tx.Where("novel_id = ? AND version = ?", novelID, opts.BaseVersion).First(&v).Error; err != nil {
+				return errors.New("base version not found")
+			}
+			base = v.Outline
+			if base == nil {
+				base = &models.NovelOutline{}
+			}
+			normalizeOutline(base)
+		}
+
+		// 差异和合并以节点 ID 为键、ID 0 表示根节点，缺少 ID 的节点（包括旧数据中的）
+		// 从各版本的最大 ID 之后分配
+		versions := []*models.NovelOutline{head, outline}
+		if base != nil {
+			versions = append(versions, base)
+		}
+		var next uint
+		for _, v := range versions {
+			if m := maxOutlineID(v.Outline); m > next {
+				next = m
+			}
+		}
+		for _, v := range versions {
+			fillOutlineIDs(v.Outline, &next)
+		}
+		fillSettingIDs(versions)
+
+		if saveInitial {
+			if err := createOutlineVersion(tx, &novel, head, models.OutlineSourceInitial, 0); err != nil {
+				return err
+			}
+		}
+
+		if base != nil {
+			merged, conflicts := MergeOutlines(base, head, outline)
+			result.Outline = merged
+			result.Conflicts = conflicts
+			if len(conflicts) > 0 {
+				result.Version = novel.OutlineVersion
+				return ErrOutlineConflict
+			}
+			outline = merged
+			result.Merged = true
+			opts.Source = models.OutlineSourceMerge
+		}
+
+		result.Changes = DiffOutlines(head, outline)
+		if novel.OutlineVersion > 0 && len(result.Changes) == 0 {
+			// 内容未变化时不产生新版本，避免自动保存刷出大量历史
+			result.Version = novel.OutlineVersion
+			return nil
+		}
+
+		if err := createOutlineVersion(tx, &novel, outline, opts.Source, opts.BaseVersion); err != nil {
+			return err
+		}
+		result.Version = novel.OutlineVersion
+
+		if err := tx.Model(&models.Novel{}).Where("id = ?", novelID).Updates(map[string]interface{}{
+			"novel_outline":   outline,
+			"outline_version": novel.OutlineVersion,
+		}).Error; err != nil {
+			return err
+		}
+
+		// 清理超出保留数量的旧版本
+		return tx.Where("novel_id = ? AND version <= ?", novelID, novel.OutlineVersion-MaxOutlineVersions).
+			Delete(&models.OutlineVersion{}).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrOutlineConflict) {
+			return result, err
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// createOutlineVersion 写入新版本并推进小说的版本号
+func createOutlineVersion(tx *gorm.DB, novel *models.Novel, outline *models.NovelOutline, source string, baseVersion int) error {
+	version := models.OutlineVersion{
+		NovelID:       novel.ID,
+		Version:       novel.OutlineVersion + 1,
+		ParentVersion: novel.OutlineVersion,
+		BaseVersion:   baseVersion,
+		AuthorID:      novel.AuthorID,
+		Source:        source,
+		NodeCount:     countOutlineNodes(outline.Outline),
+		Outline:       outline,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return err
+	}
+	novel.OutlineVersion = version.Version
+	return nil
+}
+
+// ListOutlineVersions 获取大纲历史版本列表（不含大纲内容）
+func (s *NovelService) ListOutlineVersions(novelID uint, authorID uint, page, limit int) ([]models.OutlineVersion, int64, error) {
+	if err := s.checkNovelAuthor(novelID, authorID); err != nil {
+		return nil, 0, err
+	}
+
+	var versions []models.OutlineVersion
+	var total int64
+	query := s.db.Model(&models.OutlineVersion{}).Where("novel_id = ?", novelID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Omit("outline").Order("version desc").Offset(offset).Limit(limit).Find(&versions).Error; err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
+
+// GetOutlineVersion 获取指定版本的大纲
+func (s *NovelService) GetOutlineVersion(novelID uint, authorID uint, version int) (*models.OutlineVersion, error) {
+	if err := s.checkNovelAuthor(novelID, authorID); err != nil {
+		return nil, err
+	}
+
+	var v models.OutlineVersion
+	if err := s.db.Where("novel_id = ? AND version = ?", novelID, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	if v.Outline == nil {
+		v.Outline = &models.NovelOutline{}
+	}
+	normalizeOutline(v.Outline)
+	return &v, nil
+}
+
+// DiffOutlineVersions 比较两个版本的大纲，to 为 0 时与当前大纲比较
+func (s *NovelService) DiffOutlineVersions(novelID uint, authorID uint, from, to int) ([]OutlineChange, error) {
+	fromVersion, err := s.GetOutlineVersion(novelID, authorID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *models.NovelOutline
+	if to == 0 {
+		if target, err = s.GetNovelOutline(novelID, authorID); err != nil {
+			return nil, err
+		}
+	} else {
+		toVersion, err := s.GetOutlineVersion(novelID, authorID, to)
+		if err != nil {
+			return nil, err
+		}
+		target = toVersion.Outline
+	}
+	return DiffOutlines(fromVersion.Outline, target), nil
+}
+
+// RestoreOutlineVersion 将大纲恢复为指定版本，恢复本身也会生成新版本
+func (s *NovelService) RestoreOutlineVersion(novelID uint, authorID uint, version int) (*OutlineCommitResult, error) {
+	v, err := s.GetOutlineVersion(novelID, authorID, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.CommitNovelOutline(novelID, authorID, cloneOutline(v.Outline), OutlineCommitOptions{Source: models.OutlineSourceRestore})
+}
+
+// GetOutlineHeadVersion 获取小说当前的大纲版本号
+func (s *NovelService) GetOutlineHeadVersion(novelID uint) (int, error) {
+	var version int
+	err := s.db.Model(&models.Novel{}).Where("id = ?", novelID).Select("outline_version").Scan(&version).Error
+	return version, err
+}
+
+func (s *NovelService) checkNovelAuthor(novelID uint, authorID uint) error {
+	var count int64
+	if err := s.db.Model(&models.Novel{}).Where("id = ? AND author_id = ?", novelID, authorID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("novel not found or not authorized")
+	}
+	return nil
+}
+
+// normalizeOutline 确保切片不为 nil
+func normalizeOutline(outline *models.NovelOutline) {
+	if outline.Outline == nil {
+		outline.Outline = []models.OutlineItem{}
+	}
+	if outline.WorldBuilding.Characters == nil {
+		outline.WorldBuilding.Characters = []models.Character{}
+	}
+	if outline.WorldBuilding.Locations == nil {
+		outline.WorldBuilding.Locations = []models.Location{}
+	}
+}
+
+// checkOutlineIDs 检查提交的大纲中是否有重复的节点 ID（未设置 ID 的节点除外）
+func checkOutlineIDs(items []models.OutlineItem) error {
+	seen := map[uint]bool{}
+	var walk func(items []models.OutlineItem) error
+	walk = func(items []models.OutlineItem) error {
+		for _, item := range items {
+			if item.ID != 0 {
+				if seen[item.ID] {
+					return fmt.Errorf("%w: %d", ErrOutlineDuplicateID, item.ID)
+				}
+				seen[item.ID] = true
+			}
+			if err := walk(item.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(items)
+}
+
+func maxOutlineID(items []models.OutlineItem) uint {
+	var max uint
+	for _, item := range items {
+		if item.ID > max {
+			max = item.ID
+		}
+		if m := maxOutlineID(item.Children); m > max {
+			max = m
+		}
+	}
+	return max
+}
+
+// fillOutlineIDs 为没有 ID 的节点从 next 之后依次分配 ID
+func fillOutlineIDs(items []models.OutlineItem, next *uint) {
+	for i := range items {
+		if items[i].ID == 0 {
+			*next++
+			items[i].ID = *next
+		}
+		fillOutlineIDs(items[i].Children, next)
+	}
+}
+
+// checkSettingIDs 检查角色或地点中是否有重复的 ID（未设置 ID 的除外）
+func checkSettingIDs(settings []namedSetting) error {
+	seen := map[uint]bool{}
+	for _, s := range settings {
+		if s.ID == 0 {
+			continue
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("%w: %d", ErrOutlineDuplicateID, s.ID)
+		}
+		seen[s.ID] = true
+	}
+	return nil
+}
+
+// fillSettingIDs 为各版本中没有 ID 的角色和地点分配 ID。同名的设定在各版本中分到相同的 ID，
+// 旧数据中的角色和地点因此仍能在版本之间对应
+func fillSettingIDs(versions []*models.NovelOutline) {
+	var characters, locations [][]*uint
+	var characterNames, locationNames [][]string
+	for _, v := range versions {
+		var ids []*uint
+		var names []string
+		for i := range v.WorldBuilding.Characters {
+			ids = append(ids, &v.WorldBuilding.Characters[i].ID)
+			names = append(names, v.WorldBuilding.Characters[i].Name)
+		}
+		characters, characterNames = append(characters, ids), append(characterNames, names)
+
+		ids, names = nil, nil
+		for i := range v.WorldBuilding.Locations {
+			ids = append(ids, &v.WorldBuilding.Locations[i].ID)
+			names = append(names, v.WorldBuilding.Locations[i].Name)
+		}
+		locations, locationNames = append(locations, ids), append(locationNames, names)
+	}
+	fillNamedIDs(characters, characterNames)
+	fillNamedIDs(locations, locationNames)
+}
+
+// fillNamedIDs ids[i][j] 是第 i 个版本中第 j 个设定的 ID，names 与之对应。
+// 同名设定优先沿用其他版本中已有的 ID，同一版本内已被占用时分配新 ID
+func fillNamedIDs(ids [][]*uint, names [][]string) {
+	var next uint
+	known := map[string]uint{}
+	for i := range ids {
+		for j, id := range ids[i] {
+			if *id > next {
+				next = *id
+			}
+			if *id != 0 {
+				if _, ok := known[names[i][j]]; !ok {
+					known[names[i][j]] = *id
+				}
+			}
+		}
+	}
+	for i := range ids {
+		used := map[uint]bool{}
+		for _, id := range ids[i] {
+			used[*id] = true
+		}
+		for j, id := range ids[i] {
+			if *id != 0 {
+				continue
+			}
+			if k, ok := known[names[i][j]]; ok && !used[k] {
+				*id = k
+			} else {
+				next++
+				*id = next
+				known[names[i][j]] = next
+			}
+			used[*id] = true
+		}
+	}
+}
+
+func cloneOutline(outline *models.NovelOutline) *models.NovelOutline {
+	var clone models.NovelOutline
+	data, _ := json.Marshal(outline)
+	_ = json.Unmarshal(data, &clone)
+	normalizeOutline(&clone)
+	return &clone
+}
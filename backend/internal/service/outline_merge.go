@@ -0,0 +1,650 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"sort"
+	"strings"
+)
+
+// 大纲结构变更类型
+const (
+	OutlineChangeAdded    = "added"
+	OutlineChangeRemoved  = "removed"
+	OutlineChangeMoved    = "moved"
+	OutlineChangeRenamed  = "renamed"
+	OutlineChangeModified = "modified" // 描述变化
+)
+
+// OutlineChange 大纲结构差异中的一项
+type OutlineChange struct {
+	Type        string `json:"type"`
+	Scope       string `json:"scope"` // outline、background、character、location
+	NodeID      uint   `json:"nodeId,omitempty"`
+	Name        string `json:"name"`
+	OldName     string `json:"oldName,omitempty"`
+	OldParentID uint   `json:"oldParentId,omitempty"`
+	NewParentID uint   `json:"newParentId,omitempty"`
+}
+
+// OutlineConflict 三方合并中无法自动解决的冲突
+type OutlineConflict struct {
+	Scope  string `json:"scope"`
+	NodeID uint   `json:"nodeId,omitempty"`
+	Name   string `json:"name"`
+	Field  string `json:"field"` // title、name、description、parent、order、delete
+	Base   string `json:"base"`
+	Ours   string `json:"ours"`
+	Theirs string `json:"theirs"`
+}
+
+// outlineNode 扁平化后的大纲节点
+type outlineNode struct {
+	item   models.OutlineItem // Children 置空
+	parent uint
+}
+
+// flatOutline 以节点 ID 为键的扁平大纲，children 记录每个父节点下的子节点顺序（根节点为 0）
+type flatOutline struct {
+	nodes    map[uint]*outlineNode
+	children map[uint][]uint
+}
+
+func flattenOutline(items []models.OutlineItem) *flatOutline {
+	f := &flatOutline{nodes: map[uint]*outlineNode{}, children: map[uint][]uint{}}
+	var walk func(items []models.OutlineItem, parent uint)
+	walk = func(items []models.OutlineItem, parent uint) {
+		for _, item := range items {
+			node := item
+			node.Children = nil
+			f.nodes[item.ID] = &outlineNode{item: node, parent: parent}
+			f.children[parent] = append(f.children[parent], item.ID)
+			walk(item.Children, item.ID)
+		}
+	}
+	walk(items, 0)
+	return f
+}
+
+// DiffOutlines 计算两个大纲之间的结构差异
+func DiffOutlines(from, to *models.NovelOutline) []OutlineChange {
+	if from == nil {
+		from = emptyOutline()
+	}
+	if to == nil {
+		to = emptyOutline()
+	}
+
+	changes := []OutlineChange{}
+	a, b := flattenOutline(from.Outline), flattenOutline(to.Outline)
+
+	for _, id := range sortedNodeIDs(a.nodes) {
+		if _, ok := b.nodes[id]; !ok {
+			n := a.nodes[id]
+			changes = append(changes, OutlineChange{Type: OutlineChangeRemoved, Scope: "outline", NodeID: id, Name: n.item.Title, OldParentID: n.parent})
+		}
+	}
+
+	reordered := reorderedNodes(a, b)
+	for _, id := range sortedNodeIDs(b.nodes) {
+		nb := b.nodes[id]
+		na, ok := a.nodes[id]
+		if !ok {
+			changes = append(changes, OutlineChange{Type: OutlineChangeAdded, Scope: "outline", NodeID: id, Name: nb.item.Title, NewParentID: nb.parent})
+			continue
+		}
+		if na.parent != nb.parent || reordered[id] {
+			changes = append(changes, OutlineChange{Type: OutlineChangeMoved, Scope: "outline", NodeID: id, Name: nb.item.Title, OldParentID: na.parent, NewParentID: nb.parent})
+		}
+		if na.item.Title != nb.item.Title {
+			changes = append(changes, OutlineChange{Type: OutlineChangeRenamed, Scope: "outline", NodeID: id, Name: nb.item.Title, OldName: na.item.Title})
+		}
+		if na.item.Description != nb.item.Description {
+			changes = append(changes, OutlineChange{Type: OutlineChangeModified, Scope: "outline", NodeID: id, Name: nb.item.Title})
+		}
+	}
+
+	if from.WorldBuilding.Background != to.WorldBuilding.Background {
+		changes = append(changes, OutlineChange{Type: OutlineChangeModified, Scope: "background", Name: "background"})
+	}
+	changes = append(changes, diffNamedSettings("character", characterMap(from.WorldBuilding.Characters), characterMap(to.WorldBuilding.Characters))...)
+	changes = append(changes, diffNamedSettings("location", locationMap(from.WorldBuilding.Locations), locationMap(to.WorldBuilding.Locations))...)
+	return changes
+}
+
+// reorderedNodes 找出父节点未变但在同级中相对顺序改变的节点
+func reorderedNodes(a, b *flatOutline) map[uint]bool {
+	result := map[uint]bool{}
+	for parent, oldChildren := range a.children {
+		newChildren := b.children[parent]
+		var x, y []uint
+		for _, id := range oldChildren {
+			if n, ok := b.nodes[id]; ok && n.parent == parent {
+				x = append(x, id)
+			}
+		}
+		for _, id := range newChildren {
+			if n, ok := a.nodes[id]; ok && n.parent == parent {
+				y = append(y, id)
+			}
+		}
+		stable := map[uint]bool{}
+		for _, id := range longestCommonSubsequence(x, y) {
+			stable[id] = true
+		}
+		for _, id := range y {
+			if !stable[id] {
+				result[id] = true
+			}
+		}
+	}
+	return result
+}
+
+func longestCommonSubsequence(x, y []uint) []uint {
+	dp := make([][]int, len(x)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] >= dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	var result []uint
+	for i, j := 0, 0; i < len(x) && j < len(y); {
+		switch {
+		case x[i] == y[j]:
+			result = append(result, x[i])
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return result
+}
+
+// diffNamedSettings 按 ID 比较角色或地点，改名记为 renamed 而不是删除再新增
+func diffNamedSettings(scope string, from, to map[uint]namedSetting) []OutlineChange {
+	var changes []OutlineChange
+	for _, id := range sortedSettingIDs(from) {
+		if _, ok := to[id]; !ok {
+			changes = append(changes, OutlineChange{Type: OutlineChangeRemoved, Scope: scope, NodeID: id, Name: from[id].Name})
+		}
+	}
+	for _, id := range sortedSettingIDs(to) {
+		nt := to[id]
+		na, ok := from[id]
+		if !ok {
+			changes = append(changes, OutlineChange{Type: OutlineChangeAdded, Scope: scope, NodeID: id, Name: nt.Name})
+			continue
+		}
+		if na.Name != nt.Name {
+			changes = append(changes, OutlineChange{Type: OutlineChangeRenamed, Scope: scope, NodeID: id, Name: nt.Name, OldName: na.Name})
+		}
+		if na.Description != nt.Description {
+			changes = append(changes, OutlineChange{Type: OutlineChangeModified, Scope: scope, NodeID: id, Name: nt.Name})
+		}
+	}
+	return changes
+}
+
+// MergeOutlines 以 base 为共同祖先，对 ours（当前最新版本）和 theirs（客户端提交）做三方合并。
+// 存在冲突时仍返回以 ours 为准的合并结果，供前端展示。节点、角色和地点以 ID 对应，三个版本中都需有非 0 的 ID
+func MergeOutlines(base, ours, theirs *models.NovelOutline) (*models.NovelOutline, []OutlineConflict) {
+	var conflicts []OutlineConflict
+	b, o, t := flattenOutline(base.Outline), flattenOutline(ours.Outline), flattenOutline(theirs.Outline)
+
+	merged := map[uint]*outlineNode{}
+	ids := map[uint]bool{}
+	for _, f := range []*flatOutline{b, o, t} {
+		for id := range f.nodes {
+			ids[id] = true
+		}
+	}
+
+	for id := range ids {
+		nb, inB := b.nodes[id]
+		no, inO := o.nodes[id]
+		nt, inT := t.nodes[id]
+
+		switch {
+		case !inB && inO && inT:
+			// 双方以同一 ID 新增：逐字段比较，内容相同则直接合并
+			merged[id] = cloneOutlineNode(no)
+			if no.item.Title != nt.item.Title {
+				conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: no.item.Title, Field: "title", Ours: no.item.Title, Theirs: nt.item.Title})
+			}
+			if no.item.Description != nt.item.Description {
+				conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: no.item.Title, Field: "description", Ours: no.item.Description, Theirs: nt.item.Description})
+			}
+			if no.parent != nt.parent {
+				conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: no.item.Title, Field: "parent"})
+			}
+		case !inB:
+			// 新增节点：只在一侧出现时直接保留
+			if inO {
+				merged[id] = cloneOutlineNode(no)
+			} else {
+				merged[id] = cloneOutlineNode(nt)
+			}
+		case !inO && !inT:
+			// 双方都删除
+		case !inO || !inT:
+			// 一方删除：另一方未改动则删除，否则冲突并保留修改
+			survivor := no
+			if !inO {
+				survivor = nt
+			}
+			if sameOutlineNode(nb, survivor) {
+				continue
+			}
+			conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: nb.item.Title, Field: "delete", Base: nb.item.Title, Ours: nodeTitleOrEmpty(no, inO), Theirs: nodeTitleOrEmpty(nt, inT)})
+			merged[id] = cloneOutlineNode(survivor)
+		default:
+			node := cloneOutlineNode(no)
+			var c bool
+			if node.item.Title, c = mergeField(nb.item.Title, no.item.Title, nt.item.Title); c {
+				conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: nb.item.Title, Field: "title", Base: nb.item.Title, Ours: no.item.Title, Theirs: nt.item.Title})
+			}
+			if node.item.Description, c = mergeField(nb.item.Description, no.item.Description, nt.item.Description); c {
+				conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: nb.item.Title, Field: "description", Base: nb.item.Description, Ours: no.item.Description, Theirs: nt.item.Description})
+			}
+			switch {
+			case no.parent == nb.parent:
+				node.parent = nt.parent
+			case nt.parent == nb.parent || nt.parent == no.parent:
+				node.parent = no.parent
+			default:
+				conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: nb.item.Title, Field: "parent"})
+				node.parent = no.parent
+			}
+			merged[id] = node
+		}
+	}
+
+	// 父节点已被删除的节点挂到最近的存活祖先下
+	for id, node := range merged {
+		parent := node.parent
+		for hops := 0; parent != 0 && merged[parent] == nil; hops++ {
+			if hops > len(ids) {
+				parent = 0
+				break
+			}
+			parent = ancestorParent(parent, b, o, t)
+		}
+		if parent != node.parent {
+			conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: node.item.Title, Field: "parent"})
+			node.parent = parent
+		}
+	}
+	// 并发移动可能产生环，退回 ours 的父节点，仍有环则移到根节点
+	for id, node := range merged {
+		if !outlineHasCycle(merged, id) {
+			continue
+		}
+		node.parent = 0
+		if n, ok := o.nodes[id]; ok && (n.parent == 0 || merged[n.parent] != nil) {
+			node.parent = n.parent
+			if outlineHasCycle(merged, id) {
+				node.parent = 0
+			}
+		}
+		conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: id, Name: node.item.Title, Field: "parent"})
+	}
+	// 双方都调整了同一父节点下原有子节点的相对顺序且结果不同
+	for _, parent := range sortedParentIDs(b.children) {
+		keep := commonSiblings(parent, b, o, t)
+		baseSeq := projectSiblings(b.children[parent], keep)
+		oursSeq := projectSiblings(o.children[parent], keep)
+		theirsSeq := projectSiblings(t.children[parent], keep)
+		if !sameSequence(oursSeq, baseSeq) && !sameSequence(theirsSeq, baseSeq) && !sameSequence(oursSeq, theirsSeq) {
+			name := ""
+			if n, ok := b.nodes[parent]; ok {
+				name = n.item.Title
+			}
+			conflicts = append(conflicts, OutlineConflict{Scope: "outline", NodeID: parent, Name: name, Field: "order", Base: siblingTitles(baseSeq, b), Ours: siblingTitles(oursSeq, b), Theirs: siblingTitles(theirsSeq, b)})
+		}
+	}
+	sort.SliceStable(conflicts, func(i, j int) bool { return conflicts[i].NodeID < conflicts[j].NodeID })
+
+	result := &models.NovelOutline{
+		Outline: buildMergedTree(merged, 0, b, o, t),
+	}
+
+	var c bool
+	if result.WorldBuilding.Background, c = mergeField(base.WorldBuilding.Background, ours.WorldBuilding.Background, theirs.WorldBuilding.Background); c {
+		conflicts = append(conflicts, OutlineConflict{Scope: "background", Name: "background", Field: "description", Base: base.WorldBuilding.Background, Ours: ours.WorldBuilding.Background, Theirs: theirs.WorldBuilding.Background})
+	}
+
+	characters, cc := mergeNamedSettings("character", characterMap(base.WorldBuilding.Characters), characterMap(ours.WorldBuilding.Characters), characterMap(theirs.WorldBuilding.Characters), settingOrder(characterSettings(ours.WorldBuilding.Characters), characterSettings(theirs.WorldBuilding.Characters)))
+	conflicts = append(conflicts, cc...)
+	result.WorldBuilding.Characters = make([]models.Character, 0, len(characters))
+	for _, c := range characters {
+		result.WorldBuilding.Characters = append(result.WorldBuilding.Characters, models.Character{ID: c.ID, Name: c.Name, Description: c.Description})
+	}
+
+	locations, lc := mergeNamedSettings("location", locationMap(base.WorldBuilding.Locations), locationMap(ours.WorldBuilding.Locations), locationMap(theirs.WorldBuilding.Locations), settingOrder(locationSettings(ours.WorldBuilding.Locations), locationSettings(theirs.WorldBuilding.Locations)))
+	conflicts = append(conflicts, lc...)
+	result.WorldBuilding.Locations = make([]models.Location, 0, len(locations))
+	for _, l := range locations {
+		result.WorldBuilding.Locations = append(result.WorldBuilding.Locations, models.Location{ID: l.ID, Name: l.Name, Description: l.Description})
+	}
+
+	return result, conflicts
+}
+
+// buildMergedTree 按合并后的父子关系重建树，同级顺序优先沿用调整过顺序的一方。
+// ID 0 表示根节点，调用方需先为节点分配 ID；残留的 ID 0 节点被丢弃，避免无限递归
+func buildMergedTree(merged map[uint]*outlineNode, parent uint, b, o, t *flatOutline) []models.OutlineItem {
+	var members []uint
+	for id, node := range merged {
+		if id != 0 && node.parent == parent {
+			members = append(members, id)
+		}
+	}
+	if len(members) == 0 {
+		return []models.OutlineItem{}
+	}
+
+	// ours 没有调整原有子节点的相对顺序时沿用 theirs 的顺序，只新增或删除子节点不算调整
+	sequence := o.children[parent]
+	keep := commonSiblings(parent, b, o, t)
+	if sameSequence(projectSiblings(o.children[parent], keep), projectSiblings(b.children[parent], keep)) {
+		sequence = t.children[parent]
+	}
+
+	isMember := map[uint]bool{}
+	for _, id := range members {
+		isMember[id] = true
+	}
+	ordered := make([]uint, 0, len(members))
+	placed := map[uint]bool{}
+	for _, id := range sequence {
+		if isMember[id] && !placed[id] {
+			ordered = append(ordered, id)
+			placed[id] = true
+		}
+	}
+
+	// 其余节点插入到其在来源版本中最近的已放置兄弟节点之后
+	sort.Slice(members, func(i, j int) bool { return members[i] < members[j] })
+	for _, id := range members {
+		if placed[id] {
+			continue
+		}
+		source := t.children[parent]
+		if n, ok := o.nodes[id]; ok && n.parent == parent {
+			source = o.children[parent]
+		}
+		pos := 0
+		for _, sid := range source {
+			if sid == id {
+				break
+			}
+			if placed[sid] {
+				pos = indexOf(ordered, sid) + 1
+			}
+		}
+		ordered = append(ordered, 0)
+		copy(ordered[pos+1:], ordered[pos:])
+		ordered[pos] = id
+		placed[id] = true
+	}
+
+	items := make([]models.OutlineItem, 0, len(ordered))
+	for i, id := range ordered {
+		item := merged[id].item
+		item.Order = i + 1
+		item.Children = buildMergedTree(merged, id, b, o, t)
+		items = append(items, item)
+	}
+	return items
+}
+
+// mergeField 三方合并单个字段，返回结果以及是否冲突（冲突时取 ours）
+func mergeField(base, ours, theirs string) (string, bool) {
+	switch {
+	case ours == theirs:
+		return ours, false
+	case ours == base:
+		return theirs, false
+	case theirs == base:
+		return ours, false
+	default:
+		return ours, true
+	}
+}
+
+// mergeNamedSettings 按 ID 三方合并角色或地点，名称和描述分别合并
+func mergeNamedSettings(scope string, base, ours, theirs map[uint]namedSetting, order []uint) ([]namedSetting, []OutlineConflict) {
+	var result []namedSetting
+	var conflicts []OutlineConflict
+	for _, id := range order {
+		vb, inB := base[id]
+		vo, inO := ours[id]
+		vt, inT := theirs[id]
+		switch {
+		case inO && inT:
+			// 双方以同一 ID 新增时以空值为共同祖先
+			if !inB {
+				vb = namedSetting{ID: id}
+			}
+			name := vb.Name
+			if !inB {
+				name = vo.Name
+			}
+			merged := namedSetting{ID: id}
+			var c bool
+			if merged.Name, c = mergeField(vb.Name, vo.Name, vt.Name); c {
+				conflicts = append(conflicts, OutlineConflict{Scope: scope, NodeID: id, Name: name, Field: "name", Base: vb.Name, Ours: vo.Name, Theirs: vt.Name})
+			}
+			if merged.Description, c = mergeField(vb.Description, vo.Description, vt.Description); c {
+				conflicts = append(conflicts, OutlineConflict{Scope: scope, NodeID: id, Name: name, Field: "description", Base: vb.Description, Ours: vo.Description, Theirs: vt.Description})
+			}
+			result = append(result, merged)
+		case !inB:
+			if inO {
+				result = append(result, vo)
+			} else {
+				result = append(result, vt)
+			}
+		case inO:
+			if vo != vb {
+				conflicts = append(conflicts, OutlineConflict{Scope: scope, NodeID: id, Name: vb.Name, Field: "delete", Base: vb.Description, Ours: vo.Description})
+				result = append(result, vo)
+			}
+		case inT:
+			if vt != vb {
+				conflicts = append(conflicts, OutlineConflict{Scope: scope, NodeID: id, Name: vb.Name, Field: "delete", Base: vb.Description, Theirs: vt.Description})
+				result = append(result, vt)
+			}
+		}
+	}
+	return result, conflicts
+}
+
+func cloneOutlineNode(n *outlineNode) *outlineNode {
+	c := *n
+	return &c
+}
+
+func sameOutlineNode(a, b *outlineNode) bool {
+	return a.parent == b.parent && a.item.Title == b.item.Title && a.item.Description == b.item.Description
+}
+
+func nodeTitleOrEmpty(n *outlineNode, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return n.item.Title
+}
+
+// ancestorParent 在任一版本中查找节点的父节点
+func ancestorParent(id uint, versions ...*flatOutline) uint {
+	for _, f := range versions {
+		if n, ok := f.nodes[id]; ok {
+			return n.parent
+		}
+	}
+	return 0
+}
+
+func outlineHasCycle(nodes map[uint]*outlineNode, id uint) bool {
+	seen := map[uint]bool{}
+	for cur := id; cur != 0; {
+		if seen[cur] {
+			return true
+		}
+		seen[cur] = true
+		n, ok := nodes[cur]
+		if !ok {
+			return false
+		}
+		cur = n.parent
+	}
+	return false
+}
+
+func indexOf(ids []uint, id uint) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func sameSequence(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedNodeIDs(nodes map[uint]*outlineNode) []uint {
+	ids := make([]uint, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// sortedParentIDs 有子节点的父节点 ID，按升序排列
+func sortedParentIDs(children map[uint][]uint) []uint {
+	ids := make([]uint, 0, len(children))
+	for id := range children {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// commonSiblings 三个版本中都位于 parent 之下的子节点
+func commonSiblings(parent uint, b, o, t *flatOutline) map[uint]bool {
+	keep := map[uint]bool{}
+	for _, id := range b.children[parent] {
+		no, inO := o.nodes[id]
+		nt, inT := t.nodes[id]
+		if inO && inT && no.parent == parent && nt.parent == parent {
+			keep[id] = true
+		}
+	}
+	return keep
+}
+
+// projectSiblings 只保留 keep 中的节点，得到它们的相对顺序
+func projectSiblings(sequence []uint, keep map[uint]bool) []uint {
+	var result []uint
+	for _, id := range sequence {
+		if keep[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func siblingTitles(ids []uint, f *flatOutline) string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, f.nodes[id].item.Title)
+	}
+	return strings.Join(names, "、")
+}
+
+// namedSetting 角色或地点，两者结构相同，合并和差异计算共用
+type namedSetting struct {
+	ID          uint
+	Name        string
+	Description string
+}
+
+func characterSettings(items []models.Character) []namedSetting {
+	settings := make([]namedSetting, 0, len(items))
+	for _, c := range items {
+		settings = append(settings, namedSetting{ID: c.ID, Name: c.Name, Description: c.Description})
+	}
+	return settings
+}
+
+func locationSettings(items []models.Location) []namedSetting {
+	settings := make([]namedSetting, 0, len(items))
+	for _, l := range items {
+		settings = append(settings, namedSetting{ID: l.ID, Name: l.Name, Description: l.Description})
+	}
+	return settings
+}
+
+func settingMap(settings []namedSetting) map[uint]namedSetting {
+	m := make(map[uint]namedSetting, len(settings))
+	for _, s := range settings {
+		m[s.ID] = s
+	}
+	return m
+}
+
+func characterMap(items []models.Character) map[uint]namedSetting {
+	return settingMap(characterSettings(items))
+}
+
+func locationMap(items []models.Location) map[uint]namedSetting {
+	return settingMap(locationSettings(items))
+}
+
+func sortedSettingIDs(m map[uint]namedSetting) []uint {
+	ids := make([]uint, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// settingOrder 合并后角色或地点的顺序：先 ours，再 theirs 新增的
+func settingOrder(ours, theirs []namedSetting) []uint {
+	var ids []uint
+	seen := map[uint]bool{}
+	for _, list := range [][]namedSetting{ours, theirs} {
+		for _, s := range list {
+			if !seen[s.ID] {
+				seen[s.ID] = true
+				ids = append(ids, s.ID)
+			}
+		}
+	}
+	return ids
+}
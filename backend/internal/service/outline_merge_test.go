@@ -0,0 +1,231 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func item(id uint, title string, children ...models.OutlineItem) models.OutlineItem {
+	return models.OutlineItem{ID: id, Title: title, Children: children}
+}
+
+func outlineOf(items ...models.OutlineItem) *models.NovelOutline {
+	o := &models.NovelOutline{Outline: items}
+	normalizeOutline(o)
+	return o
+}
+
+func titles(items []models.OutlineItem) []string {
+	var result []string
+	for _, it := range items {
+		result = append(result, it.Title)
+		result = append(result, titles(it.Children)...)
+	}
+	return result
+}
+
+func TestMergeOutlinesNonConflicting(t *testing.T) {
+	base := outlineOf(item(1, "第一卷", item(2, "开端")), item(3, "第二卷"))
+	ours := outlineOf(item(1, "第一卷 风起", item(2, "开端")), item(3, "第二卷"))
+	theirs := outlineOf(item(1, "第一卷", item(2, "开端"), item(4, "转折")), item(3, "第二卷"))
+
+	merged, conflicts := MergeOutlines(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+	got := titles(merged.Outline)
+	want := []string{"第一卷 风起", "开端", "转折", "第二卷"}
+	if len(got) != len(want) {
+		t.Fatalf("merged titles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("merged titles = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMergeOutlinesConflictingRename(t *testing.T) {
+	base := outlineOf(item(1, "第一卷"))
+	ours := outlineOf(item(1, "第一卷 风起"))
+	theirs := outlineOf(item(1, "第一卷 云涌"))
+
+	merged, conflicts := MergeOutlines(base, ours, theirs)
+	if len(conflicts) != 1 || conflicts[0].Field != "title" || conflicts[0].NodeID != 1 {
+		t.Fatalf("conflicts = %+v, want one title conflict on node 1", conflicts)
+	}
+	if merged.Outline[0].Title != "第一卷 风起" {
+		t.Fatalf("conflicting merge should keep ours, got %q", merged.Outline[0].Title)
+	}
+}
+
+// 没有 ID 的节点与根节点的 ID 0 相同，曾导致重建树时无限递归
+func TestMergeOutlinesWithoutIDs(t *testing.T) {
+	base := outlineOf(item(0, "第一卷", item(0, "开端")))
+	ours := outlineOf(item(0, "第一卷", item(0, "开端")), item(0, "第二卷"))
+	theirs := outlineOf(item(0, "第一卷"))
+
+	MergeOutlines(base, ours, theirs)
+
+	var next uint
+	for _, o := range []*models.NovelOutline{base, ours, theirs} {
+		if m := maxOutlineID(o.Outline); m > next {
+			next = m
+		}
+	}
+	for _, o := range []*models.NovelOutline{base, ours, theirs} {
+		fillOutlineIDs(o.Outline, &next)
+		if err := checkOutlineIDs(o.Outline); err != nil {
+			t.Fatalf("filled outline has duplicate ids: %v", err)
+		}
+	}
+	merged, _ := MergeOutlines(base, ours, theirs)
+	if len(merged.Outline) == 0 {
+		t.Fatal("merged outline is empty")
+	}
+}
+
+func TestCheckOutlineIDs(t *testing.T) {
+	if err := checkOutlineIDs(outlineOf(item(1, "a", item(0, "b")), item(0, "c")).Outline); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := checkOutlineIDs(outlineOf(item(1, "a", item(2, "b")), item(2, "c")).Outline)
+	if !errors.Is(err, ErrOutlineDuplicateID) {
+		t.Fatalf("err = %v, want ErrOutlineDuplicateID", err)
+	}
+}
+
+func TestFillOutlineIDs(t *testing.T) {
+	o := outlineOf(item(5, "a", item(0, "b")), item(0, "c"))
+	next := maxOutlineID(o.Outline)
+	fillOutlineIDs(o.Outline, &next)
+	if o.Outline[0].Children[0].ID != 6 || o.Outline[1].ID != 7 {
+		t.Fatalf("ids = %d, %d, want 6, 7", o.Outline[0].Children[0].ID, o.Outline[1].ID)
+	}
+}
+
+func TestMergeOutlinesConcurrentAdd(t *testing.T) {
+	base := outlineOf(item(1, "第一卷"))
+	tests := []struct {
+		name      string
+		ours      *models.NovelOutline
+		theirs    *models.NovelOutline
+		conflicts []string
+	}{
+		{"identical", outlineOf(item(1, "第一卷", item(2, "开端"))), outlineOf(item(1, "第一卷", item(2, "开端"))), nil},
+		{"different titles", outlineOf(item(1, "第一卷", item(2, "开端"))), outlineOf(item(1, "第一卷", item(2, "序章"))), []string{"title"}},
+		{"different parents", outlineOf(item(1, "第一卷", item(2, "开端"))), outlineOf(item(1, "第一卷"), item(2, "开端")), []string{"parent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := MergeOutlines(base, tt.ours, tt.theirs)
+			var fields []string
+			for _, c := range conflicts {
+				fields = append(fields, c.Field)
+			}
+			if !slices.Equal(fields, tt.conflicts) {
+				t.Fatalf("conflict fields = %v, want %v", fields, tt.conflicts)
+			}
+			if got := titles(merged.Outline); !slices.Equal(got, titles(tt.ours.Outline)) {
+				t.Fatalf("merged titles = %v, want ours %v", got, titles(tt.ours.Outline))
+			}
+		})
+	}
+}
+
+func TestMergeOutlinesSiblingOrder(t *testing.T) {
+	base := outlineOf(item(1, "一"), item(2, "二"), item(3, "三"))
+	tests := []struct {
+		name     string
+		ours     *models.NovelOutline
+		theirs   *models.NovelOutline
+		want     []string
+		conflict bool
+	}{
+		{"only theirs reorders", outlineOf(item(1, "一"), item(2, "二"), item(3, "三")), outlineOf(item(3, "三"), item(1, "一"), item(2, "二")), []string{"三", "一", "二"}, false},
+		{"ours adds, theirs reorders", outlineOf(item(1, "一"), item(2, "二"), item(3, "三"), item(4, "四")), outlineOf(item(3, "三"), item(1, "一"), item(2, "二")), []string{"三", "四", "一", "二"}, false},
+		{"same reorder on both sides", outlineOf(item(2, "二"), item(1, "一"), item(3, "三")), outlineOf(item(2, "二"), item(1, "一"), item(3, "三")), []string{"二", "一", "三"}, false},
+		{"different reorders", outlineOf(item(2, "二"), item(1, "一"), item(3, "三")), outlineOf(item(1, "一"), item(3, "三"), item(2, "二")), []string{"二", "一", "三"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := MergeOutlines(base, tt.ours, tt.theirs)
+			if got := titles(merged.Outline); !slices.Equal(got, tt.want) {
+				t.Fatalf("merged titles = %v, want %v", got, tt.want)
+			}
+			if !tt.conflict {
+				if len(conflicts) != 0 {
+					t.Fatalf("unexpected conflicts: %+v", conflicts)
+				}
+				return
+			}
+			if len(conflicts) != 1 || conflicts[0].Field != "order" || conflicts[0].NodeID != 0 {
+				t.Fatalf("conflicts = %+v, want one order conflict on the root", conflicts)
+			}
+			if conflicts[0].Ours != "二、一、三" || conflicts[0].Theirs != "一、三、二" {
+				t.Fatalf("order conflict = %+v", conflicts[0])
+			}
+		})
+	}
+}
+
+func withCharacters(o *models.NovelOutline, characters ...models.Character) *models.NovelOutline {
+	o.WorldBuilding.Characters = characters
+	return o
+}
+
+func TestMergeOutlinesCharactersByID(t *testing.T) {
+	base := withCharacters(outlineOf(), models.Character{ID: 1, Name: "林动", Description: "少年"})
+	ours := withCharacters(outlineOf(), models.Character{ID: 1, Name: "林动（主角）", Description: "少年"})
+	theirs := withCharacters(outlineOf(), models.Character{ID: 1, Name: "林动", Description: "少年武者"}, models.Character{ID: 2, Name: "小貂"})
+
+	merged, conflicts := MergeOutlines(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+	want := []models.Character{{ID: 1, Name: "林动（主角）", Description: "少年武者"}, {ID: 2, Name: "小貂"}}
+	if !slices.Equal(merged.WorldBuilding.Characters, want) {
+		t.Fatalf("merged characters = %+v, want %+v", merged.WorldBuilding.Characters, want)
+	}
+
+	theirs = withCharacters(outlineOf(), models.Character{ID: 1, Name: "林动（少年）", Description: "少年"})
+	_, conflicts = MergeOutlines(base, ours, theirs)
+	if len(conflicts) != 1 || conflicts[0].Scope != "character" || conflicts[0].Field != "name" || conflicts[0].NodeID != 1 {
+		t.Fatalf("conflicts = %+v, want one character name conflict", conflicts)
+	}
+}
+
+func TestDiffOutlinesCharacterRename(t *testing.T) {
+	from := withCharacters(outlineOf(), models.Character{ID: 1, Name: "林动"})
+	to := withCharacters(outlineOf(), models.Character{ID: 1, Name: "林动（主角）"})
+	changes := DiffOutlines(from, to)
+	if len(changes) != 1 || changes[0].Type != OutlineChangeRenamed || changes[0].OldName != "林动" || changes[0].NodeID != 1 {
+		t.Fatalf("changes = %+v, want one rename", changes)
+	}
+}
+
+func TestFillSettingIDs(t *testing.T) {
+	// 旧数据没有 ID：同名角色在各版本中分到相同的 ID，已有 ID 的沿用
+	head := withCharacters(outlineOf(), models.Character{Name: "林动"}, models.Character{Name: "小貂"})
+	submitted := withCharacters(outlineOf(), models.Character{Name: "小貂"}, models.Character{ID: 5, Name: "林动"}, models.Character{Name: "新角色"})
+	fillSettingIDs([]*models.NovelOutline{head, submitted})
+
+	ids := func(o *models.NovelOutline) []uint {
+		var result []uint
+		for _, c := range o.WorldBuilding.Characters {
+			result = append(result, c.ID)
+		}
+		return result
+	}
+	if got := ids(head); !slices.Equal(got, []uint{5, 6}) {
+		t.Fatalf("head ids = %v, want [5 6]", got)
+	}
+	if got := ids(submitted); !slices.Equal(got, []uint{6, 5, 7}) {
+		t.Fatalf("submitted ids = %v, want [6 5 7]", got)
+	}
+	if err := checkSettingIDs(characterSettings(append(submitted.WorldBuilding.Characters, models.Character{ID: 7}))); !errors.Is(err, ErrOutlineDuplicateID) {
+		t.Fatalf("err = %v, want ErrOutlineDuplicateID", err)
+	}
+}
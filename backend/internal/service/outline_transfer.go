@@ -0,0 +1,565 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 大纲导入导出支持的格式
+const (
+	OutlineFormatOPML     = "opml"
+	OutlineFormatMarkdown = "markdown"
+)
+
+// Markdown 导出样式
+const (
+	MarkdownStyleHeadings = "headings"
+	MarkdownStyleList     = "list"
+)
+
+// 大纲导入限制
+const (
+	MaxOutlineImportSize  = 1 << 20 // 导入文件最大 1MB
+	MaxOutlineDepth       = 6       // 最大层级，与 Markdown 标题层级一致
+	MaxOutlineNodes       = 2000    // 最大节点数
+	MaxOutlineTitleLength = 200     // 节点标题最大长度（字符）
+	MaxWorldBuildingItems = 500     // 角色、地点各自的最大数量
+)
+
+// OPML 中世界观节点使用的 type 属性
+const (
+	opmlTypeWorldBuilding = "worldBuilding"
+	opmlTypeCharacters    = "characters"
+	opmlTypeLocations     = "locations"
+)
+
+var (
+	ErrUnsupportedOutlineFormat = errors.New("unsupported outline format")
+	ErrOutlineImportTooLarge    = errors.New("outline file is too large")
+)
+
+// OutlineImportPreview 大纲导入预览
+type OutlineImportPreview struct {
+	Outline          *models.NovelOutline `json:"outline"`
+	NodeCount        int                  `json:"nodeCount"`
+	MaxDepth         int                  `json:"maxDepth"`
+	CharacterCount   int                  `json:"characterCount"`
+	LocationCount    int                  `json:"locationCount"`
+	CurrentNodeCount int                  `json:"currentNodeCount"` // 将被替换的现有大纲节点数
+	Warnings         []string             `json:"warnings"`
+}
+
+// opml 文档结构
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	Note     string        `xml:"_note,attr,omitempty"`
+	Children []opmlOutline `xml:"outline"`
+}
+
+// markdownFrontMatter Markdown front-matter 中的世界观设定
+type markdownFrontMatter struct {
+	Background string                 `yaml:"background,omitempty"`
+	Characters []markdownNamedSetting `yaml:"characters,omitempty"`
+	Locations  []markdownNamedSetting `yaml:"locations,omitempty"`
+}
+
+type markdownNamedSetting struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// DetectOutlineFormat 根据文件名和内容推断导入格式
+func DetectOutlineFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".opml", ".xml":
+		return OutlineFormatOPML
+	case ".md", ".markdown", ".txt":
+		return OutlineFormatMarkdown
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return OutlineFormatOPML
+	}
+	return OutlineFormatMarkdown
+}
+
+// ExportNovelOutline 将小说大纲导出为指定格式
+func (s *NovelService) ExportNovelOutline(novelID uint, authorID uint, format, style string) ([]byte, error) {
+	var novel models.Novel
+	if err := s.db.Where("id = ? AND author_id = ?", novelID, authorID).First(&novel).Error; err != nil {
+		return nil, err
+	}
+	outline := novel.NovelOutline
+	if outline == nil {
+		outline = emptyOutline()
+	}
+	normalizeOutline(outline)
+
+	switch format {
+	case OutlineFormatOPML:
+		return EncodeOutlineOPML(novel.Title, outline)
+	case OutlineFormatMarkdown:
+		return EncodeOutlineMarkdown(outline, style)
+	default:
+		return nil, ErrUnsupportedOutlineFormat
+	}
+}
+
+// PreviewOutlineImport 解析导入文件并返回预览，不修改数据
+func (s *NovelService) PreviewOutlineImport(novelID uint, authorID uint, format string, data []byte) (*OutlineImportPreview, error) {
+	var novel models.Novel
+	if err := s.db.Where("id = ? AND author_id = ?", novelID, authorID).First(&novel).Error; err != nil {
+		return nil, err
+	}
+
+	outline, warnings, err := ParseOutline(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &OutlineImportPreview{
+		Outline:        outline,
+		NodeCount:      countOutlineNodes(outline.Outline),
+		MaxDepth:       outlineDepth(outline.Outline),
+		CharacterCount: len(outline.WorldBuilding.Characters),
+		LocationCount:  len(outline.WorldBuilding.Locations),
+		Warnings:       warnings,
+	}
+	if novel.NovelOutline != nil {
+		preview.CurrentNodeCount = countOutlineNodes(novel.NovelOutline.Outline)
+	}
+	return preview, nil
+}
+
+// ImportNovelOutline 解析导入文件并替换现有大纲
+func (s *NovelService) ImportNovelOutline(novelID uint, authorID uint, format string, data []byte) (*OutlineImportPreview, error) {
+	preview, err := s.PreviewOutlineImport(novelID, authorID, format, data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.CommitNovelOutline(novelID, authorID, preview.Outline, OutlineCommitOptions{Source: models.OutlineSourceImport}); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// ParseOutline 按格式解析大纲并校验层级和规模限制
+func ParseOutline(format string, data []byte) (*models.NovelOutline, []string, error) {
+	if len(data) > MaxOutlineImportSize {
+		return nil, nil, ErrOutlineImportTooLarge
+	}
+	if !utf8.Valid(data) {
+		return nil, nil, errors.New("outline file must be UTF-8 encoded")
+	}
+
+	var outline *models.NovelOutline
+	var warnings []string
+	var err error
+	switch format {
+	case OutlineFormatOPML:
+		outline, warnings, err = DecodeOutlineOPML(data)
+	case OutlineFormatMarkdown:
+		outline, warnings, err = DecodeOutlineMarkdown(data)
+	default:
+		return nil, nil, ErrUnsupportedOutlineFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validateOutline(outline); err != nil {
+		return nil, nil, err
+	}
+	assignOutlineIDs(outline.Outline, new(uint))
+	return outline, warnings, nil
+}
+
+// EncodeOutlineOPML 导出为 OPML 2.0
+func EncodeOutlineOPML(title string, outline *models.NovelOutline) ([]byte, error) {
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       title,
+			DateCreated: time.Now().Format(time.RFC1123Z),
+		},
+	}
+	doc.Body.Outlines = toOPMLOutlines(outline.Outline)
+
+	wb := outline.WorldBuilding
+	if wb.Background != "" || len(wb.Characters) > 0 || len(wb.Locations) > 0 {
+		world := opmlOutline{Text: "世界观设定", Type: opmlTypeWorldBuilding, Note: wb.Background}
+		characters := opmlOutline{Text: "角色", Type: opmlTypeCharacters}
+		for _, c := range wb.Characters {
+			characters.Children = append(characters.Children, opmlOutline{Text: c.Name, Note: c.Description})
+		}
+		locations := opmlOutline{Text: "地点", Type: opmlTypeLocations}
+		for _, l := range wb.Locations {
+			locations.Children = append(locations.Children, opmlOutline{Text: l.Name, Note: l.Description})
+		}
+		world.Children = []opmlOutline{characters, locations}
+		doc.Body.Outlines = append(doc.Body.Outlines, world)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// DecodeOutlineOPML 解析 OPML 文档
+func DecodeOutlineOPML(data []byte) (*models.NovelOutline, []string, error) {
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid OPML: %w", err)
+	}
+
+	var warnings []string
+	if doc.Version != "" && !strings.HasPrefix(doc.Version, "2") {
+		warnings = append(warnings, fmt.Sprintf("OPML 版本为 %s，按 2.0 解析", doc.Version))
+	}
+
+	outline := emptyOutline()
+	for _, o := range doc.Body.Outlines {
+		if o.Type == opmlTypeWorldBuilding {
+			outline.WorldBuilding.Background = o.Note
+			for _, group := range o.Children {
+				for _, item := range group.Children {
+					name := opmlText(item)
+					switch group.Type {
+					case opmlTypeCharacters:
+						outline.WorldBuilding.Characters = append(outline.WorldBuilding.Characters, models.Character{Name: name, Description: item.Note})
+					case opmlTypeLocations:
+						outline.WorldBuilding.Locations = append(outline.WorldBuilding.Locations, models.Location{Name: name, Description: item.Note})
+					}
+				}
+			}
+			continue
+		}
+		outline.Outline = append(outline.Outline, fromOPMLOutline(o, &warnings))
+	}
+	return outline, warnings, nil
+}
+
+func toOPMLOutlines(items []models.OutlineItem) []opmlOutline {
+	result := make([]opmlOutline, 0, len(items))
+	for _, item := range items {
+		result = append(result, opmlOutline{
+			Text:     item.Title,
+			Note:     item.Description,
+			Children: toOPMLOutlines(item.Children),
+		})
+	}
+	return result
+}
+
+func fromOPMLOutline(o opmlOutline, warnings *[]string) models.OutlineItem {
+	item := models.OutlineItem{
+		Title:       opmlText(o),
+		Description: o.Note,
+		Children:    []models.OutlineItem{},
+	}
+	if item.Title == "" {
+		item.Title = "未命名"
+		*warnings = append(*warnings, "存在缺少标题的节点，已命名为“未命名”")
+	}
+	for _, child := range o.Children {
+		item.Children = append(item.Children, fromOPMLOutline(child, warnings))
+	}
+	return item
+}
+
+// opmlText 优先使用 text 属性，部分思维导图工具只写 title
+func opmlText(o opmlOutline) string {
+	if o.Text != "" {
+		return strings.TrimSpace(o.Text)
+	}
+	return strings.TrimSpace(o.Title)
+}
+
+// EncodeOutlineMarkdown 导出为 Markdown，世界观设定写入 front-matter
+func EncodeOutlineMarkdown(outline *models.NovelOutline, style string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	wb := outline.WorldBuilding
+	if wb.Background != "" || len(wb.Characters) > 0 || len(wb.Locations) > 0 {
+		fm := markdownFrontMatter{Background: wb.Background}
+		for _, c := range wb.Characters {
+			fm.Characters = append(fm.Characters, markdownNamedSetting{Name: c.Name, Description: c.Description})
+		}
+		for _, l := range wb.Locations {
+			fm.Locations = append(fm.Locations, markdownNamedSetting{Name: l.Name, Description: l.Description})
+		}
+		out, err := yaml.Marshal(fm)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(out)
+		buf.WriteString("---\n\n")
+	}
+
+	switch style {
+	case MarkdownStyleList:
+		writeMarkdownList(&buf, outline.Outline, 0)
+	case "", MarkdownStyleHeadings:
+		writeMarkdownHeadings(&buf, outline.Outline, 1)
+	default:
+		return nil, fmt.Errorf("unsupported markdown style: %s", style)
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMarkdownHeadings(buf *bytes.Buffer, items []models.OutlineItem, level int) {
+	for _, item := range items {
+		if level <= MaxOutlineDepth {
+			fmt.Fprintf(buf, "%s %s\n\n", strings.Repeat("#", level), item.Title)
+		} else {
+			fmt.Fprintf(buf, "- %s\n\n", item.Title)
+		}
+		if desc := strings.TrimSpace(item.Description); desc != "" {
+			buf.WriteString(desc)
+			buf.WriteString("\n\n")
+		}
+		writeMarkdownHeadings(buf, item.Children, level+1)
+	}
+}
+
+func writeMarkdownList(buf *bytes.Buffer, items []models.OutlineItem, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, item := range items {
+		fmt.Fprintf(buf, "%s- %s\n", indent, item.Title)
+		if desc := strings.TrimSpace(item.Description); desc != "" {
+			for _, line := range strings.Split(desc, "\n") {
+				fmt.Fprintf(buf, "%s  %s\n", indent, line)
+			}
+		}
+		writeMarkdownList(buf, item.Children, depth+1)
+	}
+}
+
+var (
+	markdownHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	markdownListItemPattern = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+)
+
+// markdownNode 解析 Markdown 时使用的中间节点
+type markdownNode struct {
+	title       string
+	description []string
+	rank        int // 标题为 1-6，列表项为 10 + 缩进宽度
+	children    []*markdownNode
+}
+
+// DecodeOutlineMarkdown 解析 Markdown 嵌套列表或标题形式的大纲
+func DecodeOutlineMarkdown(data []byte) (*models.NovelOutline, []string, error) {
+	outline := emptyOutline()
+	var warnings []string
+
+	body, fm, err := splitFrontMatter(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fm != nil {
+		outline.WorldBuilding.Background = fm.Background
+		for _, c := range fm.Characters {
+			outline.WorldBuilding.Characters = append(outline.WorldBuilding.Characters, models.Character{Name: c.Name, Description: c.Description})
+		}
+		for _, l := range fm.Locations {
+			outline.WorldBuilding.Locations = append(outline.WorldBuilding.Locations, models.Location{Name: l.Name, Description: l.Description})
+		}
+	}
+
+	root := &markdownNode{rank: 0}
+	stack := []*markdownNode{root}
+	push := func(node *markdownNode) {
+		for len(stack) > 1 && stack[len(stack)-1].rank >= node.rank {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+		parent.children = append(parent.children, node)
+		stack = append(stack, node)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxOutlineImportSize)
+	inCodeBlock := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if m := markdownHeadingPattern.FindStringSubmatch(line); m != nil {
+			push(&markdownNode{title: m[2], rank: len(m[1])})
+			continue
+		}
+		if m := markdownListItemPattern.FindStringSubmatch(line); m != nil {
+			indent := strings.ReplaceAll(m[1], "\t", "    ")
+			push(&markdownNode{title: m[2], rank: 10 + len(indent)})
+			continue
+		}
+
+		current := stack[len(stack)-1]
+		if current == root {
+			warnings = append(warnings, "忽略了位于第一个标题或列表项之前的正文")
+			continue
+		}
+		current.description = append(current.description, strings.TrimSpace(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	outline.Outline = fromMarkdownNodes(root.children, &warnings)
+	return outline, warnings, nil
+}
+
+func fromMarkdownNodes(nodes []*markdownNode, warnings *[]string) []models.OutlineItem {
+	items := make([]models.OutlineItem, 0, len(nodes))
+	for _, node := range nodes {
+		title := strings.TrimSpace(strings.Trim(node.title, "*_"))
+		if title == "" {
+			title = "未命名"
+			*warnings = append(*warnings, "存在缺少标题的节点，已命名为“未命名”")
+		}
+		items = append(items, models.OutlineItem{
+			Title:       title,
+			Description: strings.Join(node.description, "\n"),
+			Children:    fromMarkdownNodes(node.children, warnings),
+		})
+	}
+	return items
+}
+
+// splitFrontMatter 拆分 YAML front-matter 与正文
+func splitFrontMatter(data []byte) ([]byte, *markdownFrontMatter, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	normalized := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(normalized, []byte("---\n")) {
+		return normalized, nil, nil
+	}
+
+	rest := normalized[len("---\n"):]
+	end := bytes.Index(rest, []byte("\n---"))
+	if end < 0 {
+		return nil, nil, errors.New("unterminated front-matter")
+	}
+
+	var fm markdownFrontMatter
+	if err := yaml.Unmarshal(rest[:end], &fm); err != nil {
+		return nil, nil, fmt.Errorf("invalid front-matter: %w", err)
+	}
+
+	body := rest[end+len("\n---"):]
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = nil
+	}
+	return body, &fm, nil
+}
+
+// validateOutline 校验导入大纲的层级、规模和字段长度
+func validateOutline(outline *models.NovelOutline) error {
+	if n := countOutlineNodes(outline.Outline); n > MaxOutlineNodes {
+		return fmt.Errorf("outline has %d nodes, exceeds limit of %d", n, MaxOutlineNodes)
+	}
+	if d := outlineDepth(outline.Outline); d > MaxOutlineDepth {
+		return fmt.Errorf("outline depth %d exceeds limit of %d", d, MaxOutlineDepth)
+	}
+	if len(outline.WorldBuilding.Characters) > MaxWorldBuildingItems {
+		return fmt.Errorf("too many characters, limit is %d", MaxWorldBuildingItems)
+	}
+	if len(outline.WorldBuilding.Locations) > MaxWorldBuildingItems {
+		return fmt.Errorf("too many locations, limit is %d", MaxWorldBuildingItems)
+	}
+
+	var checkTitles func(items []models.OutlineItem) error
+	checkTitles = func(items []models.OutlineItem) error {
+		for _, item := range items {
+			if utf8.RuneCountInString(item.Title) > MaxOutlineTitleLength {
+				return fmt.Errorf("outline title too long: %.20s...", item.Title)
+			}
+			if err := checkTitles(item.Children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return checkTitles(outline.Outline)
+}
+
+// assignOutlineIDs 为导入的节点分配 ID 和同级排序
+func assignOutlineIDs(items []models.OutlineItem, next *uint) {
+	for i := range items {
+		*next++
+		items[i].ID = *next
+		items[i].Order = i + 1
+		if items[i].Children == nil {
+			items[i].Children = []models.OutlineItem{}
+		}
+		assignOutlineIDs(items[i].Children, next)
+	}
+}
+
+func countOutlineNodes(items []models.OutlineItem) int {
+	count := len(items)
+	for _, item := range items {
+		count += countOutlineNodes(item.Children)
+	}
+	return count
+}
+
+func outlineDepth(items []models.OutlineItem) int {
+	depth := 0
+	for _, item := range items {
+		if d := outlineDepth(item.Children) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+func emptyOutline() *models.NovelOutline {
+	return &models.NovelOutline{
+		Outline: []models.OutlineItem{},
+		WorldBuilding: models.WorldBuilding{
+			Background: "",
+			Characters: []models.Character{},
+			Locations:  []models.Location{},
+		},
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	paragraphSnippetLength = 80  // 段落指纹中保存的文本长度（字符）
+	paragraphMatchMinScore = 0.6 // 模糊匹配重新定位段落的最低相似度
+)
+
+var (
+	paragraphTagPattern = regexp.MustCompile(`(?i)</p\s*>|<br\s*/?>`)
+	htmlTagPattern      = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ParagraphFingerprint 段落指纹：规范化文本的哈希及开头片段，用于章节修改后重新定位段落
+type ParagraphFingerprint struct {
+	Hash    string
+	Snippet string
+}
+
+// SplitParagraphs 按阅读器的规则切分章节段落：以换行或 </p>、<br> 分段，去除 HTML 标签，跳过空段落。
+// 段评的段落序号和阅读进度的 ParagraphIndex 都基于此切分结果。
+func SplitParagraphs(content string) []string {
+	content = paragraphTagPattern.ReplaceAllString(content, "\n")
+	content = htmlTagPattern.ReplaceAllString(content, "")
+
+	var paragraphs []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimFunc(line, unicode.IsSpace)
+		if line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return paragraphs
+}
+
+// FingerprintParagraph 计算段落指纹，忽略空白和标点，使排版上的小改动不影响指纹
+func FingerprintParagraph(paragraph string) ParagraphFingerprint {
+	normalized := normalizeParagraph(paragraph)
+	h := fnv.New64a()
+	h.Write([]byte(normalized))
+
+	snippet := []rune(normalized)
+	if len(snippet) > paragraphSnippetLength {
+		snippet = snippet[:paragraphSnippetLength]
+	}
+	return ParagraphFingerprint{
+		Hash:    fmt.Sprintf("%016x", h.Sum64()),
+		Snippet: string(snippet),
+	}
+}
+
+// LocateParagraph 在新的段落指纹中定位原段落：优先找指纹完全一致且离原位置最近的段落，
+// 否则按开头片段的相似度模糊匹配，仍找不到时返回 false
+func LocateParagraph(fingerprints []ParagraphFingerprint, index int, anchor ParagraphFingerprint) (int, bool) {
+	if index >= 0 && index < len(fingerprints) && fingerprints[index].Hash == anchor.Hash {
+		return index, true
+	}
+
+	best, bestDistance := -1, 0
+	for i, fp := range fingerprints {
+		if fp.Hash != anchor.Hash {
+			continue
+		}
+		if d := abs(i - index); best < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	if best >= 0 {
+		return best, true
+	}
+
+	bestScore := 0.0
+	for i, fp := range fingerprints {
+		score := bigramSimilarity(anchor.Snippet, fp.Snippet)
+		d := abs(i - index)
+		if score > bestScore || (score == bestScore && best >= 0 && d < bestDistance) {
+			best, bestScore, bestDistance = i, score, d
+		}
+	}
+	if best >= 0 && bestScore >= paragraphMatchMinScore {
+		return best, true
+	}
+	return index, false
+}
+
+func normalizeParagraph(paragraph string) string {
+	var b strings.Builder
+	for _, r := range paragraph {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// bigramSimilarity 计算两段文本字符二元组的 Dice 系数
+func bigramSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		if a == b && a != "" {
+			return 1
+		}
+		return 0
+	}
+
+	counts := make(map[[2]rune]int, len(ra))
+	for i := 0; i+1 < len(ra); i++ {
+		counts[[2]rune{ra[i], ra[i+1]}]++
+	}
+	matches := 0
+	for i := 0; i+1 < len(rb); i++ {
+		key := [2]rune{rb[i], rb[i+1]}
+		if counts[key] > 0 {
+			counts[key]--
+			matches++
+		}
+	}
+	return 2 * float64(matches) / float64(len(ra)+len(rb)-2)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
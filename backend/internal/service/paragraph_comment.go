@@ -0,0 +1,124 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ParagraphCommentCount 段落的段评数量
+type ParagraphCommentCount struct {
+	ParagraphIndex int   `json:"paragraphIndex"`
+	Count          int64 `json:"count"`
+}
+
+// ParagraphCommentSummary 章节的段评统计
+type ParagraphCommentSummary struct {
+	Paragraphs []ParagraphCommentCount `json:"paragraphs"`
+	Orphaned   int64                   `json:"orphaned"` // 无法重新定位的段评数量
+}
+
+// anchorParagraph 校验段落序号并记录段落指纹
+func (s *CommentService) anchorParagraph(comment *models.Comment) error {
+	if comment.ChapterID == 0 {
+		return errors.New("paragraph comments require a chapter")
+	}
+	var chapter models.Chapter
+	if err := s.db.Select("id", "content").First(&chapter, comment.ChapterID).Error; err != nil {
+		return errors.New("chapter not found")
+	}
+
+	paragraphs := SplitParagraphs(chapter.Content)
+	index := *comment.ParagraphIndex
+	if index < 0 || index >= len(paragraphs) {
+		return errors.New("paragraph index out of range")
+	}
+	fp := FingerprintParagraph(paragraphs[index])
+	comment.ParagraphHash = fp.Hash
+	comment.ParagraphText = fp.Snippet
+	comment.AnchorOrphaned = false
+	return nil
+}
+
+// GetParagraphCommentCounts 一次性获取章节各段落的段评数量（含回复），供阅读器展示角标
+func (s *CommentService) GetParagraphCommentCounts(chapterID uint) (*ParagraphCommentSummary, error) {
+	summary := &ParagraphCommentSummary{Paragraphs: []ParagraphCommentCount{}}
+	query := s.db.Model(&models.Comment{}).
+		Where("chapter_id = ? AND paragraph_index IS NOT NULL AND status = ?", chapterID, models.CommentStatusNormal)
+
+	if err := query.Session(&gorm.Session{}).
+		Where("anchor_orphaned = ?", false).
+		Select("paragraph_index, COUNT(*) AS count").
+		Group("paragraph_index").
+		Order("paragraph_index asc").
+		Scan(&summary.Paragraphs).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Where("anchor_orphaned = ?", true).Count(&summary.Orphaned).Error; err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ReanchorParagraphComments 章节内容修改后重新定位段评：指纹一致的段落直接对应，
+// 否则按段落开头片段模糊匹配，仍无法定位的标记为孤立
+func (s *CommentService) ReanchorParagraphComments(chapterID uint) error {
+	var chapter models.Chapter
+	if err := s.db.Select("id", "content").First(&chapter, chapterID).Error; err != nil {
+		return err
+	}
+	paragraphs := SplitParagraphs(chapter.Content)
+	fingerprints := make([]ParagraphFingerprint, len(paragraphs))
+	for i, p := range paragraphs {
+		fingerprints[i] = FingerprintParagraph(p)
+	}
+
+	var comments []models.Comment
+	if err := s.db.Unscoped().
+		Select("id", "paragraph_index", "paragraph_hash", "paragraph_text", "anchor_orphaned").
+		Where("chapter_id = ? AND paragraph_index IS NOT NULL", chapterID).
+		Find(&comments).Error; err != nil {
+		return err
+	}
+
+	// 同一段落上的评论一起处理
+	type anchor struct {
+		index int
+		hash  string
+	}
+	groups := make(map[anchor][]models.Comment)
+	for _, c := range comments {
+		key := anchor{*c.ParagraphIndex, c.ParagraphHash}
+		groups[key] = append(groups[key], c)
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for key, group := range groups {
+			fp := ParagraphFingerprint{Hash: key.hash, Snippet: group[0].ParagraphText}
+			index, ok := LocateParagraph(fingerprints, key.index, fp)
+
+			updates := map[string]interface{}{"anchor_orphaned": !ok}
+			if ok {
+				updates["paragraph_index"] = index
+				updates["paragraph_hash"] = fingerprints[index].Hash
+				updates["paragraph_text"] = fingerprints[index].Snippet
+				if index == key.index && fingerprints[index].Hash == key.hash && !group[0].AnchorOrphaned {
+					continue
+				}
+			} else if group[0].AnchorOrphaned {
+				continue
+			}
+
+			ids := make([]uint, len(group))
+			for i, c := range group {
+				ids[i] = c.ID
+			}
+			if err := tx.Unscoped().Model(&models.Comment{}).Where("id IN ?", ids).
+				UpdateColumns(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	rankingKeyPrefix        = "ranking:"
+	rankingBucketTTL        = 62 * 24 * time.Hour // 保留两个月，飙升榜需要对比上一周期
+	rankingTopN             = 100
+	rankingMaterializeEvery = 10 * time.Minute
+	rankingRisingSmoothing  = 10.0 // 飙升榜分母平滑，避免极少阅读量的小说排在前面
+	rankingFavoriteWeight   = 10   // 人气中一次收藏相当于多少次阅读
+	rankingRedisTimeout     = 2 * time.Second
+)
+
+// 按天分桶的互动指标
+const (
+	rankingMetricRead     = "read"
+	rankingMetricFavorite = "favorite"
+)
+
+var (
+	ErrInvalidRankingBoard  = errors.New("invalid ranking board")
+	ErrInvalidRankingPeriod = errors.New("invalid ranking period")
+)
+
+var rankingBoards = []string{
+	models.RankingBoardRead,
+	models.RankingBoardFavorite,
+	models.RankingBoardRising,
+	models.RankingBoardCompleted,
+	models.RankingBoardRated,
+}
+
+// rankingPeriodDays 各周期包含的天数，总榜不按天统计
+var rankingPeriodDays = map[string]int{
+	models.RankingPeriodDay:   1,
+	models.RankingPeriodWeek:  7,
+	models.RankingPeriodMonth: 30,
+	models.RankingPeriodAll:   0,
+}
+
+// RankingItem 榜单条目
+type RankingItem struct {
+	Rank  int          `json:"rank"`
+	Score float64      `json:"score"`
+	Novel models.Novel `json:"novel"`
+}
+
+// rankingNovel 计算榜单所需的小说字段
+type rankingNovel struct {
+	ID            uint
+	Category      string
+	ReadCount     int
+	FavoriteCount int
+	RatingCount   int
+	RatingScore   float64
+	CompletedAt   *time.Time
+}
+
+type RankingService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewRankingService 创建榜单服务，rdb 为 nil 时只有总榜可用
+func NewRankingService(db *gorm.DB, rdb *redis.Client) *RankingService {
+	return &RankingService{db: db, rdb: rdb}
+}
+
+// RecordRead 记录一次阅读
+func (s *RankingService) RecordRead(novelID uint) {
+	s.record(novelID, map[string]float64{rankingMetricRead: 1})
+}
+
+// RecordFavorite 记录收藏（delta 为 1）或取消收藏（delta 为 -1）
+func (s *RankingService) RecordFavorite(novelID uint, delta int) {
+	s.record(novelID, map[string]float64{rankingMetricFavorite: float64(delta)})
+}
+
+// record 写入当天的分桶，失败只记录日志，不影响业务
+func (s *RankingService) record(novelID uint, increments map[string]float64) {
+	if s == nil || s.rdb == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), rankingRedisTimeout)
+	defer cancel()
+
+	day := time.Now()
+	member := strconv.FormatUint(uint64(novelID), 10)
+	pipe := s.rdb.Pipeline()
+	for metric, delta := range increments {
+		key := rankingBucketKey(metric, day)
+		pipe.ZIncrBy(ctx, key, delta, member)
+		pipe.Expire(ctx, key, rankingBucketTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to record ranking event for novel %d: %v", novelID, err)
+	}
+}
+
+func rankingBucketKey(metric string, day time.Time) string {
+	return rankingKeyPrefix + metric + ":" + day.Format("20060102")
+}
+
+// Start 启动后台任务，定期把榜单物化到 MySQL，ctx 结束时退出
+func (s *RankingService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(rankingMaterializeEvery)
+		defer ticker.Stop()
+		for {
+			if err := s.Materialize(ctx); err != nil {
+				log.Printf("Failed to materialize rankings: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Materialize 计算所有榜单、周期和分类的前 rankingTopN 名，整体替换快照
+func (s *RankingService) Materialize(ctx context.Context) error {
+	var novels []rankingNovel
+	if err := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Select("id, category, read_count, favorite_count, rating_count, rating_score, completed_at").
+		Scan(&novels).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]rankingNovel, len(novels))
+	for _, n := range novels {
+		byID[n.ID] = n
+	}
+
+	now := time.Now()
+	var entries []models.RankingEntry
+	for _, board := range rankingBoards {
+		for period := range rankingPeriodDays {
+			if !rankingSupported(board, period) {
+				continue
+			}
+			scores, err := s.scores(ctx, board, period, novels, now)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, rankEntries(board, period, scores, byID, now)...)
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.RankingEntry{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(entries, 500).Error
+	})
+}
+
+// scores 计算某个榜单在某个周期内每本小说的得分，只返回得分为正的小说
+func (s *RankingService) scores(ctx context.Context, board, period string, novels []rankingNovel, now time.Time) (map[uint]float64, error) {
+	days := rankingPeriodDays[period]
+	scores := make(map[uint]float64)
+
+	switch board {
+	case models.RankingBoardRead, models.RankingBoardFavorite:
+		if days == 0 {
+			for _, n := range novels {
+				if board == models.RankingBoardRead {
+					scores[n.ID] = float64(n.ReadCount)
+				} else {
+					scores[n.ID] = float64(n.FavoriteCount)
+				}
+			}
+			break
+		}
+		metric := rankingMetricRead
+		if board == models.RankingBoardFavorite {
+			metric = rankingMetricFavorite
+		}
+		sums, err := s.sumBuckets(ctx, metric, now, 0, days)
+		if err != nil {
+			return nil, err
+		}
+		scores = sums
+
+	case models.RankingBoardRising:
+		current, err := s.sumBuckets(ctx, rankingMetricRead, now, 0, days)
+		if err != nil {
+			return nil, err
+		}
+		previous, err := s.sumBuckets(ctx, rankingMetricRead, now, days, days)
+		if err != nil {
+			return nil, err
+		}
+		for id, cur := range current {
+			scores[id] = (cur - previous[id]) / (previous[id] + rankingRisingSmoothing)
+		}
+
+	case models.RankingBoardCompleted:
+		since := now.AddDate(0, 0, -days)
+		for _, n := range novels {
+			if n.CompletedAt == nil || (days > 0 && n.CompletedAt.Before(since)) {
+				continue
+			}
+			// 新完结的小说按人气排序，加 1 保证没有互动的新完结作品也能上榜
+			scores[n.ID] = float64(n.ReadCount+n.FavoriteCount*rankingFavoriteWeight) + 1
+		}
+
+	case models.RankingBoardRated:
+		if days == 0 {
+			for _, n := range novels {
+				if n.RatingCount > 0 {
+					scores[n.ID] = n.RatingScore
+				}
+			}
+			break
+		}
+		// 周期榜只统计周期内新写的书评，按书评当前的分数计算，修改和删除不会让分数越界
+		var rows []struct {
+			NovelID uint
+			Sum     float64
+			Count   float64
+		}
+		if err := s.db.WithContext(ctx).Model(&models.Review{}).
+			Select("novel_id, SUM(score) AS sum, COUNT(*) AS count").
+			Where("created_at >= ?", now.AddDate(0, 0, -days)).
+			Group("novel_id").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			scores[row.NovelID] = bayesianRating(row.Sum, row.Count)
+		}
+	}
+
+	for id, score := range scores {
+		if score <= 0 {
+			delete(scores, id)
+		}
+	}
+	return scores, nil
+}
+
+// sumBuckets 汇总从 offset 天前开始、连续 days 天的分桶
+func (s *RankingService) sumBuckets(ctx context.Context, metric string, now time.Time, offset, days int) (map[uint]float64, error) {
+	sums := make(map[uint]float64)
+	if s.rdb == nil || days == 0 {
+		return sums, nil
+	}
+
+	pipe := s.rdb.Pipeline()
+	cmds := make([]*redis.ZSliceCmd, 0, days)
+	for i := offset; i < offset+days; i++ {
+		cmds = append(cmds, pipe.ZRangeWithScores(ctx, rankingBucketKey(metric, now.AddDate(0, 0, -i)), 0, -1))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		for _, z := range cmd.Val() {
+			id, err := strconv.ParseUint(z.Member.(string), 10, 32)
+			if err != nil {
+				continue
+			}
+			sums[uint(id)] += z.Score
+		}
+	}
+	return sums, nil
+}
+
+// rankEntries 按得分排序，生成全站榜和各分类榜的前 rankingTopN 名
+func rankEntries(board, period string, scores map[uint]float64, novels map[uint]rankingNovel, now time.Time) []models.RankingEntry {
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		// 已删除的小说不会出现在 novels 中
+		if _, ok := novels[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] > ids[j]
+	})
+
+	var entries []models.RankingEntry
+	ranks := make(map[string]int)
+	for _, id := range ids {
+		categories := []string{""}
+		if category := novels[id].Category; category != "" {
+			categories = append(categories, category)
+		}
+		for _, category := range categories {
+			if ranks[category] >= rankingTopN {
+				continue
+			}
+			ranks[category]++
+			entries = append(entries, models.RankingEntry{
+				Board:      board,
+				Period:     period,
+				Category:   category,
+				Rank:       ranks[category],
+				NovelID:    id,
+				Score:      scores[id],
+				ComputedAt: now,
+			})
+		}
+	}
+	return entries
+}
+
+func rankingSupported(board, period string) bool {
+	if _, ok := rankingPeriodDays[period]; !ok {
+		return false
+	}
+	// 飙升榜需要对比上一周期，没有总榜
+	return !(board == models.RankingBoardRising && period == models.RankingPeriodAll)
+}
+
+// GetRanking 读取榜单快照；category 为空时返回全站榜
+func (s *RankingService) GetRanking(board, period, category string, limit int) ([]RankingItem, time.Time, error) {
+	valid := false
+	for _, b := range rankingBoards {
+		if b == board {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, time.Time{}, ErrInvalidRankingBoard
+	}
+	if !rankingSupported(board, period) {
+		return nil, time.Time{}, ErrInvalidRankingPeriod
+	}
+
+	var entries []models.RankingEntry
+	if err := s.db.Where("board = ? AND period = ? AND category = ?", board, period, category).
+		Order("`rank` asc").Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(entries) == 0 {
+		return []RankingItem{}, time.Time{}, nil
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.NovelID)
+	}
+	var novels []models.Novel
+	if err := s.db.Omit("novel_outline").Preload("Author").Scopes(models.VisibleNovels).
+		Where("id IN ?", ids).Find(&novels).Error; err != nil {
+		return nil, time.Time{}, err
+	}
+	byID := make(map[uint]models.Novel, len(novels))
+	for _, n := range novels {
+		byID[n.ID] = n
+	}
+
+	items := make([]RankingItem, 0, len(entries))
+	for _, e := range entries {
+		novel, ok := byID[e.NovelID]
+		if !ok {
+			// 快照生成后被删除的小说
+			continue
+		}
+		items = append(items, RankingItem{Rank: e.Rank, Score: e.Score, Novel: novel})
+	}
+	return items, entries[0].ComputedAt, nil
+}
@@ -2,44 +2,203 @@ package service
 
 import (
 	"ai-novel-platform/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// 多端进度冲突的处理策略
+const (
+	ProgressStrategyLatest = "latest" // 以客户端时间最新的一次为准
+	ProgressStrategyMax    = "max"    // 以读得最远的位置为准
+)
+
+const (
+	readProgressKeyPrefix   = "read_progress:"
+	readProgressDirtyKey    = "read_progress:dirty"
+	chapterInfoKeyPrefix    = "chapter_info:"
+	readProgressCacheTTL    = 7 * 24 * time.Hour
+	chapterInfoCacheTTL     = time.Hour
+	readProgressFlushBatch  = 500
+	maxClientClockSkewAhead = time.Minute
+	readProgressWatchRetry  = 5
+)
+
+var (
+	ErrChapterNotInNovel     = errors.New("chapter does not belong to novel or is not published")
+	ErrReadProgressContended = errors.New("reading progress is being updated concurrently")
+)
+
+// ProgressUpdate 客户端上报的阅读位置
+type ProgressUpdate struct {
+	ChapterID      uint
+	ParagraphIndex int
+	CharOffset     int
+	Percentage     float64
+	DeviceID       string
+	ClientTime     time.Time // 客户端记录时间，为零值时使用服务端时间
+	Strategy       string
+}
+
 type ReadProgressService struct {
-	db *gorm.DB
+	db  *gorm.DB
+	rdb *redis.Client
 }
 
-func NewReadProgressService(db *gorm.DB) *ReadProgressService {
-	return &ReadProgressService{db: db}
+// NewReadProgressService 创建阅读进度服务，rdb 为 nil 时直接写数据库
+func NewReadProgressService(db *gorm.DB, rdb *redis.Client) *ReadProgressService {
+	return &ReadProgressService{db: db, rdb: rdb}
 }
 
-// UpdateReadProgress 更新用户阅读进度
-func (s *ReadProgressService) UpdateReadProgress(userID, novelID, chapterID uint) error {
-	progress := models.ReadProgress{}
+// UpdateReadProgress 更新用户阅读进度，返回冲突处理后的进度以及本次上报是否被采用。
+// 启用 Redis 时进度先写入缓存，由 StartFlusher 批量落库。
+func (s *ReadProgressService) UpdateReadProgress(userID, novelID uint, update ProgressUpdate) (*models.ReadProgress, bool, error) {
+	now := time.Now()
+	if update.ClientTime.IsZero() || update.ClientTime.After(now.Add(maxClientClockSkewAhead)) {
+		update.ClientTime = now
+	}
+	if update.Percentage < 0 {
+		update.Percentage = 0
+	} else if update.Percentage > 100 {
+		update.Percentage = 100
+	}
 
-	// 查找是否存在记录
-	result := s.db.Where("user_id = ? AND novel_id = ?", userID, novelID).First(&progress)
+	if _, _, err := s.chapterInfo(update.ChapterID, novelID); err != nil {
+		return nil, false, err
+	}
 
-	if result.Error == gorm.ErrRecordNotFound {
-		// 不存在则创建新记录
-		progress = models.ReadProgress{
-			UserID:    userID,
-			NovelID:   novelID,
-			ChapterID: chapterID,
+	if s.rdb == nil {
+		current, err := s.GetReadProgress(userID, novelID)
+		if err != nil {
+			return nil, false, err
 		}
-		return s.db.Create(&progress).Error
-	} else if result.Error != nil {
-		return result.Error
+		progress, accepted := s.applyUpdate(current, userID, novelID, update, now)
+		if !accepted {
+			return current, false, nil
+		}
+		return progress, true, s.saveProgress([]models.ReadProgress{*progress})
+	}
+
+	// 读取、比较、写入在 WATCH 事务中完成，并发上报时后提交的一方重新比较，
+	// 避免 max 策略下较远的位置被同时到达的较近位置覆盖
+	ctx := context.Background()
+	key := readProgressKey(userID, novelID)
+	for attempt := 0; attempt < readProgressWatchRetry; attempt++ {
+		var result *models.ReadProgress
+		var accepted bool
+		err := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := s.loadProgress(ctx, tx, userID, novelID)
+			if err != nil {
+				return err
+			}
+			progress, ok := s.applyUpdate(current, userID, novelID, update, now)
+			if !ok {
+				result = current
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.HSet(ctx, key, progressToHash(progress))
+				pipe.Expire(ctx, key, readProgressCacheTTL)
+				pipe.SAdd(ctx, readProgressDirtyKey, fmt.Sprintf("%d:%d", userID, novelID))
+				return nil
+			})
+			result, accepted = progress, true
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return result, accepted, nil
+	}
+	return nil, false, ErrReadProgressContended
+}
+
+// applyUpdate 按冲突策略合并上报的位置，返回新进度以及是否采用
+func (s *ReadProgressService) applyUpdate(current *models.ReadProgress, userID, novelID uint, update ProgressUpdate, now time.Time) (*models.ReadProgress, bool) {
+	if current != nil && !s.shouldAccept(current, update) {
+		return current, false
+	}
+
+	progress := models.ReadProgress{
+		UserID:          userID,
+		NovelID:         novelID,
+		ChapterID:       update.ChapterID,
+		ParagraphIndex:  update.ParagraphIndex,
+		CharOffset:      update.CharOffset,
+		Percentage:      update.Percentage,
+		DeviceID:        update.DeviceID,
+		ClientUpdatedAt: update.ClientTime,
+		UpdatedAt:       now,
+	}
+	if current != nil {
+		progress.ID = current.ID
+		progress.CreatedAt = current.CreatedAt
+	} else {
+		progress.CreatedAt = now
+	}
+	return &progress, true
+}
+
+// loadProgress 在 WATCH 事务中读取当前进度，缓存未命中时读数据库但不回填缓存，以免改动被监视的键
+func (s *ReadProgressService) loadProgress(ctx context.Context, tx *redis.Tx, userID, novelID uint) (*models.ReadProgress, error) {
+	values, err := tx.HGetAll(ctx, readProgressKey(userID, novelID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > 0 {
+		return progressFromHash(userID, novelID, values), nil
 	}
 
-	// 存在则更新
-	progress.ChapterID = chapterID
-	return s.db.Save(&progress).Error
+	var progress models.ReadProgress
+	err = s.db.Where("user_id = ? AND novel_id = ?", userID, novelID).First(&progress).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
 }
 
-// GetReadProgress 获取用户阅读进度
+// shouldAccept 判断新上报的位置是否覆盖当前进度
+func (s *ReadProgressService) shouldAccept(current *models.ReadProgress, update ProgressUpdate) bool {
+	if update.Strategy == ProgressStrategyMax {
+		if current.ChapterID == update.ChapterID {
+			if update.ParagraphIndex != current.ParagraphIndex {
+				return update.ParagraphIndex > current.ParagraphIndex
+			}
+			return update.CharOffset >= current.CharOffset
+		}
+		_, currentOrder, err1 := s.chapterInfo(current.ChapterID, 0)
+		_, newOrder, err2 := s.chapterInfo(update.ChapterID, 0)
+		if err1 != nil || err2 != nil {
+			return true
+		}
+		return newOrder > currentOrder
+	}
+	return !update.ClientTime.Before(current.ClientUpdatedAt)
+}
+
+// GetReadProgress 获取用户阅读进度，优先读取 Redis 中尚未落库的进度
 func (s *ReadProgressService) GetReadProgress(userID, novelID uint) (*models.ReadProgress, error) {
+	if s.rdb != nil {
+		values, err := s.rdb.HGetAll(context.Background(), readProgressKey(userID, novelID)).Result()
+		if err == nil && len(values) > 0 {
+			return progressFromHash(userID, novelID, values), nil
+		}
+	}
+
 	var progress models.ReadProgress
 	err := s.db.Where("user_id = ? AND novel_id = ?", userID, novelID).First(&progress).Error
 	if err != nil {
@@ -48,5 +207,271 @@ func (s *ReadProgressService) GetReadProgress(userID, novelID uint) (*models.Rea
 		}
 		return nil, err
 	}
+
+	if s.rdb != nil {
+		ctx := context.Background()
+		key := readProgressKey(userID, novelID)
+		s.rdb.HSet(ctx, key, progressToHash(&progress))
+		s.rdb.Expire(ctx, key, readProgressCacheTTL)
+	}
 	return &progress, nil
 }
+
+// DeleteReadProgress 删除阅读进度（数据库与缓存）
+func (s *ReadProgressService) DeleteReadProgress(userID uint, novelIDs ...uint) error {
+	query := s.db.Where("user_id = ?", userID)
+	if len(novelIDs) > 0 {
+		query = query.Where("novel_id IN ?", novelIDs)
+	}
+
+	if s.rdb != nil {
+		ctx := context.Background()
+		if len(novelIDs) == 0 {
+			if err := s.db.Model(&models.ReadProgress{}).Where("user_id = ?", userID).Pluck("novel_id", &novelIDs).Error; err != nil {
+				return err
+			}
+			// 只在 Redis 中、尚未落库的进度
+			pending, err := s.pendingNovelIDs(ctx, userID)
+			if err != nil {
+				return err
+			}
+			novelIDs = append(novelIDs, pending...)
+		}
+		pipe := s.rdb.Pipeline()
+		for _, novelID := range novelIDs {
+			pipe.Del(ctx, readProgressKey(userID, novelID))
+			pipe.SRem(ctx, readProgressDirtyKey, fmt.Sprintf("%d:%d", userID, novelID))
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return query.Delete(&models.ReadProgress{}).Error
+}
+
+// StartFlusher 定期将 Redis 中合并后的进度批量写入数据库，ctx 取消时执行最后一次写入后退出，返回的通道在退出后关闭
+func (s *ReadProgressService) StartFlusher(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	if s.rdb == nil {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := s.FlushPending(context.Background()); err != nil {
+					log.Printf("Failed to flush reading progress: %v", err)
+				}
+				return
+			case <-ticker.C:
+				if err := s.FlushPending(ctx); err != nil {
+					log.Printf("Failed to flush reading progress: %v", err)
+				}
+			}
+		}
+	}()
+	return done
+}
+
+// FlushPending 将待落库的进度写入数据库
+func (s *ReadProgressService) FlushPending(ctx context.Context) error {
+	for {
+		members, err := s.rdb.SPopN(ctx, readProgressDirtyKey, readProgressFlushBatch).Result()
+		if err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+
+		batch := make([]models.ReadProgress, 0, len(members))
+		for _, m := range members {
+			var userID, novelID uint
+			if _, err := fmt.Sscanf(m, "%d:%d", &userID, &novelID); err != nil {
+				continue
+			}
+			values, err := s.rdb.HGetAll(ctx, readProgressKey(userID, novelID)).Result()
+			if err != nil || len(values) == 0 {
+				continue
+			}
+			batch = append(batch, *progressFromHash(userID, novelID, values))
+		}
+
+		if err := s.saveProgress(batch); err != nil {
+			// 写入失败时放回待写集合，下次重试
+			s.rdb.SAdd(ctx, readProgressDirtyKey, stringsToInterfaces(members)...)
+			return err
+		}
+		if len(members) < readProgressFlushBatch {
+			return nil
+		}
+	}
+}
+
+// flushUserPending 将指定用户尚未落库的进度写入数据库
+func (s *ReadProgressService) flushUserPending(ctx context.Context, userID uint) error {
+	if s.rdb == nil {
+		return nil
+	}
+	novelIDs, err := s.pendingNovelIDs(ctx, userID)
+	if err != nil || len(novelIDs) == 0 {
+		return err
+	}
+
+	batch := make([]models.ReadProgress, 0, len(novelIDs))
+	for _, novelID := range novelIDs {
+		s.rdb.SRem(ctx, readProgressDirtyKey, fmt.Sprintf("%d:%d", userID, novelID))
+		values, err := s.rdb.HGetAll(ctx, readProgressKey(userID, novelID)).Result()
+		if err != nil || len(values) == 0 {
+			continue
+		}
+		batch = append(batch, *progressFromHash(userID, novelID, values))
+	}
+	return s.saveProgress(batch)
+}
+
+// pendingNovelIDs 查找用户在 Redis 中尚未落库的小说进度
+func (s *ReadProgressService) pendingNovelIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var novelIDs []uint
+	prefix := fmt.Sprintf("%d:", userID)
+	iter := s.rdb.SScan(ctx, readProgressDirtyKey, 0, prefix+"*", 500).Iterator()
+	for iter.Next(ctx) {
+		if id, err := strconv.ParseUint(strings.TrimPrefix(iter.Val(), prefix), 10, 32); err == nil {
+			novelIDs = append(novelIDs, uint(id))
+		}
+	}
+	return novelIDs, iter.Err()
+}
+
+// saveProgress 以 (user_id, novel_id) 为键批量插入或更新
+func (s *ReadProgressService) saveProgress(batch []models.ReadProgress) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	for i := range batch {
+		batch[i].ID = 0
+	}
+	return s.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "novel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"chapter_id", "paragraph_index", "char_offset", "percentage", "device_id", "client_updated_at", "updated_at",
+		}),
+	}).Create(&batch).Error
+}
+
+// chapterInfo 获取已发布章节所属小说和顺序，结果在 Redis 中缓存。novelID 不为 0 时校验章节属于该小说。
+// 章节调整顺序、删除或撤下时由 ForgetChapters 清除缓存
+func (s *ReadProgressService) chapterInfo(chapterID, novelID uint) (uint, int, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d", chapterInfoKeyPrefix, chapterID)
+	var chapter models.Chapter
+	cached := false
+	if s.rdb != nil {
+		if v, err := s.rdb.Get(ctx, key).Result(); err == nil {
+			_, err = fmt.Sscanf(v, "%d:%d", &chapter.NovelID, &chapter.Order)
+			cached = err == nil
+		}
+	}
+	if !cached {
+		err := s.db.Select("id", "novel_id", "order").Where("status = ?", models.ChapterStatusPublished).
+			First(&chapter, chapterID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, 0, ErrChapterNotInNovel
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		if s.rdb != nil {
+			s.rdb.Set(ctx, key, fmt.Sprintf("%d:%d", chapter.NovelID, chapter.Order), chapterInfoCacheTTL)
+		}
+	}
+	if novelID != 0 && chapter.NovelID != novelID {
+		return 0, 0, ErrChapterNotInNovel
+	}
+	return chapter.NovelID, chapter.Order, nil
+}
+
+// ForgetChapters 清除章节所属小说和顺序的缓存
+func (s *ReadProgressService) ForgetChapters(chapterIDs ...uint) {
+	if s.rdb == nil || len(chapterIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(chapterIDs))
+	for _, id := range chapterIDs {
+		keys = append(keys, fmt.Sprintf("%s%d", chapterInfoKeyPrefix, id))
+	}
+	if err := s.rdb.Del(context.Background(), keys...).Err(); err != nil {
+		log.Printf("Failed to clear chapter info cache: %v", err)
+	}
+}
+
+// DeduplicateReadProgress 删除同一用户同一小说的重复进度，只保留最近更新的一条。
+// 旧版本先查询再保存，并发时可能插入重复记录，需在 AutoMigrate 创建唯一索引前清理
+func DeduplicateReadProgress(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.ReadProgress{}) || migrator.HasIndex(&models.ReadProgress{}, "idx_read_progress_user_novel") {
+		return nil
+	}
+	result := db.Exec(`DELETE rp FROM read_progresses rp
+		JOIN read_progresses newer ON newer.user_id = rp.user_id AND newer.novel_id = rp.novel_id
+			AND (newer.updated_at > rp.updated_at OR (newer.updated_at = rp.updated_at AND newer.id > rp.id))`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Removed %d duplicate reading progress records", result.RowsAffected)
+	}
+	return nil
+}
+
+func readProgressKey(userID, novelID uint) string {
+	return fmt.Sprintf("%s%d:%d", readProgressKeyPrefix, userID, novelID)
+}
+
+func progressToHash(p *models.ReadProgress) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              p.ID,
+		"chapterId":       p.ChapterID,
+		"paragraphIndex":  p.ParagraphIndex,
+		"charOffset":      p.CharOffset,
+		"percentage":      p.Percentage,
+		"deviceId":        p.DeviceID,
+		"clientUpdatedAt": p.ClientUpdatedAt.UnixMilli(),
+		"createdAt":       p.CreatedAt.UnixMilli(),
+		"updatedAt":       p.UpdatedAt.UnixMilli(),
+	}
+}
+
+func progressFromHash(userID, novelID uint, values map[string]string) *models.ReadProgress {
+	atoi := func(k string) int64 {
+		v, _ := strconv.ParseInt(values[k], 10, 64)
+		return v
+	}
+	percentage, _ := strconv.ParseFloat(values["percentage"], 64)
+	return &models.ReadProgress{
+		ID:              uint(atoi("id")),
+		UserID:          userID,
+		NovelID:         novelID,
+		ChapterID:       uint(atoi("chapterId")),
+		ParagraphIndex:  int(atoi("paragraphIndex")),
+		CharOffset:      int(atoi("charOffset")),
+		Percentage:      percentage,
+		DeviceID:        values["deviceId"],
+		ClientUpdatedAt: time.UnixMilli(atoi("clientUpdatedAt")),
+		CreatedAt:       time.UnixMilli(atoi("createdAt")),
+		UpdatedAt:       time.UnixMilli(atoi("updatedAt")),
+	}
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
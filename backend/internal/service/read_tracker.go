@@ -0,0 +1,257 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	readTrackKeyPrefix  = "reads:"
+	readTrackKeyTTL     = 72 * time.Hour
+	readTrackFlushBatch = 500
+	readTrackFlushDays  = 2 // 同时检查昨天，跨零点时昨天可能还有未落库的增量
+	readTrackTimeout    = 2 * time.Second
+)
+
+// botUserAgent 常见爬虫和脚本的 User-Agent 特征
+var botUserAgent = regexp.MustCompile(`(?i)bot|spider|crawl|slurp|curl|wget|python|java/|go-http-client|httpclient|headless|phantomjs|scrapy|libwww|feedfetcher|preview`)
+
+// readTarget 待落库的统计对象；ChapterID 为 0 时为小说
+type readTarget struct {
+	member    string
+	NovelID   uint
+	ChapterID uint
+}
+
+// ReadTrackerService 阅读计数：按天用 HyperLogLog 统计章节和小说的独立读者，
+// 后台定期把新增的读者数批量累加到 MySQL
+type ReadTrackerService struct {
+	db       *gorm.DB
+	rdb      *redis.Client
+	rankings *RankingService
+}
+
+// NewReadTrackerService 创建阅读计数服务，rdb 为 nil 时不统计
+func NewReadTrackerService(db *gorm.DB, rdb *redis.Client, rankings *RankingService) *ReadTrackerService {
+	return &ReadTrackerService{db: db, rdb: rdb, rankings: rankings}
+}
+
+// ReadVisitor 读者标识：登录用户使用用户ID，游客使用 IP 和 User-Agent 的哈希
+func ReadVisitor(userID uint, ip, userAgent string) string {
+	if userID != 0 {
+		return "u:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	sum := sha1.Sum([]byte(ip + "|" + userAgent))
+	return "a:" + hex.EncodeToString(sum[:12])
+}
+
+// IsBotUserAgent 判断是否为爬虫或脚本请求，空 User-Agent 也视为机器请求
+func IsBotUserAgent(userAgent string) bool {
+	return strings.TrimSpace(userAgent) == "" || botUserAgent.MatchString(userAgent)
+}
+
+// TrackRead 记录一次章节阅读。同一读者当天重复阅读只计一次；
+// 当天首次阅读该小说时同时计入排行榜的阅读数
+func (s *ReadTrackerService) TrackRead(novelID, chapterID uint, visitor, userAgent string) {
+	if s == nil || s.rdb == nil || IsBotUserAgent(userAgent) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), readTrackTimeout)
+	defer cancel()
+
+	day := time.Now()
+	novelKey := readTrackHLLKey(day, readTarget{NovelID: novelID})
+	chapterKey := readTrackHLLKey(day, readTarget{NovelID: novelID, ChapterID: chapterID})
+	dirtyKey := readTrackDirtyKey(day)
+
+	pipe := s.rdb.Pipeline()
+	novelAdded := pipe.PFAdd(ctx, novelKey, visitor)
+	pipe.PFAdd(ctx, chapterKey, visitor)
+	pipe.SAdd(ctx, dirtyKey, readTargetMember(novelID, 0), readTargetMember(novelID, chapterID))
+	for _, key := range []string{novelKey, chapterKey, dirtyKey} {
+		pipe.Expire(ctx, key, readTrackKeyTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to track read of chapter %d: %v", chapterID, err)
+		return
+	}
+	if novelAdded.Val() == 1 {
+		s.rankings.RecordRead(novelID)
+	}
+}
+
+// StartFlusher 定期把新增的独立读者数写入数据库，ctx 取消时执行最后一次写入后退出，返回的通道在退出后关闭
+func (s *ReadTrackerService) StartFlusher(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	if s.rdb == nil {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := s.FlushPending(context.Background()); err != nil {
+					log.Printf("Failed to flush read counts: %v", err)
+				}
+				return
+			case <-ticker.C:
+				if err := s.FlushPending(ctx); err != nil {
+					log.Printf("Failed to flush read counts: %v", err)
+				}
+			}
+		}
+	}()
+	return done
+}
+
+// FlushPending 处理有新阅读的章节和小说
+func (s *ReadTrackerService) FlushPending(ctx context.Context) error {
+	now := time.Now()
+	for i := 0; i < readTrackFlushDays; i++ {
+		if err := s.flushDay(ctx, now.AddDate(0, 0, -i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ReadTrackerService) flushDay(ctx context.Context, day time.Time) error {
+	dirtyKey := readTrackDirtyKey(day)
+	for {
+		members, err := s.rdb.SPopN(ctx, dirtyKey, readTrackFlushBatch).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		if err := s.flushBatch(ctx, day, members); err != nil {
+			// 放回待处理集合，下次重试
+			s.rdb.SAdd(ctx, dirtyKey, members)
+			return err
+		}
+	}
+}
+
+// flushBatch 用当前的 HyperLogLog 基数减去已落库的读者数，得到本次需要累加的增量
+func (s *ReadTrackerService) flushBatch(ctx context.Context, day time.Time, members []string) error {
+	targets := make([]readTarget, 0, len(members))
+	for _, member := range members {
+		if target, ok := parseReadTarget(member); ok {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	flushedKey := readTrackFlushedKey(day)
+	pipe := s.rdb.Pipeline()
+	counts := make([]*redis.IntCmd, len(targets))
+	flushed := make([]*redis.StringCmd, len(targets))
+	for i, target := range targets {
+		counts[i] = pipe.PFCount(ctx, readTrackHLLKey(day, target))
+		flushed[i] = pipe.HGet(ctx, flushedKey, target.member)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return err
+	}
+
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	stats := make([]models.ReadStat, 0, len(targets))
+	newFlushed := make(map[string]interface{}, len(targets))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for i, target := range targets {
+			count := counts[i].Val()
+			previous, _ := flushed[i].Int64()
+			if count <= previous {
+				continue
+			}
+
+			delta := count - previous
+			var update *gorm.DB
+			if target.ChapterID == 0 {
+				update = tx.Model(&models.Novel{}).Where("id = ?", target.NovelID)
+			} else {
+				update = tx.Model(&models.Chapter{}).Where("id = ?", target.ChapterID)
+			}
+			if err := update.UpdateColumn("read_count", gorm.Expr("read_count + ?", delta)).Error; err != nil {
+				return err
+			}
+			stats = append(stats, models.ReadStat{Date: date, NovelID: target.NovelID, ChapterID: target.ChapterID, Readers: int(count)})
+			newFlushed[target.member] = count
+		}
+		if len(stats) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"readers", "updated_at"}),
+		}).CreateInBatches(&stats, readTrackFlushBatch).Error
+	})
+	if err != nil || len(newFlushed) == 0 {
+		return err
+	}
+
+	pipe = s.rdb.Pipeline()
+	pipe.HSet(ctx, flushedKey, newFlushed)
+	pipe.Expire(ctx, flushedKey, readTrackKeyTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func readTargetMember(novelID, chapterID uint) string {
+	if chapterID == 0 {
+		return fmt.Sprintf("n:%d", novelID)
+	}
+	return fmt.Sprintf("c:%d:%d", chapterID, novelID)
+}
+
+func parseReadTarget(member string) (readTarget, bool) {
+	parts := strings.Split(member, ":")
+	ids := make([]uint, 0, 2)
+	for _, p := range parts[1:] {
+		id, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return readTarget{}, false
+		}
+		ids = append(ids, uint(id))
+	}
+	switch {
+	case parts[0] == "n" && len(ids) == 1:
+		return readTarget{member: member, NovelID: ids[0]}, true
+	case parts[0] == "c" && len(ids) == 2:
+		return readTarget{member: member, ChapterID: ids[0], NovelID: ids[1]}, true
+	}
+	return readTarget{}, false
+}
+
+func readTrackHLLKey(day time.Time, target readTarget) string {
+	if target.ChapterID == 0 {
+		return fmt.Sprintf("%snovel:%s:%d", readTrackKeyPrefix, day.Format("20060102"), target.NovelID)
+	}
+	return fmt.Sprintf("%schapter:%s:%d", readTrackKeyPrefix, day.Format("20060102"), target.ChapterID)
+}
+
+func readTrackDirtyKey(day time.Time) string {
+	return readTrackKeyPrefix + "dirty:" + day.Format("20060102")
+}
+
+func readTrackFlushedKey(day time.Time) string {
+	return readTrackKeyPrefix + "flushed:" + day.Format("20060102")
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"context"
+	"time"
+)
+
+// ReadingHistoryEntry 阅读历史条目
+type ReadingHistoryEntry struct {
+	NovelID           uint      `json:"novelId"`
+	NovelTitle        string    `json:"novelTitle"`
+	CoverURL          string    `json:"coverUrl"`
+	NovelStatus       int       `json:"novelStatus"`
+	AuthorName        string    `json:"authorName"`
+	ChapterID         uint      `json:"chapterId"`
+	ChapterTitle      string    `json:"chapterTitle"`
+	ChapterOrder      int       `json:"chapterOrder"`
+	ChapterPercentage float64   `json:"chapterPercentage"` // 当前章节内的阅读百分比
+	Progress          float64   `json:"progress"`          // 全书阅读百分比（按已发布章节计算）
+	ReadBeforeCount   int64     `json:"-"`
+	PublishedCount    int64     `json:"publishedCount"`
+	UnreadCount       int64     `json:"unreadCount"` // 当前章节之后新发布的章节数
+	LastReadAt        time.Time `json:"lastReadAt"`
+}
+
+// ListReadingHistory 按最近阅读时间获取用户的阅读历史
+func (s *ReadProgressService) ListReadingHistory(userID uint, page, limit int) ([]ReadingHistoryEntry, int64, error) {
+	// 先写入该用户缓存中的进度，保证排序和内容是最新的
+	if err := s.flushUserPending(context.Background(), userID); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.Table("read_progresses AS rp").
+		Joins("JOIN novels AS n ON n.id = rp.novel_id AND n.deleted_at IS NULL").
+		Where("rp.user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []ReadingHistoryEntry
+	offset := (page - 1) * limit
+	if err := query.
+		Joins("LEFT JOIN users AS u ON u.id = n.author_id").
+		Joins("LEFT JOIN chapters AS c ON c.id = rp.chapter_id").
+		Select(`rp.novel_id, n.title AS novel_title, n.cover_url, n.status AS novel_status, u.username AS author_name,
+			rp.chapter_id, c.title AS chapter_title, COALESCE(c.`+"`order`"+`, 0) AS chapter_order,
+			rp.percentage AS chapter_percentage, rp.updated_at AS last_read_at,
+			(SELECT COUNT(*) FROM chapters AS c2 WHERE c2.novel_id = rp.novel_id AND c2.status = ?) AS published_count,
+			(SELECT COUNT(*) FROM chapters AS c3 WHERE c3.novel_id = rp.novel_id AND c3.status = ? AND c3.`+"`order`"+` > COALESCE(c.`+"`order`"+`, 0)) AS unread_count,
+			(SELECT COUNT(*) FROM chapters AS c4 WHERE c4.novel_id = rp.novel_id AND c4.status = ? AND c4.`+"`order`"+` < COALESCE(c.`+"`order`"+`, 0)) AS read_before_count`,
+			models.ChapterStatusPublished, models.ChapterStatusPublished, models.ChapterStatusPublished).
+		Order("rp.updated_at desc").
+		Offset(offset).Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.PublishedCount > 0 {
+			progress := (float64(e.ReadBeforeCount) + e.ChapterPercentage/100) / float64(e.PublishedCount) * 100
+			e.Progress = min(progress, 100)
+		}
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,530 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/search"
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	recommendRefreshEvery   = time.Hour
+	recommendNeighbors      = 20  // 每本小说保留的相似小说数
+	recommendMaxUserItems   = 200 // 每个用户参与协同过滤的最近互动数，避免个别用户产生过多组合
+	recommendFavoriteWeight = 1.0
+	recommendReadWeight     = 0.5
+	recommendCFShrink       = 5.0 // 共同读者较少时更多依赖内容相似度
+	recommendTermsPerNovel  = 30  // 简介 TF-IDF 向量保留的词数
+	recommendMinDFCap       = 50  // 高频词/标签的文档数上限至少为此值
+	recommendSeeds          = 50  // 个性化推荐参考的最近互动数
+)
+
+// 内容相似度中各部分的权重
+const (
+	recommendCategoryWeight = 0.2
+	recommendTagWeight      = 0.4
+	recommendTextWeight     = 0.4
+)
+
+// 推荐理由
+const (
+	RecommendReasonSimilar = "similar" // 与用户收藏或在读的小说相似
+	RecommendReasonPopular = "popular" // 热门补位
+)
+
+// RecommendationItem 推荐条目，BecauseOf 为促成推荐的用户收藏或在读小说的标题
+type RecommendationItem struct {
+	Novel     models.Novel `json:"novel"`
+	Score     float64      `json:"score"`
+	Reason    string       `json:"reason"`
+	BecauseOf string       `json:"becauseOf,omitempty"`
+}
+
+// recommendNovel 计算相似度所需的小说字段
+type recommendNovel struct {
+	ID          uint
+	Category    string
+	Tags        models.StringArray
+	Description string
+}
+
+// recommendInteraction 用户与小说的一次互动
+type recommendInteraction struct {
+	UserID    uint
+	NovelID   uint
+	UpdatedAt time.Time
+}
+
+type novelPair struct {
+	a, b uint
+}
+
+// coStat 两本小说的共同读者统计
+type coStat struct {
+	dot     float64
+	readers int
+}
+
+type weightedID struct {
+	id     uint
+	weight float64
+}
+
+type RecommendationService struct {
+	db *gorm.DB
+}
+
+func NewRecommendationService(db *gorm.DB) *RecommendationService {
+	return &RecommendationService{db: db}
+}
+
+// Start 启动后台任务，定期重新计算相似小说列表，ctx 结束时退出
+func (s *RecommendationService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(recommendRefreshEvery)
+		defer ticker.Stop()
+		for {
+			if err := s.Recompute(ctx); err != nil {
+				log.Printf("Failed to compute novel neighbors: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Recompute 结合协同过滤和内容相似度计算每本小说的相似小说，整体替换旧结果。
+// 协同过滤基于收藏和阅读进度的共现做余弦相似度，内容相似度由分类、标签重合度和简介 TF-IDF 组成
+func (s *RecommendationService) Recompute(ctx context.Context) error {
+	var novels []recommendNovel
+	if err := s.db.WithContext(ctx).Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Select("id, category, tags, description").
+		Where("EXISTS (SELECT 1 FROM chapters WHERE chapters.novel_id = novels.id AND chapters.status = ?)", models.ChapterStatusPublished).
+		Scan(&novels).Error; err != nil {
+		return err
+	}
+	catalog := make(map[uint]recommendNovel, len(novels))
+	for _, n := range novels {
+		catalog[n.ID] = n
+	}
+
+	cf, err := s.collaborative(ctx, catalog)
+	if err != nil {
+		return err
+	}
+	content := contentSimilarity(novels)
+
+	now := time.Now()
+	var entries []models.NovelNeighbor
+	for _, n := range novels {
+		scores := make(map[uint]float64, len(cf[n.ID])+len(content[n.ID]))
+		for id, sim := range content[n.ID] {
+			scores[id] = sim
+		}
+		for id, stat := range cf[n.ID] {
+			// 共同读者越多，协同过滤的权重越高
+			alpha := float64(stat.readers) / (float64(stat.readers) + recommendCFShrink)
+			scores[id] = alpha*stat.dot + (1-alpha)*content[n.ID][id]
+		}
+		for rank, neighbor := range topWeighted(scores, recommendNeighbors) {
+			entries = append(entries, models.NovelNeighbor{
+				NovelID:    n.ID,
+				Rank:       rank + 1,
+				NeighborID: neighbor.id,
+				Score:      neighbor.weight,
+				ComputedAt: now,
+			})
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.NovelNeighbor{}).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(entries, 500).Error
+	})
+}
+
+// collaborative 计算共现余弦相似度，返回每本小说的相似小说及其相似度（存于 coStat.dot）
+func (s *RecommendationService) collaborative(ctx context.Context, catalog map[uint]recommendNovel) (map[uint]map[uint]coStat, error) {
+	var favorites, reads []recommendInteraction
+	if err := s.db.WithContext(ctx).Model(&models.Favorite{}).
+		Select("user_id, novel_id, created_at AS updated_at").
+		Scan(&favorites).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&models.ReadProgress{}).
+		Select("user_id, novel_id, updated_at").
+		Scan(&reads).Error; err != nil {
+		return nil, err
+	}
+
+	type userItem struct {
+		weight float64
+		at     time.Time
+	}
+	users := make(map[uint]map[uint]userItem)
+	add := func(list []recommendInteraction, weight float64) {
+		for _, it := range list {
+			if _, ok := catalog[it.NovelID]; !ok {
+				continue
+			}
+			items := users[it.UserID]
+			if items == nil {
+				items = make(map[uint]userItem)
+				users[it.UserID] = items
+			}
+			prev := items[it.NovelID]
+			items[it.NovelID] = userItem{weight: max(prev.weight, weight), at: maxTime(prev.at, it.UpdatedAt)}
+		}
+	}
+	add(favorites, recommendFavoriteWeight)
+	add(reads, recommendReadWeight)
+
+	pairs := make(map[novelPair]*coStat)
+	norms := make(map[uint]float64)
+	for _, items := range users {
+		list := make([]uint, 0, len(items))
+		for id := range items {
+			list = append(list, id)
+		}
+		if len(list) > recommendMaxUserItems {
+			sort.Slice(list, func(i, j int) bool { return items[list[i]].at.After(items[list[j]].at) })
+			list = list[:recommendMaxUserItems]
+		}
+		for i, a := range list {
+			wa := items[a].weight
+			norms[a] += wa * wa
+			for _, b := range list[i+1:] {
+				key := novelPair{a, b}
+				if b < a {
+					key = novelPair{b, a}
+				}
+				stat := pairs[key]
+				if stat == nil {
+					stat = &coStat{}
+					pairs[key] = stat
+				}
+				stat.dot += wa * items[b].weight
+				stat.readers++
+			}
+		}
+	}
+
+	result := make(map[uint]map[uint]coStat)
+	put := func(a, b uint, stat coStat) {
+		if result[a] == nil {
+			result[a] = make(map[uint]coStat)
+		}
+		result[a][b] = stat
+	}
+	for key, stat := range pairs {
+		cos := coStat{dot: stat.dot / math.Sqrt(norms[key.a]*norms[key.b]), readers: stat.readers}
+		put(key.a, key.b, cos)
+		put(key.b, key.a, cos)
+	}
+	return result, nil
+}
+
+// contentSimilarity 计算内容相似度。只比较至少有一个共同标签或简介关键词的小说，
+// 出现在过多小说中的标签和词区分度低，不参与比较
+func contentSimilarity(novels []recommendNovel) map[uint]map[uint]float64 {
+	maxDF := max(recommendMinDFCap, len(novels)/10)
+
+	// 简介 TF-IDF，每本小说只保留权重最高的若干个词并归一化
+	termFreqs := make([]map[string]int, len(novels))
+	df := make(map[string]int)
+	for i, n := range novels {
+		freq := make(map[string]int)
+		for _, t := range search.QueryTokens(n.Description) {
+			freq[t]++
+		}
+		for t := range freq {
+			df[t]++
+		}
+		termFreqs[i] = freq
+	}
+	type posting struct {
+		index  int
+		weight float64
+	}
+	termPostings := make(map[string][]posting)
+	vectors := make([]map[string]float64, len(novels))
+	for i, freq := range termFreqs {
+		weights := make(map[string]float64, len(freq))
+		for t, tf := range freq {
+			if df[t] < 2 || df[t] > maxDF {
+				continue
+			}
+			weights[t] = (1 + math.Log(float64(tf))) * math.Log(float64(len(novels))/float64(df[t]))
+		}
+		top := make([]string, 0, len(weights))
+		for t := range weights {
+			top = append(top, t)
+		}
+		sort.Slice(top, func(a, b int) bool {
+			if weights[top[a]] != weights[top[b]] {
+				return weights[top[a]] > weights[top[b]]
+			}
+			return top[a] < top[b]
+		})
+		if len(top) > recommendTermsPerNovel {
+			top = top[:recommendTermsPerNovel]
+		}
+		var norm float64
+		for _, t := range top {
+			norm += weights[t] * weights[t]
+		}
+		vectors[i] = make(map[string]float64, len(top))
+		for _, t := range top {
+			w := weights[t] / math.Sqrt(norm)
+			vectors[i][t] = w
+			termPostings[t] = append(termPostings[t], posting{index: i, weight: w})
+		}
+	}
+
+	tagSets := make([]map[string]bool, len(novels))
+	tagPostings := make(map[string][]int)
+	for i, n := range novels {
+		tagSets[i] = make(map[string]bool, len(n.Tags))
+		for _, tag := range n.Tags {
+			if tag != "" && !tagSets[i][tag] {
+				tagSets[i][tag] = true
+				tagPostings[tag] = append(tagPostings[tag], i)
+			}
+		}
+	}
+
+	result := make(map[uint]map[uint]float64, len(novels))
+	for i, n := range novels {
+		text := make(map[int]float64)
+		for t, w := range vectors[i] {
+			for _, p := range termPostings[t] {
+				if p.index != i {
+					text[p.index] += w * p.weight
+				}
+			}
+		}
+		shared := make(map[int]int)
+		for tag := range tagSets[i] {
+			if len(tagPostings[tag]) > maxDF {
+				continue
+			}
+			for _, j := range tagPostings[tag] {
+				if j != i {
+					shared[j]++
+				}
+			}
+		}
+
+		scores := make(map[uint]float64, len(text)+len(shared))
+		score := func(j int) float64 {
+			other := novels[j]
+			sim := recommendTextWeight * text[j]
+			if s := shared[j]; s > 0 {
+				sim += recommendTagWeight * float64(s) / float64(len(tagSets[i])+len(tagSets[j])-s)
+			}
+			if n.Category != "" && n.Category == other.Category {
+				sim += recommendCategoryWeight
+			}
+			return sim
+		}
+		for j := range text {
+			scores[novels[j].ID] = score(j)
+		}
+		for j := range shared {
+			scores[novels[j].ID] = score(j)
+		}
+		result[n.ID] = scores
+	}
+	return result
+}
+
+// Recommend 为用户生成个性化推荐：按用户最近收藏和在读的小说汇总相似小说，
+// 排除已读过的小说，数量不足时（含游客和新用户）用热门小说补齐
+func (s *RecommendationService) Recommend(userID uint, limit int) ([]RecommendationItem, error) {
+	scores := make(map[uint]float64)
+	because := make(map[uint]uint)
+	seen := make(map[uint]bool)
+
+	if userID != 0 {
+		var favorites, reads []uint
+		if err := s.db.Model(&models.Favorite{}).Where("user_id = ?", userID).
+			Order("created_at desc").Pluck("novel_id", &favorites).Error; err != nil {
+			return nil, err
+		}
+		if err := s.db.Model(&models.ReadProgress{}).Where("user_id = ?", userID).
+			Order("updated_at desc").Pluck("novel_id", &reads).Error; err != nil {
+			return nil, err
+		}
+
+		seeds := make(map[uint]float64)
+		addSeeds := func(ids []uint, weight float64) {
+			for i, id := range ids {
+				seen[id] = true
+				if i < recommendSeeds {
+					seeds[id] = max(seeds[id], weight)
+				}
+			}
+		}
+		addSeeds(favorites, recommendFavoriteWeight)
+		addSeeds(reads, recommendReadWeight)
+
+		if len(seeds) > 0 {
+			seedIDs := make([]uint, 0, len(seeds))
+			for id := range seeds {
+				seedIDs = append(seedIDs, id)
+			}
+			var neighbors []models.NovelNeighbor
+			if err := s.db.Where("novel_id IN ?", seedIDs).Find(&neighbors).Error; err != nil {
+				return nil, err
+			}
+			best := make(map[uint]float64)
+			for _, n := range neighbors {
+				if seen[n.NeighborID] {
+					continue
+				}
+				contribution := seeds[n.NovelID] * n.Score
+				scores[n.NeighborID] += contribution
+				if contribution > best[n.NeighborID] {
+					best[n.NeighborID] = contribution
+					because[n.NeighborID] = n.NovelID
+				}
+			}
+		}
+	}
+
+	picked := topWeighted(scores, limit)
+	ids := make([]uint, 0, limit)
+	for _, p := range picked {
+		ids = append(ids, p.id)
+	}
+	if len(ids) < limit {
+		exclude := make([]uint, 0, len(seen)+len(ids))
+		for id := range seen {
+			exclude = append(exclude, id)
+		}
+		exclude = append(exclude, ids...)
+		popular, err := s.popular(limit-len(ids), exclude)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, popular...)
+	}
+	if len(ids) == 0 {
+		return []RecommendationItem{}, nil
+	}
+
+	lookup := append([]uint{}, ids...)
+	for _, id := range because {
+		lookup = append(lookup, id)
+	}
+	byID, err := s.loadNovels(lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]RecommendationItem, 0, len(ids))
+	for _, id := range ids {
+		novel, ok := byID[id]
+		if !ok {
+			continue
+		}
+		item := RecommendationItem{Novel: novel, Reason: RecommendReasonPopular}
+		if score, ok := scores[id]; ok {
+			item.Score = score
+			item.Reason = RecommendReasonSimilar
+			item.BecauseOf = byID[because[id]].Title
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// AlsoLiked 读过这本书的人还喜欢：返回预计算的相似小说
+func (s *RecommendationService) AlsoLiked(novelID uint, limit int) ([]models.Novel, error) {
+	var ids []uint
+	if err := s.db.Model(&models.NovelNeighbor{}).Where("novel_id = ?", novelID).
+		Order("`rank` asc").Limit(limit).
+		Pluck("neighbor_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	byID, err := s.loadNovels(ids)
+	if err != nil {
+		return nil, err
+	}
+	novels := make([]models.Novel, 0, len(ids))
+	for _, id := range ids {
+		if novel, ok := byID[id]; ok {
+			novels = append(novels, novel)
+		}
+	}
+	return novels, nil
+}
+
+// popular 按人气返回有已发布章节的小说ID
+func (s *RecommendationService) popular(limit int, exclude []uint) ([]uint, error) {
+	query := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Where("EXISTS (SELECT 1 FROM chapters WHERE chapters.novel_id = novels.id AND chapters.status = ?)", models.ChapterStatusPublished)
+	if len(exclude) > 0 {
+		query = query.Where("id NOT IN ?", exclude)
+	}
+	var ids []uint
+	err := query.Order(gorm.Expr("read_count + favorite_count * ? DESC", rankingFavoriteWeight)).
+		Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (s *RecommendationService) loadNovels(ids []uint) (map[uint]models.Novel, error) {
+	byID := make(map[uint]models.Novel, len(ids))
+	if len(ids) == 0 {
+		return byID, nil
+	}
+	var novels []models.Novel
+	if err := s.db.Omit("novel_outline").Preload("Author").Scopes(models.VisibleNovels).
+		Where("id IN ?", ids).Find(&novels).Error; err != nil {
+		return nil, err
+	}
+	for _, n := range novels {
+		byID[n.ID] = n
+	}
+	return byID, nil
+}
+
+// topWeighted 返回得分最高的 n 个，得分相同时按 ID 排序保证结果稳定
+func topWeighted(scores map[uint]float64, n int) []weightedID {
+	list := make([]weightedID, 0, len(scores))
+	for id, w := range scores {
+		if w > 0 {
+			list = append(list, weightedID{id: id, weight: w})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].weight != list[j].weight {
+			return list[i].weight > list[j].weight
+		}
+		return list[i].id < list[j].id
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxReviewLength = 5000
+
+	// 贝叶斯平均的先验：相当于每部小说预先有 ratingPriorWeight 个 ratingPriorMean 分的评分
+	ratingPriorMean   = 3.5
+	ratingPriorWeight = 10
+)
+
+// 书评排序方式
+const (
+	ReviewSortHelpful = "helpful"
+	ReviewSortLatest  = "latest"
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewNovelNotFound = errors.New("novel not found")
+	ErrInvalidScore        = errors.New("score must be between 1 and 5")
+	ErrReviewOwnNovel      = errors.New("cannot review your own novel")
+	ErrReviewOwnReview     = errors.New("cannot vote for your own review")
+)
+
+type ReviewService struct {
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewReviewService(db *gorm.DB, notifications *NotificationService) *ReviewService {
+	return &ReviewService{db: db, notifications: notifications}
+}
+
+// ReviewEntry 书评列表条目
+type ReviewEntry struct {
+	models.Review
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	Voted    bool   `json:"voted" gorm:"-"` // 当前用户是否已投“有用”
+}
+
+// SaveReview 发表或修改书评，并增量更新小说的评分汇总
+func (s *ReviewService) SaveReview(userID, novelID uint, score int, content string, isSpoiler bool) (*models.Review, error) {
+	if score < 1 || score > 5 {
+		return nil, ErrInvalidScore
+	}
+	content = strings.TrimSpace(content)
+	if utf8.RuneCountInString(content) > maxReviewLength {
+		return nil, fmt.Errorf("review exceeds %d characters", maxReviewLength)
+	}
+
+	var review models.Review
+	var novel models.Novel
+	created := false
+	oldScore := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// 被隐藏的小说不接受新书评
+		if err := tx.Select("id", "author_id").Scopes(models.VisibleNovels).First(&novel, novelID).Error; err != nil {
+			return ErrReviewNovelNotFound
+		}
+		if novel.AuthorID == userID {
+			return ErrReviewOwnNovel
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("novel_id = ? AND user_id = ?", novelID, userID).
+			First(&review).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			review = models.Review{NovelID: novelID, UserID: userID, Score: score, Content: content, IsSpoiler: isSpoiler}
+			if err := tx.Create(&review).Error; err != nil {
+				return err
+			}
+			created = true
+			return applyRatingChange(tx, novelID, 0, score)
+		case err != nil:
+			return err
+		}
+
+		oldScore = review.Score
+		review.Score = score
+		review.Content = content
+		review.IsSpoiler = isSpoiler
+		if err := tx.Save(&review).Error; err != nil {
+			return err
+		}
+		if oldScore == score {
+			return nil
+		}
+		return applyRatingChange(tx, novelID, oldScore, score)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		s.notifications.Publish(NotificationEvent{
+			Type:        models.NotificationNovelReview,
+			ActorID:     userID,
+			RecipientID: novel.AuthorID,
+			NovelID:     novelID,
+			ReviewID:    review.ID,
+			Content:     review.Content,
+		})
+	}
+	return &review, nil
+}
+
+// DeleteReview 删除自己的书评
+func (s *ReviewService) DeleteReview(userID, novelID uint) error {
+	var review models.Review
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("novel_id = ? AND user_id = ?", novelID, userID).
+			First(&review).Error; err != nil {
+			return ErrReviewNotFound
+		}
+		if err := tx.Where("review_id = ?", review.ID).Delete(&models.ReviewVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&review).Error; err != nil {
+			return err
+		}
+		return applyRatingChange(tx, novelID, review.Score, 0)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// bayesianRating 评分数为 count、总分为 sum 时的贝叶斯平均分
+func bayesianRating(sum, count float64) float64 {
+	return (ratingPriorMean*ratingPriorWeight + sum) / (ratingPriorWeight + count)
+}
+
+// applyRatingChange 增量更新评分汇总：oldScore 为 0 表示新增，newScore 为 0 表示删除
+func applyRatingChange(tx *gorm.DB, novelID uint, oldScore, newScore int) error {
+	updates := map[string]interface{}{
+		"rating_sum": gorm.Expr("rating_sum + ?", newScore-oldScore),
+	}
+	if oldScore > 0 {
+		column := fmt.Sprintf("rating_star%d", oldScore)
+		updates[column] = gorm.Expr(column + " - 1")
+	}
+	if newScore > 0 {
+		column := fmt.Sprintf("rating_star%d", newScore)
+		updates[column] = gorm.Expr(column + " + 1")
+	}
+	switch {
+	case oldScore == 0:
+		updates["rating_count"] = gorm.Expr("rating_count + 1")
+	case newScore == 0:
+		updates["rating_count"] = gorm.Expr("rating_count - 1")
+	}
+	if err := tx.Model(&models.Novel{}).Where("id = ?", novelID).UpdateColumns(updates).Error; err != nil {
+		return err
+	}
+
+	// 计数更新后再单独计算平均分，避免依赖 UPDATE 中各列的赋值顺序
+	return tx.Model(&models.Novel{}).Where("id = ?", novelID).UpdateColumns(map[string]interface{}{
+		"rating_average": gorm.Expr("CASE WHEN rating_count > 0 THEN rating_sum / rating_count ELSE 0 END"),
+		"rating_score":   gorm.Expr("(? + rating_sum) / (? + rating_count)", ratingPriorMean*ratingPriorWeight, ratingPriorWeight),
+	}).Error
+}
+
+// GetReview 获取用户对小说的书评
+func (s *ReviewService) GetReview(userID, novelID uint) (*models.Review, error) {
+	var review models.Review
+	if err := s.db.Where("novel_id = ? AND user_id = ?", novelID, userID).First(&review).Error; err != nil {
+		return nil, ErrReviewNotFound
+	}
+	return &review, nil
+}
+
+// ListReviews 获取小说的书评，按有用数或时间排序
+func (s *ReviewService) ListReviews(viewerID, novelID uint, sort string, page, limit int) ([]ReviewEntry, int64, error) {
+	var entries []ReviewEntry
+	var total int64
+
+	var visible int64
+	if err := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).Where("id = ?", novelID).Count(&visible).Error; err != nil {
+		return nil, 0, err
+	}
+	if visible == 0 {
+		return nil, 0, ErrReviewNovelNotFound
+	}
+
+	query := s.db.Model(&models.Review{}).Where("reviews.novel_id = ?", novelID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "reviews.helpful_count desc, reviews.id desc"
+	if sort == ReviewSortLatest {
+		order = "reviews.id desc"
+	}
+	offset := (page - 1) * limit
+	if err := query.Select("reviews.*, users.username, users.avatar").
+		Joins("LEFT JOIN users ON users.id = reviews.user_id").
+		Order(order).
+		Offset(offset).Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if viewerID > 0 && len(entries) > 0 {
+		ids := make([]uint, len(entries))
+		for i, e := range entries {
+			ids[i] = e.ID
+		}
+		var voted []uint
+		if err := s.db.Model(&models.ReviewVote{}).
+			Where("user_id = ? AND review_id IN ?", viewerID, ids).
+			Pluck("review_id", &voted).Error; err != nil {
+			return nil, 0, err
+		}
+		votedSet := make(map[uint]bool, len(voted))
+		for _, id := range voted {
+			votedSet[id] = true
+		}
+		for i := range entries {
+			entries[i].Voted = votedSet[entries[i].ID]
+		}
+	}
+	return entries, total, nil
+}
+
+// GetNovelRating 获取小说的评分汇总
+func (s *ReviewService) GetNovelRating(novelID uint) (*models.RatingStats, error) {
+	var novel models.Novel
+	if err := s.db.Select("id", "rating_count", "rating_sum", "rating_average", "rating_score",
+		"rating_star1", "rating_star2", "rating_star3", "rating_star4", "rating_star5").
+		Scopes(models.VisibleNovels).First(&novel, novelID).Error; err != nil {
+		return nil, err
+	}
+	if novel.Rating.Count == 0 {
+		novel.Rating.Score = ratingPriorMean
+	}
+	return &novel.Rating, nil
+}
+
+// VoteHelpful 为书评投“有用”，重复投票不会重复计数
+func (s *ReviewService) VoteHelpful(userID, reviewID uint) (int, error) {
+	var review models.Review
+	if err := s.db.Select("id", "user_id").First(&review, reviewID).Error; err != nil {
+		return 0, ErrReviewNotFound
+	}
+	if review.UserID == userID {
+		return 0, ErrReviewOwnReview
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.ReviewVote{ReviewID: reviewID, UserID: userID, CreatedAt: time.Now()})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Review{}).Where("id = ?", reviewID).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count + 1")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.helpfulCount(reviewID)
+}
+
+// UnvoteHelpful 取消“有用”投票
+func (s *ReviewService) UnvoteHelpful(userID, reviewID uint) (int, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&models.ReviewVote{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Review{}).Where("id = ? AND helpful_count > 0", reviewID).
+			UpdateColumn("helpful_count", gorm.Expr("helpful_count - 1")).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return s.helpfulCount(reviewID)
+}
+
+func (s *ReviewService) helpfulCount(reviewID uint) (int, error) {
+	var count int
+	err := s.db.Model(&models.Review{}).Where("id = ?", reviewID).Select("helpful_count").Scan(&count).Error
+	return count, err
+}
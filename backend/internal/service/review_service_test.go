@@ -0,0 +1,44 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBayesianRating(t *testing.T) {
+	tests := []struct {
+		name  string
+		sum   float64
+		count float64
+		want  float64
+	}{
+		{"no reviews", 0, 0, ratingPriorMean},
+		{"single five", 5, 1, (ratingPriorMean*ratingPriorWeight + 5) / (ratingPriorWeight + 1)},
+		{"single one", 1, 1, (ratingPriorMean*ratingPriorWeight + 1) / (ratingPriorWeight + 1)},
+		{"many fives", 5000, 1000, (ratingPriorMean*ratingPriorWeight + 5000) / (ratingPriorWeight + 1000)},
+		{"mean equals prior", ratingPriorMean * 20, 20, ratingPriorMean},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bayesianRating(tt.sum, tt.count)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("bayesianRating(%v, %v) = %v, want %v", tt.sum, tt.count, got, tt.want)
+			}
+			if got < 1 || got > 5 {
+				t.Fatalf("bayesianRating(%v, %v) = %v, out of range 1-5", tt.sum, tt.count, got)
+			}
+		})
+	}
+}
+
+func TestBayesianRatingShrinksTowardPrior(t *testing.T) {
+	// 评分数越多，平均分越接近实际均值
+	prev := bayesianRating(5, 1)
+	for _, count := range []float64{10, 100, 1000} {
+		got := bayesianRating(5*count, count)
+		if got <= prev || got >= 5 {
+			t.Fatalf("bayesianRating with %v fives = %v, want in (%v, 5)", count, got, prev)
+		}
+		prev = got
+	}
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/search"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	searchQueueSize = 1024
+	searchBatchSize = 200
+
+	suggestCheckEvery   = 30 * time.Second // 小说有变更时，最迟在这个间隔后重建输入建议
+	suggestRefreshEvery = 10 * time.Minute // 阅读量、收藏数变化后定期刷新热度
+	suggestFavoriteRate = 10               // 一次收藏相当于多少次阅读
+	suggestCharacterCut = 0.5              // 角色名热度相对所属小说的折扣
+)
+
+// searchTask 待同步到索引的变更；ChapterID 为 0 时表示小说元数据变更
+type searchTask struct {
+	NovelID   uint
+	ChapterID uint
+}
+
+// SearchItem 搜索结果条目
+type SearchItem struct {
+	Novel        models.Novel `json:"novel"`
+	Title        string       `json:"title"`   // 高亮后的标题
+	Snippet      string       `json:"snippet"` // 高亮后的摘要
+	ChapterID    uint         `json:"chapterId,omitempty"`
+	ChapterTitle string       `json:"chapterTitle,omitempty"`
+	Score        float64      `json:"score"`
+}
+
+type SearchService struct {
+	db           *gorm.DB
+	searcher     search.Searcher
+	suggester    *search.Suggester
+	tasks        chan searchTask
+	suggestDirty atomic.Bool // 小说元数据有变更，输入建议待重建
+	indexStale   atomic.Bool // 有变更因队列已满被丢弃，索引待全量重建
+
+	rebuildMu sync.Mutex
+	// rebuilding 不为 nil 时正在全量重建，记录重建期间处理过的变更，
+	// 重建可能用较早读到的数据覆盖这些变更，完成后需要重新同步
+	rebuilding map[searchTask]bool
+}
+
+func NewSearchService(db *gorm.DB, searcher search.Searcher) *SearchService {
+	return &SearchService{
+		db:        db,
+		searcher:  searcher,
+		suggester: search.NewSuggester(),
+		tasks:     make(chan searchTask, searchQueueSize),
+	}
+}
+
+// Start 后台重建索引和输入建议，同时持续处理增量变更，ctx 结束时退出
+func (s *SearchService) Start(ctx context.Context) {
+	s.rebuildAsync()
+
+	go func() {
+		lastRefresh := time.Now()
+		ticker := time.NewTicker(suggestCheckEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case task := <-s.tasks:
+				s.apply(task)
+			case <-ticker.C:
+				if s.indexStale.Swap(false) {
+					s.rebuildAsync()
+				}
+				if s.suggestDirty.Swap(false) || time.Since(lastRefresh) >= suggestRefreshEvery {
+					s.refreshSuggestions()
+					lastRefresh = time.Now()
+				}
+			}
+		}
+	}()
+}
+
+// rebuildAsync 在单独的 goroutine 中全量重建索引和输入建议，已在重建时不重复启动
+func (s *SearchService) rebuildAsync() {
+	s.rebuildMu.Lock()
+	if s.rebuilding != nil {
+		s.rebuildMu.Unlock()
+		return
+	}
+	s.rebuilding = make(map[searchTask]bool)
+	s.rebuildMu.Unlock()
+
+	go func() {
+		started := time.Now()
+		if err := s.Rebuild(); err != nil {
+			log.Printf("Failed to build search index: %v", err)
+			s.indexStale.Store(true)
+		} else {
+			log.Printf("Search index built in %v", time.Since(started))
+		}
+
+		s.rebuildMu.Lock()
+		replay := s.rebuilding
+		s.rebuilding = nil
+		s.rebuildMu.Unlock()
+		for task := range replay {
+			s.apply(task)
+		}
+		s.refreshSuggestions()
+	}()
+}
+
+// NovelChanged 小说新增、修改或删除后调用，异步同步到索引
+func (s *SearchService) NovelChanged(novelID uint) {
+	s.enqueue(searchTask{NovelID: novelID})
+}
+
+// ChapterChanged 章节新增、修改、删除或状态变化后调用，异步同步到索引
+func (s *SearchService) ChapterChanged(chapterID uint) {
+	s.enqueue(searchTask{ChapterID: chapterID})
+}
+
+// InvalidateSuggestions 标记输入建议待重建，用于小说可见性变化等影响建议内容的操作
+func (s *SearchService) InvalidateSuggestions() {
+	if s == nil {
+		return
+	}
+	s.suggestDirty.Store(true)
+}
+
+func (s *SearchService) enqueue(task searchTask) {
+	if s == nil {
+		return
+	}
+	// 不阻塞保存内容的请求，队列已满时丢弃，并标记索引待全量重建
+	select {
+	case s.tasks <- task:
+	default:
+		s.indexStale.Store(true)
+		log.Printf("Search queue full, dropped change of novel %d chapter %d", task.NovelID, task.ChapterID)
+	}
+}
+
+// apply 从数据库读取最新状态并更新索引
+func (s *SearchService) apply(task searchTask) {
+	s.rebuildMu.Lock()
+	if s.rebuilding != nil {
+		s.rebuilding[task] = true
+	}
+	s.rebuildMu.Unlock()
+
+	var err error
+	if task.ChapterID != 0 {
+		err = s.syncChapter(task.ChapterID)
+	} else {
+		err = s.syncNovel(task.NovelID)
+		s.suggestDirty.Store(true)
+	}
+	if err != nil {
+		log.Printf("Failed to sync search index (novel %d, chapter %d): %v", task.NovelID, task.ChapterID, err)
+	}
+}
+
+func (s *SearchService) syncNovel(novelID uint) error {
+	var novel models.Novel
+	err := s.db.Omit("novel_outline").Preload("Author").First(&novel, novelID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.searcher.RemoveNovel(novelID)
+	}
+	if err != nil {
+		return err
+	}
+	// 被隐藏的小说连同章节一起移出索引
+	if novel.HiddenAt != nil {
+		return s.searcher.RemoveNovel(novelID)
+	}
+	return s.searcher.IndexNovel(novelDocument(novel))
+}
+
+// syncChapter 只有可见小说中已发布的章节进入索引
+func (s *SearchService) syncChapter(chapterID uint) error {
+	var chapter models.Chapter
+	err := s.db.First(&chapter, chapterID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.searcher.RemoveChapter(chapterID)
+	}
+	if err != nil {
+		return err
+	}
+	if chapter.Status != models.ChapterStatusPublished {
+		return s.searcher.RemoveChapter(chapterID)
+	}
+	var visible int64
+	if err := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).
+		Where("id = ?", chapter.NovelID).Count(&visible).Error; err != nil {
+		return err
+	}
+	if visible == 0 {
+		return s.searcher.RemoveChapter(chapterID)
+	}
+	return s.searcher.IndexChapter(chapterDocument(chapter))
+}
+
+// Rebuild 从数据库全量建立索引，并移除重建前已在索引中、但现在不应被检索的小说和章节
+func (s *SearchService) Rebuild() error {
+	staleNovels, staleChapters := s.searcher.IndexedIDs()
+	seenNovels := make(map[uint]bool)
+	seenChapters := make(map[uint]bool)
+
+	var novels []models.Novel
+	err := s.db.Omit("novel_outline").Preload("Author").Scopes(models.VisibleNovels).
+		FindInBatches(&novels, searchBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, novel := range novels {
+				if err := s.searcher.IndexNovel(novelDocument(novel)); err != nil {
+					return err
+				}
+				seenNovels[novel.ID] = true
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	var chapters []models.Chapter
+	visible := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).Select("id")
+	err = s.db.Where("status = ? AND novel_id IN (?)", models.ChapterStatusPublished, visible).
+		FindInBatches(&chapters, searchBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, chapter := range chapters {
+				if err := s.searcher.IndexChapter(chapterDocument(chapter)); err != nil {
+					return err
+				}
+				seenChapters[chapter.ID] = true
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range staleNovels {
+		if !seenNovels[id] {
+			if err := s.searcher.RemoveNovel(id); err != nil {
+				return err
+			}
+		}
+	}
+	for _, id := range staleChapters {
+		if !seenChapters[id] {
+			if err := s.searcher.RemoveChapter(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Search 搜索小说，按相关度排序返回当前页
+func (s *SearchService) Search(q search.Query) ([]SearchItem, int, error) {
+	result, err := s.searcher.Search(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(result.Hits) == 0 {
+		return []SearchItem{}, result.Total, nil
+	}
+
+	ids := make([]uint, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		ids = append(ids, hit.NovelID)
+	}
+	novels, err := s.loadNovels(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]SearchItem, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		novel, ok := novels[hit.NovelID]
+		if !ok {
+			// 索引尚未同步删除
+			continue
+		}
+		items = append(items, SearchItem{
+			Novel:        novel,
+			Title:        hit.Title,
+			Snippet:      hit.Snippet,
+			ChapterID:    hit.ChapterID,
+			ChapterTitle: hit.ChapterTitle,
+			Score:        hit.Score,
+		})
+	}
+	return items, result.Total, nil
+}
+
+// SearchNovels 只返回小说列表，供关键词筛选的列表接口使用
+func (s *SearchService) SearchNovels(q search.Query) ([]models.Novel, int64, error) {
+	items, total, err := s.Search(q)
+	if err != nil {
+		return nil, 0, err
+	}
+	novels := make([]models.Novel, 0, len(items))
+	for _, item := range items {
+		novels = append(novels, item.Novel)
+	}
+	return novels, int64(total), nil
+}
+
+// Suggest 搜索框输入建议，支持标题、作者、标签、角色名前缀及拼音首字母
+func (s *SearchService) Suggest(prefix string, limit int, suggestionType string) []search.Suggestion {
+	return s.suggester.Suggest(prefix, limit, suggestionType)
+}
+
+func (s *SearchService) refreshSuggestions() {
+	if err := s.rebuildSuggestions(); err != nil {
+		log.Printf("Failed to build search suggestions: %v", err)
+	}
+}
+
+// rebuildSuggestions 汇总小说标题、作者、标签和大纲中的角色名，按阅读量和收藏数计算热度
+func (s *SearchService) rebuildSuggestions() error {
+	var entries []search.Suggestion
+	authors := make(map[uint]*search.Suggestion)
+	tags := make(map[string]*search.Suggestion)
+
+	var novels []models.Novel
+	err := s.db.Scopes(models.VisibleNovels).
+		Select("id", "title", "author_id", "read_count", "favorite_count", "tags", "novel_outline").
+		Preload("Author", func(db *gorm.DB) *gorm.DB { return db.Select("id", "username") }).
+		FindInBatches(&novels, searchBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, novel := range novels {
+				weight := float64(novel.ReadCount + novel.FavoriteCount*suggestFavoriteRate)
+				entries = append(entries, search.Suggestion{
+					Text: novel.Title, Type: search.SuggestNovel, NovelID: novel.ID, AuthorID: novel.AuthorID, Weight: weight,
+				})
+
+				author := authors[novel.AuthorID]
+				if author == nil {
+					author = &search.Suggestion{Text: novel.Author.Username, Type: search.SuggestAuthor, AuthorID: novel.AuthorID}
+					authors[novel.AuthorID] = author
+				}
+				author.Weight += weight
+
+				for _, tag := range novel.Tags {
+					if tag = strings.TrimSpace(tag); tag == "" {
+						continue
+					}
+					if tags[tag] == nil {
+						tags[tag] = &search.Suggestion{Text: tag, Type: search.SuggestTag}
+					}
+					tags[tag].Weight += weight
+				}
+
+				if novel.NovelOutline == nil {
+					continue
+				}
+				for _, character := range novel.NovelOutline.WorldBuilding.Characters {
+					if name := strings.TrimSpace(character.Name); name != "" {
+						entries = append(entries, search.Suggestion{
+							Text: name, Type: search.SuggestCharacter, NovelID: novel.ID, NovelTitle: novel.Title,
+							Weight: weight * suggestCharacterCut,
+						})
+					}
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	for _, author := range authors {
+		if author.Text != "" {
+			entries = append(entries, *author)
+		}
+	}
+	for _, tag := range tags {
+		entries = append(entries, *tag)
+	}
+	s.suggester.Replace(entries)
+	return nil
+}
+
+func (s *SearchService) loadNovels(ids []uint) (map[uint]models.Novel, error) {
+	var novels []models.Novel
+	if err := s.db.Omit("novel_outline").Preload("Author").Scopes(models.VisibleNovels).
+		Where("id IN ?", ids).Find(&novels).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]models.Novel, len(novels))
+	for _, novel := range novels {
+		result[novel.ID] = novel
+	}
+	return result, nil
+}
+
+func novelDocument(novel models.Novel) search.NovelDocument {
+	return search.NovelDocument{
+		ID:          novel.ID,
+		Title:       novel.Title,
+		Description: novel.Description,
+		Author:      novel.Author.Username,
+		Category:    novel.Category,
+		Status:      novel.Status,
+		Tags:        novel.Tags,
+		WordCount:   novel.WordCount,
+	}
+}
+
+func chapterDocument(chapter models.Chapter) search.ChapterDocument {
+	return search.ChapterDocument{
+		ID:      chapter.ID,
+		NovelID: chapter.NovelID,
+		Title:   chapter.Title,
+		Content: strings.Join(SplitParagraphs(chapter.Content), "\n"),
+	}
+}
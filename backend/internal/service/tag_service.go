@@ -0,0 +1,565 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxTagLength    = 20 // 标签最大字数
+	maxNovelTags    = 10 // 每本小说最多标签数
+	tagMigrateBatch = 200
+)
+
+var (
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrTooManyTags = errors.New("too many tags")
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagConflict = errors.New("tag name already in use")
+)
+
+// tagSeed 预置标签及其同义写法
+type tagSeed struct {
+	name     string
+	category string
+	synonyms []string
+}
+
+var defaultTags = []tagSeed{
+	{"修仙", models.TagCategoryGenre, []string{"仙侠", "修真", "修仙流"}},
+	{"玄幻", models.TagCategoryGenre, []string{"东方玄幻", "高武"}},
+	{"奇幻", models.TagCategoryGenre, []string{"西幻", "西方奇幻", "魔幻"}},
+	{"武侠", models.TagCategoryGenre, []string{"江湖", "古典武侠"}},
+	{"都市", models.TagCategoryGenre, []string{"现代都市", "都市生活"}},
+	{"科幻", models.TagCategoryGenre, []string{"sf", "硬科幻", "星际"}},
+	{"末世", models.TagCategoryGenre, []string{"末日", "废土", "丧尸"}},
+	{"悬疑", models.TagCategoryGenre, []string{"推理", "侦探", "惊悚"}},
+	{"历史", models.TagCategoryGenre, []string{"架空历史", "历史架空"}},
+	{"游戏", models.TagCategoryGenre, []string{"网游", "电竞", "虚拟网游"}},
+	{"言情", models.TagCategoryGenre, []string{"恋爱", "爱情"}},
+	{"穿越", models.TagCategoryElement, []string{"穿越流", "魂穿"}},
+	{"重生", models.TagCategoryElement, []string{"重生流", "回档"}},
+	{"系统", models.TagCategoryElement, []string{"系统流", "金手指"}},
+	{"无限流", models.TagCategoryElement, []string{"无限", "主神空间"}},
+	{"种田", models.TagCategoryElement, []string{"种田流", "经营"}},
+	{"升级", models.TagCategoryElement, []string{"升级流", "练级"}},
+	{"爽文", models.TagCategoryStyle, []string{"爽", "打脸"}},
+	{"轻松", models.TagCategoryStyle, []string{"搞笑", "沙雕", "欢乐"}},
+	{"热血", models.TagCategoryStyle, []string{"燃"}},
+	{"甜宠", models.TagCategoryStyle, []string{"甜文", "甜"}},
+	{"虐文", models.TagCategoryStyle, []string{"虐", "虐恋"}},
+	{"暗黑", models.TagCategoryStyle, []string{"黑暗", "致郁"}},
+	{"治愈", models.TagCategoryStyle, []string{"温馨", "暖心"}},
+}
+
+type TagService struct {
+	db     *gorm.DB
+	search *SearchService
+}
+
+func NewTagService(db *gorm.DB, searchService *SearchService) *TagService {
+	return &TagService{db: db, search: searchService}
+}
+
+// NormalizeTagName 规范化标签写法：全角转半角、英文转小写、去掉开头的 # 并合并空白
+func NormalizeTagName(raw string) string {
+	name := width.Fold.String(raw)
+	name = strings.TrimLeft(strings.TrimSpace(name), "#")
+	name = strings.Join(strings.Fields(name), " ")
+	return strings.ToLower(name)
+}
+
+// validateTagName 检查规范化后的标签：不超过 maxTagLength 个字，只允许文字、数字、空格、连字符和间隔号
+func validateTagName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > maxTagLength {
+		return fmt.Errorf("%w: 标签长度需在 1-%d 字之间", ErrInvalidTag, maxTagLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && r != '-' && r != '·' {
+			return fmt.Errorf("%w: 标签“%s”包含不支持的字符", ErrInvalidTag, name)
+		}
+	}
+	return nil
+}
+
+// EnsureDefaultTags 写入预置标签和同义写法，已存在的不做修改
+func (s *TagService) EnsureDefaultTags() error {
+	for _, seed := range defaultTags {
+		tag := models.Tag{Name: seed.name, Category: seed.category}
+		if err := s.db.Where("name = ?", seed.name).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		for _, synonym := range seed.synonyms {
+			var count int64
+			if err := s.db.Model(&models.Tag{}).Where("name = ?", synonym).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				// 同义写法已经作为独立标签在使用，需人工合并
+				log.Printf("Tag synonym %q of %q already exists as a tag, skipped", synonym, seed.name)
+				continue
+			}
+			if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.TagSynonym{TagID: tag.ID, Name: synonym}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Resolve 把小说提交的标签规范化并映射到标签表，同义写法归并到规范标签，
+// 新标签自动创建为未归类标签。strict 为 false 时跳过不合法的标签并截断多余的标签，用于迁移旧数据
+func (s *TagService) Resolve(tx *gorm.DB, raw []string, strict bool) ([]models.Tag, error) {
+	names := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		name := NormalizeTagName(r)
+		if err := validateTagName(name); err != nil {
+			if strict {
+				return nil, err
+			}
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []models.Tag{}, nil
+	}
+
+	byName, err := s.lookup(tx, names)
+	if err != nil {
+		return nil, err
+	}
+	var missing []models.Tag
+	for _, name := range names {
+		if _, ok := byName[name]; !ok {
+			missing = append(missing, models.Tag{Name: name, Category: models.TagCategoryOther})
+		}
+	}
+	if len(missing) > 0 {
+		// 并发创建同名标签时以先写入的为准
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&missing).Error; err != nil {
+			return nil, err
+		}
+		if byName, err = s.lookup(tx, names); err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(names))
+	added := make(map[uint]bool, len(names))
+	for _, name := range names {
+		tag, ok := byName[name]
+		if !ok || added[tag.ID] {
+			continue
+		}
+		added[tag.ID] = true
+		tags = append(tags, tag)
+	}
+	if len(tags) > maxNovelTags {
+		if strict {
+			return nil, fmt.Errorf("%w: 每本小说最多 %d 个标签", ErrTooManyTags, maxNovelTags)
+		}
+		tags = tags[:maxNovelTags]
+	}
+	return tags, nil
+}
+
+// lookup 按规范名或同义写法查找标签
+func (s *TagService) lookup(tx *gorm.DB, names []string) (map[string]models.Tag, error) {
+	var tags []models.Tag
+	if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	var synonyms []models.TagSynonym
+	if err := tx.Where("name IN ?", names).Find(&synonyms).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]models.Tag, len(names))
+	for _, t := range tags {
+		result[t.Name] = t
+	}
+	if len(synonyms) > 0 {
+		ids := make([]uint, 0, len(synonyms))
+		for _, syn := range synonyms {
+			ids = append(ids, syn.TagID)
+		}
+		var canonical []models.Tag
+		if err := tx.Where("id IN ?", ids).Find(&canonical).Error; err != nil {
+			return nil, err
+		}
+		byID := make(map[uint]models.Tag, len(canonical))
+		for _, t := range canonical {
+			byID[t.ID] = t
+		}
+		for _, syn := range synonyms {
+			if t, ok := byID[syn.TagID]; ok {
+				result[syn.Name] = t
+			}
+		}
+	}
+	return result, nil
+}
+
+// SetNovelTags 用给定标签替换小说的标签关联，并同步标签使用次数
+func (s *TagService) SetNovelTags(tx *gorm.DB, novelID uint, tags []models.Tag) error {
+	var current []uint
+	if err := tx.Model(&models.NovelTag{}).Where("novel_id = ?", novelID).Pluck("tag_id", &current).Error; err != nil {
+		return err
+	}
+	keep := make(map[uint]bool, len(tags))
+	for _, t := range tags {
+		keep[t.ID] = true
+	}
+	existing := make(map[uint]bool, len(current))
+	var removed []uint
+	for _, id := range current {
+		existing[id] = true
+		if !keep[id] {
+			removed = append(removed, id)
+		}
+	}
+	var added []models.NovelTag
+	var addedIDs []uint
+	for _, t := range tags {
+		if !existing[t.ID] {
+			added = append(added, models.NovelTag{NovelID: novelID, TagID: t.ID})
+			addedIDs = append(addedIDs, t.ID)
+		}
+	}
+
+	if len(removed) > 0 {
+		if err := tx.Where("novel_id = ? AND tag_id IN ?", novelID, removed).Delete(&models.NovelTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Tag{}).Where("id IN ? AND usage_count > 0", removed).
+			UpdateColumn("usage_count", gorm.Expr("usage_count - 1")).Error; err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		if err := tx.Create(&added).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Tag{}).Where("id IN ?", addedIDs).
+			UpdateColumn("usage_count", gorm.Expr("usage_count + 1")).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MigrateLegacyTags 为还没有标签关联的小说解析旧的 JSON 标签，写回规范化后的标签名。
+// 可重复执行，已迁移的小说会被跳过
+func (s *TagService) MigrateLegacyTags(ctx context.Context) error {
+	var lastID uint
+	for {
+		var novels []models.Novel
+		if err := s.db.WithContext(ctx).Select("id", "tags").
+			Where("id > ? AND tags IS NOT NULL AND JSON_LENGTH(tags) > 0", lastID).
+			Where("NOT EXISTS (SELECT 1 FROM novel_tags WHERE novel_tags.novel_id = novels.id)").
+			Order("id asc").Limit(tagMigrateBatch).
+			Find(&novels).Error; err != nil {
+			return err
+		}
+		if len(novels) == 0 {
+			return nil
+		}
+		for _, n := range novels {
+			err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				tags, err := s.Resolve(tx, n.Tags, false)
+				if err != nil {
+					return err
+				}
+				if err := s.SetNovelTags(tx, n.ID, tags); err != nil {
+					return err
+				}
+				return tx.Model(&models.Novel{}).Where("id = ?", n.ID).
+					UpdateColumn("tags", TagNames(tags)).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate tags of novel %d: %w", n.ID, err)
+			}
+		}
+		lastID = novels[len(novels)-1].ID
+	}
+}
+
+// ListTags 按使用次数列出标签，category 为空时列出全部分类
+func (s *TagService) ListTags(category, keyword string, page, pageSize int) ([]models.Tag, int64, error) {
+	var tags []models.Tag
+	var total int64
+
+	query := s.db.Model(&models.Tag{})
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if keyword = NormalizeTagName(keyword); keyword != "" {
+		query = query.Where("name LIKE ? OR id IN (SELECT tag_id FROM tag_synonyms WHERE name LIKE ?)", keyword+"%", keyword+"%")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Preload("Synonyms").Order("usage_count desc, id asc").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&tags).Error; err != nil {
+		return nil, 0, err
+	}
+	return tags, total, nil
+}
+
+// GetTag 按规范名或同义写法获取标签
+func (s *TagService) GetTag(name string) (*models.Tag, error) {
+	name = NormalizeTagName(name)
+	byName, err := s.lookup(s.db, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	tag, ok := byName[name]
+	if !ok {
+		return nil, ErrTagNotFound
+	}
+	if err := s.db.Model(&tag).Association("Synonyms").Find(&tag.Synonyms); err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// ListNovelsByTags 列出同时带有全部给定标签的小说，sortBy 为 popular 时按人气排序，否则按更新时间
+func (s *TagService) ListNovelsByTags(names []string, sortBy string, page, pageSize int) ([]models.Novel, int64, error) {
+	normalized := make([]string, 0, len(names))
+	for _, n := range names {
+		if n = NormalizeTagName(n); n != "" {
+			normalized = append(normalized, n)
+		}
+	}
+	if len(normalized) == 0 {
+		return []models.Novel{}, 0, nil
+	}
+	byName, err := s.lookup(s.db, normalized)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make(map[uint]bool, len(normalized))
+	for _, n := range normalized {
+		tag, ok := byName[n]
+		if !ok {
+			// 有不存在的标签时交集为空
+			return []models.Novel{}, 0, nil
+		}
+		ids[tag.ID] = true
+	}
+	tagIDs := make([]uint, 0, len(ids))
+	for id := range ids {
+		tagIDs = append(tagIDs, id)
+	}
+
+	matched := s.db.Model(&models.NovelTag{}).Select("novel_id").
+		Where("tag_id IN ?", tagIDs).
+		Group("novel_id").Having("COUNT(*) = ?", len(tagIDs))
+	query := s.db.Model(&models.Novel{}).Scopes(models.VisibleNovels).Where("id IN (?)", matched)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if sortBy == "popular" {
+		query = query.Order(gorm.Expr("read_count + favorite_count * ? DESC", rankingFavoriteWeight))
+	} else {
+		query = query.Order("updated_at desc")
+	}
+	var novels []models.Novel
+	if err := query.Omit("novel_outline").Preload("Author").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&novels).Error; err != nil {
+		return nil, 0, err
+	}
+	return novels, total, nil
+}
+
+// MergeTags 把 source 合并到 target：小说关联转移到 target，source 的名称和同义写法成为 target 的同义写法，
+// 受影响小说的标签名和搜索索引随之更新
+func (s *TagService) MergeTags(sourceID, targetID uint) (*models.Tag, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("%w: 不能合并到自身", ErrInvalidTag)
+	}
+	var target models.Tag
+	var novelIDs []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var source models.Tag
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return tagLookupError(err)
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return tagLookupError(err)
+		}
+
+		if err := tx.Model(&models.NovelTag{}).Where("tag_id = ?", sourceID).Pluck("novel_id", &novelIDs).Error; err != nil {
+			return err
+		}
+		if len(novelIDs) > 0 {
+			// 已带有目标标签的小说只删除源标签的关联，避免主键冲突
+			var both []uint
+			if err := tx.Model(&models.NovelTag{}).Where("tag_id = ? AND novel_id IN ?", targetID, novelIDs).
+				Pluck("novel_id", &both).Error; err != nil {
+				return err
+			}
+			if len(both) > 0 {
+				if err := tx.Where("tag_id = ? AND novel_id IN ?", sourceID, both).Delete(&models.NovelTag{}).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Model(&models.NovelTag{}).Where("tag_id = ?", sourceID).
+				UpdateColumn("tag_id", targetID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.TagSynonym{}).Where("tag_id = ?", sourceID).
+			UpdateColumn("tag_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&source).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.TagSynonym{TagID: targetID, Name: source.Name}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&target).UpdateColumn("usage_count",
+			tx.Model(&models.NovelTag{}).Select("COUNT(*)").Where("tag_id = ?", targetID)).Error; err != nil {
+			return err
+		}
+		return renameNovelTag(tx, novelIDs, source.Name, target.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range novelIDs {
+		s.search.NovelChanged(id)
+	}
+	s.search.InvalidateSuggestions()
+	return s.getTagByID(targetID)
+}
+
+// renameNovelTag 把小说 JSON 标签中的 from 替换为 to，已有 to 时直接去掉 from
+func renameNovelTag(tx *gorm.DB, novelIDs []uint, from, to string) error {
+	for start := 0; start < len(novelIDs); start += tagMigrateBatch {
+		end := start + tagMigrateBatch
+		if end > len(novelIDs) {
+			end = len(novelIDs)
+		}
+		var novels []models.Novel
+		if err := tx.Select("id", "tags").Where("id IN ?", novelIDs[start:end]).Find(&novels).Error; err != nil {
+			return err
+		}
+		for _, n := range novels {
+			names := make(models.StringArray, 0, len(n.Tags))
+			seen := make(map[string]bool, len(n.Tags))
+			for _, name := range n.Tags {
+				if name == from {
+					name = to
+				}
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			if err := tx.Model(&models.Novel{}).Where("id = ?", n.ID).UpdateColumn("tags", names).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddSynonym 为标签添加同义写法，写法已是其他标签或同义写法时返回 ErrTagConflict
+func (s *TagService) AddSynonym(tagID uint, raw string) (*models.Tag, error) {
+	name := NormalizeTagName(raw)
+	if err := validateTagName(name); err != nil {
+		return nil, err
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, tagID).Error; err != nil {
+			return tagLookupError(err)
+		}
+		byName, err := s.lookup(tx, []string{name})
+		if err != nil {
+			return err
+		}
+		if _, ok := byName[name]; ok {
+			return fmt.Errorf("%w: “%s”已是标签或同义写法", ErrTagConflict, name)
+		}
+		return tx.Create(&models.TagSynonym{TagID: tagID, Name: name}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.getTagByID(tagID)
+}
+
+// DeleteSynonym 删除标签的一个同义写法
+func (s *TagService) DeleteSynonym(tagID uint, raw string) (*models.Tag, error) {
+	result := s.db.Where("tag_id = ? AND name = ?", tagID, NormalizeTagName(raw)).Delete(&models.TagSynonym{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTagNotFound
+	}
+	return s.getTagByID(tagID)
+}
+
+// SetTagCategory 修改标签分类
+func (s *TagService) SetTagCategory(tagID uint, category string) (*models.Tag, error) {
+	switch category {
+	case models.TagCategoryGenre, models.TagCategoryElement, models.TagCategoryStyle, models.TagCategoryOther:
+	default:
+		return nil, fmt.Errorf("%w: 未知的标签分类“%s”", ErrInvalidTag, category)
+	}
+	result := s.db.Model(&models.Tag{}).Where("id = ?", tagID).Update("category", category)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return s.getTagByID(tagID)
+}
+
+func (s *TagService) getTagByID(id uint) (*models.Tag, error) {
+	var tag models.Tag
+	if err := s.db.Preload("Synonyms").First(&tag, id).Error; err != nil {
+		return nil, tagLookupError(err)
+	}
+	return &tag, nil
+}
+
+func tagLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTagNotFound
+	}
+	return err
+}
+
+// TagNames 标签名列表，写入 Novel.Tags
+func TagNames(tags []models.Tag) models.StringArray {
+	names := make(models.StringArray, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/policy"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrLastAdmin    = errors.New("cannot demote the last admin")
+)
+
+// GetRole 获取用户当前角色
+func (s *UserService) GetRole(userID uint) (string, error) {
+	var user models.User
+	if err := s.db.Select("id", "role").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrUserNotFound
+		}
+		return "", err
+	}
+	return user.Role, nil
+}
+
+// UpdateRole 修改用户角色，系统中至少保留一个管理员
+func (s *UserService) UpdateRole(userID uint, role string) (*models.User, error) {
+	if !policy.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if user.Role == policy.RoleAdmin && role != policy.RoleAdmin {
+			var admins int64
+			// 锁住所有管理员，避免并发降级时同时通过检查
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.User{}).
+				Where("role = ?", policy.RoleAdmin).Count(&admins).Error; err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return ErrLastAdmin
+			}
+		}
+		user.Role = role
+		return tx.Model(&user).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// EnsureAdmin 创建管理员账号；用户名已存在时将其提升为管理员并重设密码
+func (s *UserService) EnsureAdmin(username, email, password string) (*models.User, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = s.db.Where("username = ?", username).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) && email == "" {
+		return nil, errors.New("email is required to create a new admin")
+	}
+	switch {
+	case err == nil:
+		err = s.db.Model(&user).Updates(map[string]interface{}{
+			"role":          policy.RoleAdmin,
+			"password_hash": string(hashedPassword),
+		}).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user = models.User{
+			Username:     username,
+			Email:        email,
+			PasswordHash: string(hashedPassword),
+			Role:         policy.RoleAdmin,
+		}
+		err = s.db.Create(&user).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// BackfillAuthorRoles 已经发布过作品的读者补记为作者，用于引入角色之前注册的用户
+func (s *UserService) BackfillAuthorRoles() error {
+	return s.db.Model(&models.User{}).
+		Where("role = ? AND EXISTS (SELECT 1 FROM novels WHERE novels.author_id = users.id)", policy.RoleReader).
+		Update("role", policy.RoleAuthor).Error
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"ai-novel-platform/internal/models"
+	"ai-novel-platform/internal/policy"
 	"ai-novel-platform/internal/utils"
 	"errors"
 	"time"
@@ -42,6 +43,7 @@ func (s *UserService) Register(username, password, email string) error {
 		PasswordHash: string(hashedPassword),
 		Email:        email,
 		Bio:          "",
+		Role:         policy.RoleReader,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 		ResetExpires: time.Now().Add(1 * time.Hour),
@@ -60,10 +62,25 @@ func (s *UserService) Login(username, password string) (*models.User, error) {
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
 		return nil, errors.New("invalid password")
 	}
+	if user.IsBanned(time.Now()) {
+		return nil, ErrUserBanned
+	}
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
 
 	return &user, nil
 }
 
+// LookupUserID 根据用户名查找用户ID，不存在时返回 0；用于记录登录失败
+func (s *UserService) LookupUserID(username string) uint {
+	var user models.User
+	if err := s.db.Select("id").Where("username = ?", username).First(&user).Error; err != nil {
+		return 0
+	}
+	return user.ID
+}
+
 func (s *UserService) RequestPasswordReset(email string) (string, error) {
 	var user models.User
 	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
@@ -84,23 +101,25 @@ func (s *UserService) RequestPasswordReset(email string) (string, error) {
 	return resetToken, nil
 }
 
-func (s *UserService) ResetPassword(token, newPassword string) error {
+// ResetPassword 使用重置令牌设置新密码，返回对应的用户ID
+func (s *UserService) ResetPassword(token, newPassword string) (uint, error) {
 	var user models.User
 	if err := s.db.Where("reset_token = ? AND reset_expires > ?", token, time.Now()).First(&user).Error; err != nil {
-		return errors.New("invalid or expired reset token")
+		return 0, errors.New("invalid or expired reset token")
 	}
 
 	// 加密新密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	// 更新密码并清除重置token
 	user.PasswordHash = string(hashedPassword)
 	user.ResetToken = ""
 	user.ResetExpires = time.Time{}
-	return s.db.Save(&user).Error
+	user.PasswordResetRequired = false
+	return user.ID, s.db.Save(&user).Error
 }
 
 func (s *UserService) UpdateUser(userID uint, updates map[string]interface{}) error {
@@ -0,0 +1,85 @@
+// Package simhash 计算文本的 64 位 SimHash 指纹，用于发现近似重复的内容。
+// 相似文本的指纹只有少数位不同；把指纹切成若干段后，海明距离不超过段数减一的两个指纹
+// 至少有一段完全相同，因此可以按段建索引，只比较至少一段相同的候选
+package simhash
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"unicode"
+)
+
+const (
+	// ShingleSize 特征为连续若干个字符组成的片段
+	ShingleSize = 4
+	// BandCount 指纹切分的段数，每段 16 位
+	BandCount = 4
+)
+
+// Fingerprint 文本的 SimHash 指纹
+type Fingerprint struct {
+	Hash     uint64
+	Shingles int // 参与计算的特征数，文本过短时指纹不可靠
+}
+
+// Compute 计算文本指纹。忽略大小写、空白和标点，只用字母、数字和汉字组成特征，
+// 每个特征按出现次数加权
+func Compute(text string) Fingerprint {
+	var runes []rune
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+	if len(runes) < ShingleSize {
+		return Fingerprint{}
+	}
+
+	var weights [64]int
+	h := fnv.New64a()
+	buf := make([]byte, 0, ShingleSize*4)
+	shingles := len(runes) - ShingleSize + 1
+	for i := 0; i < shingles; i++ {
+		buf = buf[:0]
+		for _, r := range runes[i : i+ShingleSize] {
+			buf = append(buf, string(r)...)
+		}
+		h.Reset()
+		h.Write(buf)
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, w := range weights {
+		if w > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return Fingerprint{Hash: hash, Shingles: shingles}
+}
+
+// Distance 两个指纹的海明距离，即不同的位数
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similarity 按海明距离换算的相似度，0 到 1
+func Similarity(a, b uint64) float64 {
+	return 1 - float64(Distance(a, b))/64
+}
+
+// Bands 把指纹切分为 BandCount 段，用于建索引查找候选
+func Bands(hash uint64) [BandCount]uint16 {
+	var bands [BandCount]uint16
+	for i := range bands {
+		bands[i] = uint16(hash >> (16 * i))
+	}
+	return bands
+}
@@ -0,0 +1,113 @@
+package simhash
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func sharesBand(a, b uint64) bool {
+	ba, bb := Bands(a), Bands(b)
+	for i := range ba {
+		if ba[i] == bb[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func TestBandsWithinDistanceThree(t *testing.T) {
+	// 海明距离不超过 BandCount-1 的两个指纹至少有一段相同
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		a := rng.Uint64()
+		b := a
+		flips := rng.Intn(BandCount)
+		for _, bit := range rng.Perm(64)[:flips] {
+			b ^= 1 << bit
+		}
+		if Distance(a, b) != flips {
+			t.Fatalf("Distance(%x, %x) = %d, want %d", a, b, Distance(a, b), flips)
+		}
+		if !sharesBand(a, b) {
+			t.Fatalf("%x and %x at distance %d share no band", a, b, flips)
+		}
+	}
+}
+
+func TestBandsOneFlipPerBand(t *testing.T) {
+	// 每段各翻转一位时距离为 BandCount，所有段都不同，超出了保证范围
+	a := uint64(0x0123456789abcdef)
+	b := a
+	for i := 0; i < BandCount; i++ {
+		b ^= 1 << (16*i + i)
+	}
+	if Distance(a, b) != BandCount {
+		t.Fatalf("Distance = %d, want %d", Distance(a, b), BandCount)
+	}
+	if sharesBand(a, b) {
+		t.Fatalf("%x and %x share a band", a, b)
+	}
+}
+
+func TestBands(t *testing.T) {
+	got := Bands(0x0123456789abcdef)
+	want := [BandCount]uint16{0xcdef, 0x89ab, 0x4567, 0x0123}
+	if got != want {
+		t.Fatalf("Bands() = %x, want %x", got, want)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	base := "第一章 少年站在山巅，望着远方的云海，心中燃起了修仙的念头。Hello World"
+	tests := []struct {
+		name    string
+		a, b    string
+		maxDist int
+		minDist int
+	}{
+		{"identical", base, base, 0, 0},
+		{"case and punctuation ignored", base, "第一章少年站在山巅望着远方的云海心中燃起了修仙的念头hello world!!", 0, 0},
+		{"small edit stays close", base, "第一章 少年站在山顶，望着远方的云海，心中燃起了修仙的念头。Hello World", 20, 0},
+		{"unrelated text is far", base, "城市的夜晚霓虹闪烁，车流不息，她独自走在回家的路上，想起了很多往事。", 64, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := Compute(tt.a), Compute(tt.b)
+			if a.Shingles == 0 || b.Shingles == 0 {
+				t.Fatalf("Compute() returned no shingles: %+v %+v", a, b)
+			}
+			d := Distance(a.Hash, b.Hash)
+			if d > tt.maxDist || d < tt.minDist {
+				t.Fatalf("Distance = %d, want in [%d, %d]", d, tt.minDist, tt.maxDist)
+			}
+		})
+	}
+}
+
+func TestComputeShortText(t *testing.T) {
+	for _, text := range []string{"", "abc", "你好！", "a b, c"} {
+		if got := Compute(text); got != (Fingerprint{}) {
+			t.Errorf("Compute(%q) = %+v, want zero", text, got)
+		}
+	}
+	if got := Compute("abcd"); got.Shingles != 1 {
+		t.Errorf("Compute(abcd).Shingles = %d, want 1", got.Shingles)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want float64
+	}{
+		{0, 0, 1},
+		{0, ^uint64(0), 0},
+		{0, 0xffffffff, 0.5},
+		{0b1011, 0b0001, 1 - 2.0/64},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("Similarity(%x, %x) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
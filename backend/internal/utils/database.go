@@ -1,7 +1,7 @@
 package utils
 
 import (
-    "fmt"
+    "ai-novel-platform/config"
     "gorm.io/driver/mysql"
     "gorm.io/gorm"
     "gorm.io/gorm/logger"
@@ -10,26 +10,26 @@ import (
     "time"
 )
 
-func InitDB(username, password, host, port, dbname string) (*gorm.DB, error) {
-    dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-        username,
-        password,
-        host,
-        port,
-        dbname,
-    )
-    
+// gormLogLevels 配置中的日志级别名称
+var gormLogLevels = map[string]logger.LogLevel{
+    "silent": logger.Silent,
+    "error":  logger.Error,
+    "warn":   logger.Warn,
+    "info":   logger.Info,
+}
+
+func InitDB(cfg config.DatabaseConfig) (*gorm.DB, error) {
     // 配置GORM日志
     newLogger := logger.New(
         log.New(os.Stdout, "\r\n", log.LstdFlags),
         logger.Config{
             SlowThreshold: time.Second,
-            LogLevel:      logger.Info,
+            LogLevel:      gormLogLevels[cfg.LogLevel],
             Colorful:      true,
         },
     )
     
-    db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+    db, err := gorm.Open(mysql.Open(cfg.DSN()), &gorm.Config{
         Logger: newLogger,
     })
     if err != nil {
@@ -42,9 +42,9 @@ func InitDB(username, password, host, port, dbname string) (*gorm.DB, error) {
         return nil, err
     }
     
-    sqlDB.SetMaxIdleConns(10)
-    sqlDB.SetMaxOpenConns(100)
-    sqlDB.SetConnMaxLifetime(time.Hour)
+    sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+    sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+    sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
     
     return db, nil
 } 
\ No newline at end of file
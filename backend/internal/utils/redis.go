@@ -1,16 +1,16 @@
 package utils
 
 import (
+	"ai-novel-platform/config"
 	"context"
-	"fmt"
 	"github.com/go-redis/redis/v8"
 )
 
-func InitRedis(host, port, password string, db int) (*redis.Client, error) {
+func InitRedis(cfg config.RedisConfig) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", host, port),
-		Password: password,
-		DB:       db,
+		Addr:     cfg.Addr(),
+		Password: cfg.Password,
+		DB:       cfg.DB,
 	})
 
 	// 测试连接
@@ -0,0 +1,121 @@
+// src/api/admin.js
+import request from './request'
+
+export const adminApi = {
+  // 系统概况
+  getStats() {
+    return request.get('/v1/admin/stats')
+  },
+  // 搜索用户，params: { keyword, role, banned, page, limit }
+  listUsers(params) {
+    return request.get('/v1/admin/users', { params })
+  },
+  // 封禁用户，until 为空表示永久封禁
+  banUser(userId, reason, until) {
+    return request.post(`/v1/admin/users/${userId}/ban`, { reason, until })
+  },
+  // 解除封禁
+  unbanUser(userId) {
+    return request.delete(`/v1/admin/users/${userId}/ban`)
+  },
+  // 要求用户重置密码
+  forcePasswordReset(userId, reason) {
+    return request.post(`/v1/admin/users/${userId}/force-password-reset`, { reason })
+  },
+  // 获取已删除的小说
+  listDeletedNovels(params) {
+    return request.get('/v1/admin/novels/deleted', { params })
+  },
+  // 隐藏小说
+  hideNovel(novelId, reason) {
+    return request.post(`/v1/admin/novels/${novelId}/hide`, { reason })
+  },
+  // 取消隐藏小说
+  unhideNovel(novelId) {
+    return request.delete(`/v1/admin/novels/${novelId}/hide`)
+  },
+  // 恢复已删除的小说
+  restoreNovel(novelId) {
+    return request.post(`/v1/admin/novels/${novelId}/restore`)
+  },
+  // 隐藏章节
+  hideChapter(chapterId, reason) {
+    return request.post(`/v1/admin/chapters/${chapterId}/hide`, { reason })
+  },
+  // 取消隐藏章节
+  unhideChapter(chapterId) {
+    return request.delete(`/v1/admin/chapters/${chapterId}/hide`)
+  },
+  // 获取评论举报，params: { status, page, limit }
+  listReports(params) {
+    return request.get('/v1/admin/reports', { params })
+  },
+  // 处理举报，action 为 remove 或 reject
+  resolveReport(reportId, action, reason) {
+    return request.put(`/v1/admin/reports/${reportId}`, { action, reason })
+  },
+  // 获取疑似抄袭或重复上传的章节，params: { status, novelId, page, limit }，status 默认为待处理，-1 为全部
+  listDuplicates(params) {
+    return request.get('/v1/admin/duplicates', { params })
+  },
+  // 获取疑似重复章节的逐段对照证据
+  getDuplicate(id) {
+    return request.get(`/v1/admin/duplicates/${id}`)
+  },
+  // 处理疑似重复，action 为 confirm 或 dismiss
+  reviewDuplicate(id, action, note) {
+    return request.put(`/v1/admin/duplicates/${id}`, { action, note })
+  },
+  // 获取内容审核队列，params: { status, contentType, page, limit }，status 默认为待审核，-1 为全部
+  listModeration(params) {
+    return request.get('/v1/admin/moderation', { params })
+  },
+  // 审核内容，action 为 approve 或 reject，驳回时 reason 必填并会展示给作者
+  reviewModeration(itemId, action, reason) {
+    return request.put(`/v1/admin/moderation/${itemId}`, { action, reason })
+  },
+  // 立即重新加载敏感词库
+  reloadSensitiveWords() {
+    return request.post('/v1/admin/moderation/words/reload')
+  },
+  // 查询审计日志，params: { actorId, action, targetType, targetId, requestId, from, to, page, limit }
+  listAuditLogs(params) {
+    return request.get('/v1/admin/audit-logs', { params })
+  },
+  // 获取所有角色及其权限
+  listRoles() {
+    return request.get('/v1/admin/roles')
+  },
+  // 修改用户角色
+  updateUserRole(userId, role) {
+    return request.put(`/v1/admin/users/${userId}/role`, { role })
+  },
+  // 创建分类
+  createCategory(data) {
+    return request.post('/v1/admin/categories', data)
+  },
+  // 更新分类
+  updateCategory(id, data) {
+    return request.put(`/v1/admin/categories/${id}`, data)
+  },
+  // 删除分类
+  deleteCategory(id) {
+    return request.delete(`/v1/admin/categories/${id}`)
+  },
+  // 把标签合并到另一个标签，原标签名成为目标标签的同义写法
+  mergeTag(id, targetId) {
+    return request.post(`/v1/admin/tags/${id}/merge`, { targetId })
+  },
+  // 添加标签同义写法
+  addTagSynonym(id, name) {
+    return request.post(`/v1/admin/tags/${id}/synonyms`, { name })
+  },
+  // 删除标签同义写法
+  deleteTagSynonym(id, name) {
+    return request.delete(`/v1/admin/tags/${id}/synonyms/${encodeURIComponent(name)}`)
+  },
+  // 修改标签分类
+  updateTagCategory(id, category) {
+    return request.put(`/v1/admin/tags/${id}/category`, { category })
+  }
+}
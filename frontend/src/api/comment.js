@@ -0,0 +1,60 @@
+// src/api/comment.js
+import request from './request'
+
+export const commentApi = {
+  // 获取小说或章节评论（chapterId 为空时为整部小说的评论，paragraph 为段落序号时获取段评）
+  getComments(params) {
+    return request.get('/v1/comments', { params })
+  },
+
+  // 获取章节各段落的段评数量
+  getParagraphCounts(chapterId) {
+    return request.get('/v1/comments/paragraphs', { params: { chapterId } })
+  },
+
+  // 获取评论回复
+  getReplies(id, params) {
+    return request.get(`/v1/comments/${id}/replies`, { params })
+  },
+
+  // 发表评论或回复
+  createComment(data) {
+    return request.post('/v1/comments', data)
+  },
+
+  // 删除评论
+  deleteComment(id) {
+    return request.delete(`/v1/comments/${id}`)
+  },
+
+  // 点赞 / 取消点赞
+  likeComment(id) {
+    return request.post(`/v1/comments/${id}/like`)
+  },
+  unlikeComment(id) {
+    return request.delete(`/v1/comments/${id}/like`)
+  },
+
+  // 置顶 / 取消置顶（作者）
+  pinComment(id) {
+    return request.post(`/v1/comments/${id}/pin`)
+  },
+  unpinComment(id) {
+    return request.delete(`/v1/comments/${id}/pin`)
+  },
+
+  // 举报评论
+  reportComment(id, reason) {
+    return request.post(`/v1/comments/${id}/report`, { reason })
+  },
+
+  // 获取待处理的举报（作者）
+  getReports(params) {
+    return request.get('/v1/comments/reports', { params })
+  },
+
+  // 处理举报：remove 删除评论，reject 驳回
+  resolveReport(id, action) {
+    return request.put(`/v1/comments/reports/${id}`, { action })
+  }
+}
@@ -0,0 +1,55 @@
+// src/api/notification.js
+import request from './request'
+
+export const notificationApi = {
+  // 获取通知列表，可按 type 和 unread=true 筛选
+  getNotifications(params) {
+    return request.get('/v1/notifications', { params })
+  },
+
+  // 获取各类型未读数量
+  getUnreadCounts() {
+    return request.get('/v1/notifications/unread-count')
+  },
+
+  // 标记已读：传 ids 标记指定通知，否则按 type 标记（都不传时全部标记）
+  markRead(data) {
+    return request.put('/v1/notifications/read', data)
+  },
+
+  // 删除通知
+  deleteNotification(id) {
+    return request.delete(`/v1/notifications/${id}`)
+  },
+
+  // 通知偏好
+  getPreferences() {
+    return request.get('/v1/notifications/preferences')
+  },
+  updatePreferences(prefs) {
+    return request.put('/v1/notifications/preferences', prefs)
+  },
+
+  // 上报浏览器中 AI 任务的进度，转发给当前用户的其他会话：{ jobId, status, progress, message }
+  reportJobProgress(data) {
+    return request.post('/v1/ws/job-progress', data)
+  }
+}
+
+export const followApi = {
+  follow(userId) {
+    return request.post(`/v1/users/${userId}/follow`)
+  },
+  unfollow(userId) {
+    return request.delete(`/v1/users/${userId}/follow`)
+  },
+  getFollowStats(userId) {
+    return request.get(`/v1/users/${userId}/follow-stats`)
+  },
+  getFollowers(userId, params) {
+    return request.get(`/v1/users/${userId}/followers`, { params })
+  },
+  getFollowing(userId, params) {
+    return request.get(`/v1/users/${userId}/following`, { params })
+  }
+}
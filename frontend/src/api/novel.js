@@ -9,6 +9,38 @@ export const novelApi = {
   getNovelList(params) {
     return request.get('/v1/novels', { params })
   },
+  // 全文搜索：q、category、status、tags（逗号分隔）、minWords、maxWords、page、limit
+  searchNovels(params) {
+    return request.get('/v1/search', { params })
+  },
+  // 搜索框输入建议：q 为前缀或拼音首字母，type 可选 novel/author/tag/character
+  getSearchSuggestions(params) {
+    return request.get('/v1/search/suggest', { params })
+  },
+  // 排行榜：board 为 read/favorite/rising/completed/rated，params 包含 period、category、limit
+  getRanking(board, params) {
+    return request.get(`/v1/rankings/${board}`, { params })
+  },
+  // 获取分类树及各分类下的小说数
+  getCategories() {
+    return request.get('/v1/categories')
+  },
+  // 获取标签列表，可按分类过滤或按前缀搜索
+  getTags(params) {
+    return request.get('/v1/tags', { params })
+  },
+  // 获取标签详情，同义写法返回对应的规范标签
+  getTag(name) {
+    return request.get(`/v1/tags/${encodeURIComponent(name)}`)
+  },
+  // 按标签浏览小说，返回同时带有所有标签的小说
+  getNovelsByTags(tags, params) {
+    return request.get('/v1/tags/novels', { params: { ...params, tags: tags.join(',') } })
+  },
+  // 获取个性化推荐，未登录时返回热门小说
+  getRecommendations(params) {
+    return request.get('/v1/recommendations', { params })
+  },
   // 获取小说详情
   getNovelDetail(id) {
     return request.get(`/v1/novels/${id}`)
@@ -108,6 +140,100 @@ export const novelApi = {
     })
   },
 
+  // 获取小说评分汇总
+  getNovelRating(novelId) {
+    return request.get(`/v1/novels/${novelId}/rating`)
+  },
+
+  // 获取书评列表，sort 为 helpful 或 latest
+  getReviews(novelId, params) {
+    return request.get(`/v1/novels/${novelId}/reviews`, { params })
+  },
+
+  // 获取 / 发表或修改 / 删除我的书评
+  getMyReview(novelId) {
+    return request.get(`/v1/novels/${novelId}/review`)
+  },
+  saveReview(novelId, data) {
+    return request.put(`/v1/novels/${novelId}/review`, data)
+  },
+  deleteReview(novelId) {
+    return request.delete(`/v1/novels/${novelId}/review`)
+  },
+
+  // 书评投“有用” / 取消
+  voteReviewHelpful(reviewId) {
+    return request.post(`/v1/reviews/${reviewId}/helpful`)
+  },
+  unvoteReviewHelpful(reviewId) {
+    return request.delete(`/v1/reviews/${reviewId}/helpful`)
+  },
+
+  // 获取阅读历史
+  getReadingHistory(params) {
+    return request.get('/v1/user/history', { params })
+  },
+
+  // 删除单本小说的阅读记录
+  deleteReadingHistory(novelId) {
+    return request.delete(`/v1/user/history/${novelId}`)
+  },
+
+  // 清空阅读历史
+  clearReadingHistory() {
+    return request.delete('/v1/user/history')
+  },
+
+  // 添加书签
+  createBookmark(data) {
+    return request.post('/v1/bookmarks', data)
+  },
+
+  // 获取书签列表
+  getBookmarks(params) {
+    return request.get('/v1/bookmarks', { params })
+  },
+
+  // 修改书签颜色、备注和公开状态
+  updateBookmark(id, data) {
+    return request.put(`/v1/bookmarks/${id}`, data)
+  },
+
+  // 删除书签
+  deleteBookmark(id) {
+    return request.delete(`/v1/bookmarks/${id}`)
+  },
+
+  // 添加划线笔记
+  createAnnotation(data) {
+    return request.post('/v1/annotations', data)
+  },
+
+  // 修改划线笔记
+  updateAnnotation(id, data) {
+    return request.put(`/v1/annotations/${id}`, data)
+  },
+
+  // 删除划线笔记
+  deleteAnnotation(id) {
+    return request.delete(`/v1/annotations/${id}`)
+  },
+
+  // 获取章节内的划线笔记
+  getChapterAnnotations(chapterId) {
+    return request.get(`/v1/annotations/chapter/${chapterId}`)
+  },
+
+  // 获取我的全部笔记
+  getMyAnnotations(params) {
+    return request.get('/v1/annotations', { params })
+  },
+
+  // 导出笔记
+  exportAnnotations(params) {
+    return request.get('/v1/annotations/export', { params, responseType: 'blob' })
+  },
+
   // AI 辅助写作
   getAIAssistance(prompt) {
     return request.post('/ai/assist', { prompt })
@@ -154,6 +280,49 @@ export const novelApi = {
     return request.put(`/v1/novels/${novelId}/outline`, data)
   },
 
+  // 获取大纲历史版本
+  getOutlineVersions(novelId, params) {
+    return request.get(`/v1/novels/${novelId}/outline/versions`, { params })
+  },
+
+  // 获取指定版本大纲
+  getOutlineVersion(novelId, version) {
+    return request.get(`/v1/novels/${novelId}/outline/versions/${version}`)
+  },
+
+  // 比较大纲版本
+  diffOutlineVersions(novelId, params) {
+    return request.get(`/v1/novels/${novelId}/outline/diff`, { params })
+  },
+
+  // 恢复大纲版本
+  restoreOutlineVersion(novelId, version) {
+    return request.post(`/v1/novels/${novelId}/outline/versions/${version}/restore`)
+  },
+
+  // 导出小说大纲
+  exportNovelOutline(novelId, params) {
+    return request.get(`/v1/novels/${novelId}/outline/export`, { params, responseType: 'blob' })
+  },
+
+  // 预览大纲导入
+  previewOutlineImport(novelId, formData) {
+    return request.post(`/v1/novels/${novelId}/outline/import/preview`, formData, {
+      headers: {
+        'Content-Type': 'multipart/form-data'
+      }
+    })
+  },
+
+  // 导入大纲
+  importNovelOutline(novelId, formData) {
+    return request.post(`/v1/novels/${novelId}/outline/import`, formData, {
+      headers: {
+        'Content-Type': 'multipart/form-data'
+      }
+    })
+  },
+
   // 更新小说状态
   updateNovelStatus: async (novelId, status) => {
     const response = await request.put(`/v1/novels/${novelId}/status`, { status })
@@ -40,5 +40,28 @@ export const userApi = {
         'Content-Type': 'multipart/form-data'
       }
     })
+  },
+
+  // OPDS 书库订阅令牌，供电子阅读器使用
+  getFeedToken() {
+    return api.get('/user/feed-token')
+  },
+
+  rotateFeedToken() {
+    return api.post('/user/feed-token')
+  },
+
+  revokeFeedToken() {
+    return api.delete('/user/feed-token')
+  },
+
+  // 账号安全记录：登录、修改密码和邮箱等
+  getSecurityActivity(params) {
+    return api.get('/user/security-activity', { params })
+  },
+
+  // 自己被送审的内容及审核结果，驳回时包含原因
+  getModerationItems(params) {
+    return api.get('/user/moderation', { params })
   }
 } 
\ No newline at end of file
@@ -0,0 +1,28 @@
+// src/utils/category.js
+import { novelApi } from '../api/novel'
+
+/**
+ * 把分类树展开为下拉选项，子分类缩进显示
+ * @param {Array} tree 分类树
+ * @param {number} [depth=0] 当前层级
+ * @returns {Array<{value: string, label: string, count: number}>}
+ */
+export const flattenCategories = (tree, depth = 0) => {
+  return tree.flatMap(category => [
+    {
+      value: category.slug,
+      label: `${'　'.repeat(depth)}${category.icon ? category.icon + ' ' : ''}${category.name}`,
+      count: category.novelCount
+    },
+    ...flattenCategories(category.children || [], depth + 1)
+  ])
+}
+
+/**
+ * 加载分类下拉选项
+ * @returns {Promise<Array<{value: string, label: string, count: number}>>}
+ */
+export const loadCategoryOptions = async () => {
+  const response = await novelApi.getCategories()
+  return flattenCategories(response.categories)
+}
@@ -0,0 +1,63 @@
+// 实时推送：建立 WebSocket 连接，断线后自动重连并通过 lastEventId 续传
+const WS_URL = 'ws://localhost:8080/api/v1/ws'
+const HEARTBEAT_TIMEOUT = 60000
+const MAX_RETRY_DELAY = 30000
+
+export function connectRealtime(token, handlers = {}) {
+  let socket = null
+  let lastEventId = localStorage.getItem('realtimeLastEventId') || ''
+  let retry = 0
+  let closed = false
+  let heartbeatTimer = null
+  let reconnectTimer = null
+
+  const resetHeartbeat = () => {
+    clearTimeout(heartbeatTimer)
+    // 长时间没有收到心跳时认为连接已失效，主动断开触发重连
+    heartbeatTimer = setTimeout(() => socket && socket.close(), HEARTBEAT_TIMEOUT)
+  }
+
+  const scheduleReconnect = () => {
+    if (closed) return
+    const delay = Math.min(1000 * 2 ** retry, MAX_RETRY_DELAY)
+    retry++
+    reconnectTimer = setTimeout(open, delay)
+  }
+
+  const open = () => {
+    const params = new URLSearchParams()
+    if (lastEventId) params.set('lastEventId', lastEventId)
+    // token 通过子协议传递，不出现在 URL 和服务端访问日志中
+    socket = new WebSocket(`${WS_URL}?${params}`, ['bearer', token])
+
+    socket.onopen = () => {
+      retry = 0
+      resetHeartbeat()
+    }
+    socket.onmessage = (message) => {
+      resetHeartbeat()
+      const event = JSON.parse(message.data)
+      if (event.id) {
+        lastEventId = event.id
+        localStorage.setItem('realtimeLastEventId', event.id)
+      }
+      const handler = handlers[event.type]
+      if (handler) handler(event.data, event)
+    }
+    socket.onclose = () => {
+      clearTimeout(heartbeatTimer)
+      scheduleReconnect()
+    }
+  }
+
+  open()
+
+  return {
+    close() {
+      closed = true
+      clearTimeout(heartbeatTimer)
+      clearTimeout(reconnectTimer)
+      if (socket) socket.close()
+    }
+  }
+}